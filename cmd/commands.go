@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	common "github.com/cloudtrust/common-healthcheck"
+	flaki_gen "github.com/cloudtrust/flaki"
+	"github.com/cloudtrust/flaki-service/internal/flakid"
+	"github.com/cloudtrust/flaki-service/pkg/flaki"
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const usage = `Usage: flakid [command] [flags]
+
+Commands:
+  serve            start the service (default)
+  migrate          apply the schema migrations to the cockroach DB
+  version          print the version
+  config check     validate and print the effective configuration
+  config defaults  print the default configuration
+  decode <id>      print the time, sequence, node and component IDs of a flaki ID
+  gen -n N         generate N IDs with the configured node and component IDs
+  healthcheck      query the health of the running instance, exit with status 1 if it is unhealthy
+
+Flags:
+`
+
+func main() {
+	pflag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		pflag.PrintDefaults()
+	}
+	var count = pflag.IntP("count", "n", 1, "number of IDs generated by the gen command")
+	var timeout = pflag.Duration("timeout", 5*time.Second, "timeout of the healthcheck command")
+
+	// The flags are parsed when the configuration is loaded. Nothing is logged: the commands other
+	// than serve only print their result, serve loads and logs the configuration again.
+	var v, configErr = config(log.NewNopLogger())
+
+	switch pflag.Arg(0) {
+	case "", "serve", "migrate":
+		serve()
+		return
+	}
+
+	var err = runCommand(os.Stdout, pflag.Args(), v, configErr, *count, *timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flakid: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runCommand runs the command 'args', other than serve and migrate, and prints its result to w. The
+// loaded configuration and its error are only used by the commands that need them.
+func runCommand(w io.Writer, args []string, v *viper.Viper, configErr error, count int, timeout time.Duration) error {
+	var arg = func(i int) string {
+		if i < len(args) {
+			return args[i]
+		}
+		return ""
+	}
+
+	switch command := arg(0); command {
+	case "version":
+		return printVersion(w)
+	case "config":
+		switch arg(1) {
+		case "check":
+			return checkConfig(w, v, configErr)
+		case "defaults":
+			return printConfig(w, defaultConfig())
+		default:
+			return fmt.Errorf("unknown config command '%s', expected 'check' or 'defaults'", arg(1))
+		}
+	case "decode":
+		return decode(w, arg(1))
+	case "gen":
+		var c, err = validConfig(v, configErr)
+		if err != nil {
+			return err
+		}
+		return gen(w, c, count)
+	case "healthcheck":
+		var c, err = validConfig(v, configErr)
+		if err != nil {
+			return err
+		}
+		return healthcheck(w, c, timeout)
+	default:
+		return fmt.Errorf("unknown command '%s', see 'flakid --help'", command)
+	}
+}
+
+// printVersion prints the build information, as returned by the HTTP route '/'.
+func printVersion(w io.Writer) error {
+	var infos = struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+		Env     string `json:"environment"`
+		Commit  string `json:"commit"`
+	}{
+		Name:    ComponentName,
+		Version: Version,
+		Env:     Environment,
+		Commit:  GitCommit,
+	}
+
+	var j, _ = json.MarshalIndent(infos, "", "  ")
+	var _, err = fmt.Fprintln(w, string(j))
+	return err
+}
+
+// validConfig decodes and validates the loaded configuration. All the invalid values are
+// reported in the returned error, one per line.
+func validConfig(v *viper.Viper, err error) (flakid.Config, error) {
+	if err != nil {
+		return flakid.Config{}, err
+	}
+
+	var c, errs = loadConfig(v)
+	if len(errs) != 0 {
+		var msgs = []string{}
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		return flakid.Config{}, fmt.Errorf("invalid configuration:\n%s", strings.Join(msgs, "\n"))
+	}
+	return c, nil
+}
+
+// checkConfig validates the effective configuration, i.e. the configuration file with the
+// environment variables and flags overrides, and prints it.
+func checkConfig(w io.Writer, v *viper.Viper, err error) error {
+	_, err = validConfig(v, err)
+	if err != nil {
+		return err
+	}
+	return printConfig(w, v)
+}
+
+// printConfig prints the configuration as YAML, without the secrets and the derived keys. The
+// output can be used as configuration file.
+func printConfig(w io.Writer, v *viper.Viper) error {
+	var settings = redactedSettings(v)
+	for k := range derivedKeys {
+		delete(settings, k)
+	}
+
+	var out, err = yaml.Marshal(settings)
+	if err != nil {
+		return errors.Wrap(err, "could not encode configuration")
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// decode prints the decoded flaki ID.
+func decode(w io.Writer, id string) error {
+	if id == "" {
+		return fmt.Errorf("missing ID, usage: flakid decode <id>")
+	}
+
+	var decoded, err = flaki.DecodeID(id)
+	if err != nil {
+		return err
+	}
+
+	var j, _ = json.MarshalIndent(decoded, "", "  ")
+	_, err = fmt.Fprintln(w, string(j))
+	return err
+}
+
+// gen generates n IDs locally, with the node and component IDs of the configuration. They are only
+// unique if no running instance uses the same node and component IDs.
+func gen(w io.Writer, c flakid.Config, n int) error {
+	if n <= 0 {
+		return fmt.Errorf("invalid count %d, it must be positive", n)
+	}
+
+	var flakiGen, err = flaki_gen.New(flaki_gen.ComponentID(uint64(c.FlakiComponentID)), flaki_gen.NodeID(uint64(c.FlakiNodeID)))
+	if err != nil {
+		return errors.Wrap(err, "could not create Flaki generator")
+	}
+
+	for i := 0; i < n; i++ {
+		if _, err = fmt.Fprintln(w, flakiGen.NextValidIDString()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// healthcheck reads the health reports of the instance listening on component-http-host-port and
+// prints them. It returns an error if the instance does not answer or if a unit is KO, so it can be
+// used as container health check.
+func healthcheck(w io.Writer, c flakid.Config, timeout time.Duration) error {
+	var host, port, err = net.SplitHostPort(c.HTTPHostPort)
+	if err != nil {
+		return errors.Wrapf(err, "invalid HTTP address '%s'", c.HTTPHostPort)
+	}
+	// The service listens on all the interfaces, it is queried locally.
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	var url = fmt.Sprintf("http://%s/health", net.JoinHostPort(host, port))
+
+	var client = &http.Client{Timeout: timeout}
+	var resp *http.Response
+	resp, err = client.Get(url)
+	if err != nil {
+		return errors.Wrapf(err, "could not query '%s'", url)
+	}
+	defer resp.Body.Close()
+
+	var body []byte
+	body, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "could not read reply of '%s'", url)
+	}
+	fmt.Fprintln(w, string(body))
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unhealthy, '%s' returned status %d", url, resp.StatusCode)
+	}
+
+	var ko, parseErr = koUnits(body)
+	switch {
+	case parseErr != nil:
+		return parseErr
+	case len(ko) != 0:
+		return fmt.Errorf("unhealthy, units KO: %s", strings.Join(ko, ", "))
+	}
+	return nil
+}
+
+// koUnits returns the units of the health reply that have a KO report, sorted by name. The reply
+// of a unit is either the list of its reports, or a single report if they could not be read.
+func koUnits(reply []byte) ([]string, error) {
+	type report struct {
+		Status string `json:"status"`
+	}
+
+	var units map[string]json.RawMessage
+	var err = json.Unmarshal(reply, &units)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode health reports")
+	}
+
+	var ko = []string{}
+	for unit, raw := range units {
+		var reports []report
+		if json.Unmarshal(raw, &reports) != nil {
+			var r report
+			if err = json.Unmarshal(raw, &r); err != nil {
+				return nil, errors.Wrapf(err, "could not decode health reports of '%s'", unit)
+			}
+			reports = []report{r}
+		}
+
+		for _, r := range reports {
+			if r.Status == common.KO.String() {
+				ko = append(ko, unit)
+				break
+			}
+		}
+	}
+	sort.Strings(ko)
+	return ko, nil
+}
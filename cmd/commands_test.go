@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/flaki-service/internal/flakid"
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestRunCommand(t *testing.T) {
+	var v = defaultConfig()
+
+	// Unknown commands.
+	var err = runCommand(&bytes.Buffer{}, []string{"unknown"}, v, nil, 1, time.Second)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unknown command 'unknown'")
+	err = runCommand(&bytes.Buffer{}, []string{"config"}, v, nil, 1, time.Second)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unknown config command ''")
+
+	// The commands that need the configuration fail if it could not be loaded.
+	err = runCommand(&bytes.Buffer{}, []string{"gen"}, nil, fmt.Errorf("could not read configuration file"), 1, time.Second)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "could not read configuration file")
+
+	// The commands print to the writer.
+	var out = &bytes.Buffer{}
+	assert.Nil(t, runCommand(out, []string{"gen"}, v, nil, 3, time.Second))
+	assert.Len(t, strings.Split(strings.TrimSpace(out.String()), "\n"), 3)
+}
+
+func TestPrintVersion(t *testing.T) {
+	var out = &bytes.Buffer{}
+	assert.Nil(t, printVersion(out))
+
+	var infos map[string]string
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &infos))
+	assert.Equal(t, ComponentName, infos["name"])
+	assert.Equal(t, Version, infos["version"])
+	assert.Equal(t, Environment, infos["environment"])
+	assert.Equal(t, GitCommit, infos["commit"])
+}
+
+func TestCheckConfig(t *testing.T) {
+	// Valid configuration.
+	var v = defaultConfig()
+	var out = &bytes.Buffer{}
+	assert.Nil(t, checkConfig(out, v, nil))
+	var settings map[string]interface{}
+	assert.Nil(t, yaml.Unmarshal(out.Bytes(), &settings))
+	assert.Equal(t, v.GetString("component-http-host-port"), settings["component-http-host-port"])
+
+	// Invalid configuration, all the invalid values are reported and nothing is printed.
+	v.Set("flaki-node-id", -1)
+	v.Set("log-level", "verbose")
+	out.Reset()
+	var err = checkConfig(out, v, nil)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "'flaki-node-id'")
+	assert.Contains(t, err.Error(), "'log-level'")
+	assert.Empty(t, out.String())
+
+	// Configuration that could not be loaded.
+	err = checkConfig(out, nil, fmt.Errorf("could not read configuration file"))
+	assert.NotNil(t, err)
+	assert.Empty(t, out.String())
+}
+
+func TestPrintConfig(t *testing.T) {
+	var v = defaultConfig()
+	v.Set("redis-password", "p4ssw0rd")
+	v.Set("sentry-dsn", "https://key@sentry/1")
+	for k := range derivedKeys {
+		v.Set(k, true)
+	}
+
+	var out = &bytes.Buffer{}
+	assert.Nil(t, printConfig(out, v))
+	assert.NotContains(t, out.String(), "p4ssw0rd")
+	assert.NotContains(t, out.String(), "https://key@sentry/1")
+
+	var settings map[string]interface{}
+	assert.Nil(t, yaml.Unmarshal(out.Bytes(), &settings))
+	assert.Equal(t, flakid.Redacted, settings["redis-password"])
+	assert.Equal(t, flakid.Redacted, settings["sentry-dsn"])
+	for k := range derivedKeys {
+		assert.NotContains(t, settings, k)
+	}
+
+	// The defaults have no secrets.
+	out.Reset()
+	assert.Nil(t, printConfig(out, defaultConfig()))
+	settings = nil
+	assert.Nil(t, yaml.Unmarshal(out.Bytes(), &settings))
+	for _, k := range flakid.SecretKeys {
+		assert.Equal(t, "", settings[k], k)
+	}
+	for k := range derivedKeys {
+		assert.NotContains(t, settings, k)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	// Missing ID.
+	var out = &bytes.Buffer{}
+	var err = decode(out, "")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "missing ID")
+
+	// Invalid IDs.
+	for _, id := range []string{"flaki", "-1", "18446744073709551616"} {
+		assert.NotNil(t, decode(out, id), id)
+	}
+	assert.Empty(t, out.String())
+}
+
+func TestGen(t *testing.T) {
+	var c = flakid.Config{FlakiNodeID: 1, FlakiComponentID: 2}
+
+	// Invalid counts.
+	var out = &bytes.Buffer{}
+	for _, n := range []int{0, -1} {
+		var err = gen(out, c, n)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "invalid count")
+	}
+	assert.Empty(t, out.String())
+
+	// One ID per line.
+	assert.Nil(t, gen(out, c, 5))
+	var ids = strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.Len(t, ids, 5)
+	var unique = map[string]bool{}
+	for _, id := range ids {
+		assert.NotEmpty(t, id)
+		unique[id] = true
+	}
+	assert.Len(t, unique, 5)
+}
+
+func TestKOUnits(t *testing.T) {
+	// All units OK.
+	var ko, err = koUnits([]byte(`{"influx":[{"name":"ping","status":"OK"}],"redis":[{"name":"ping","status":"Deactivated"}]}`))
+	assert.Nil(t, err)
+	assert.Empty(t, ko)
+
+	// The units with a KO report are returned sorted by name.
+	ko, err = koUnits([]byte(`{"sentry":[{"name":"ping","status":"OK"},{"name":"dsn","status":"KO"}],"influx":[{"name":"ping","status":"KO"}],"redis":[{"name":"ping","status":"OK"}]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"influx", "sentry"}, ko)
+
+	// A single report, when the reports of the unit could not be read.
+	ko, err = koUnits([]byte(`{"jaeger":{"name":"jaeger","status":"KO","error":"could not read reports"}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"jaeger"}, ko)
+
+	// Invalid replies.
+	_, err = koUnits([]byte(`not json`))
+	assert.NotNil(t, err)
+	_, err = koUnits([]byte(`{"influx":"OK"}`))
+	assert.NotNil(t, err)
+}
+
+func TestHealthcheck(t *testing.T) {
+	var status = http.StatusOK
+	var reply = `{"influx":[{"name":"ping","status":"OK"}]}`
+	var s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/health", r.URL.Path)
+		w.WriteHeader(status)
+		fmt.Fprint(w, reply)
+	}))
+	defer s.Close()
+
+	// The instance listens on all the interfaces, it is queried locally.
+	var port = s.URL[strings.LastIndex(s.URL, ":")+1:]
+	var c = flakid.Config{HTTPHostPort: "0.0.0.0:" + port}
+
+	// Healthy.
+	var out = &bytes.Buffer{}
+	assert.Nil(t, healthcheck(out, c, time.Second))
+	assert.Equal(t, reply+"\n", out.String())
+
+	// Unit KO.
+	reply = `{"influx":[{"name":"ping","status":"KO"}]}`
+	var err = healthcheck(&bytes.Buffer{}, c, time.Second)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "influx")
+
+	// Error status.
+	status = http.StatusInternalServerError
+	err = healthcheck(&bytes.Buffer{}, c, time.Second)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "500")
+
+	// Invalid reply.
+	status = http.StatusOK
+	reply = `not json`
+	assert.NotNil(t, healthcheck(&bytes.Buffer{}, c, time.Second))
+
+	// Invalid address.
+	assert.NotNil(t, healthcheck(&bytes.Buffer{}, flakid.Config{HTTPHostPort: "flaki"}, time.Second))
+
+	// No instance.
+	s.Close()
+	assert.NotNil(t, healthcheck(&bytes.Buffer{}, c, time.Second))
+}
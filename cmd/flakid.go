@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"os"
 	"os/signal"
 	"sort"
+	"strings"
 	"syscall"
 	"time"
 
@@ -18,16 +20,16 @@ import (
 	flaki_gen "github.com/cloudtrust/flaki"
 	"github.com/cloudtrust/flaki-service/api/fb"
 	"github.com/cloudtrust/flaki-service/internal/flakid"
+	"github.com/cloudtrust/flaki-service/internal/migration"
 	"github.com/cloudtrust/flaki-service/internal/redis"
 	"github.com/cloudtrust/flaki-service/pkg/flaki"
 	"github.com/cloudtrust/flaki-service/pkg/health"
 	health_job "github.com/cloudtrust/flaki-service/pkg/job"
 	"github.com/cloudtrust/go-jobs"
-	"github.com/cloudtrust/go-jobs/job"
 	job_lock "github.com/cloudtrust/go-jobs/lock"
 	job_status "github.com/cloudtrust/go-jobs/status"
 	"github.com/coreos/go-systemd/dbus"
-	sentry "github.com/getsentry/raven-go"
+	"github.com/fsnotify/fsnotify"
 	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/metrics"
@@ -39,10 +41,12 @@ import (
 	influx "github.com/influxdata/influxdb/client/v2"
 	_ "github.com/lib/pq"
 	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	jaeger "github.com/uber/jaeger-client-go/config"
-	"golang.org/x/time/rate"
+	otelbridge "go.opentelemetry.io/otel/bridge/opentracing"
+	"go.opentelemetry.io/otel/propagation"
 	"google.golang.org/grpc"
 )
 
@@ -64,11 +68,22 @@ const (
 	jaegerKey = "jaeger"
 	redisKey  = "redis"
 	sentryKey = "sentry"
+	cleanKey  = "clean"
 )
 
-func main() {
+// serve starts the service, it is the default command.
+func serve() {
 
 	// Logger.
+	// Exit status of flakid, set on failure. The process exits after the other deferred calls,
+	// so the log sinks are flushed.
+	var exitStatus = 0
+	defer func() {
+		if exitStatus != 0 {
+			os.Exit(exitStatus)
+		}
+	}()
+
 	var logger = log.NewJSONLogger(os.Stdout)
 	{
 		logger = log.With(logger, "ts", log.DefaultTimestampUTC, "caller", log.DefaultCaller)
@@ -76,93 +91,256 @@ func main() {
 	defer logger.Log("msg", "goodbye")
 
 	// Configurations.
-	var c = config(log.With(logger, "unit", "config"))
+	var c flakid.Config
+	var secrets map[string]*flakid.Secret
+	{
+		var v, err = config(log.With(logger, "unit", "config"))
+		if err != nil {
+			logger.Log("msg", "could not load configuration", "error", err)
+			os.Exit(1)
+		}
+		logConfig(v, log.With(logger, "unit", "config"))
+
+		var errs []error
+		c, errs = loadConfig(v)
+		if len(errs) == 0 {
+			secrets, errs = c.Secrets()
+		}
+		if len(errs) != 0 {
+			for _, err := range errs {
+				logger.Log("msg", "invalid configuration", "error", err)
+			}
+			os.Exit(1)
+		}
+	}
 	var (
 		// Component
-		grpcAddr = c.GetString("component-grpc-host-port")
-		httpAddr = c.GetString("component-http-host-port")
+		grpcAddr = c.GRPCHostPort
+		httpAddr = c.HTTPHostPort
 
 		// Flaki
-		flakiNodeID      = uint64(c.GetInt("flaki-node-id"))
-		flakiComponentID = uint64(c.GetInt("flaki-component-id"))
+		flakiNodeID      = uint64(c.FlakiNodeID)
+		flakiComponentID = uint64(c.FlakiComponentID)
 
 		// Enabled units
-		cockroachEnabled  = c.GetBool("cockroach")
-		influxEnabled     = c.GetBool("influx")
-		jaegerEnabled     = c.GetBool("jaeger")
-		redisEnabled      = c.GetBool("redis")
-		sentryEnabled     = c.GetBool("sentry")
-		pprofRouteEnabled = c.GetBool("pprof-route-enabled")
+		cockroachEnabled    = c.Cockroach
+		influxEnabled       = c.Influx
+		jaegerEnabled       = c.Jaeger
+		otelEnabled         = c.OTel
+		redisEnabled        = c.Redis
+		logFileEnabled      = c.LogFile
+		logstashEnabled     = c.LogLogstash
+		gelfEnabled         = c.LogGELF
+		syslogEnabled       = c.LogSyslog
+		sentryEnabled       = c.Sentry
+		trackingFileEnabled = c.TrackingFile
+		pprofRouteEnabled   = c.PprofRouteEnabled
 
 		// Influx
 		influxHTTPConfig = influx.HTTPConfig{
-			Addr:     fmt.Sprintf("http://%s", c.GetString("influx-host-port")),
-			Username: c.GetString("influx-username"),
-			Password: c.GetString("influx-password"),
+			Addr:     fmt.Sprintf("http://%s", c.InfluxHostPort),
+			Username: c.InfluxUsername,
+			Password: secrets["influx-password"].Value(),
 		}
 		influxBatchPointsConfig = influx.BatchPointsConfig{
-			Precision:        c.GetString("influx-precision"),
-			Database:         c.GetString("influx-database"),
-			RetentionPolicy:  c.GetString("influx-retention-policy"),
-			WriteConsistency: c.GetString("influx-write-consistency"),
+			Precision:        c.InfluxPrecision,
+			Database:         c.InfluxDatabase,
+			RetentionPolicy:  c.InfluxRetentionPolicy,
+			WriteConsistency: c.InfluxWriteConsistency,
 		}
-		influxWriteInterval = c.GetDuration("influx-write-interval")
+		influxWriteInterval = c.InfluxWriteInterval
 
 		// Jaeger
 		jaegerConfig = jaeger.Configuration{
 			Disabled: !jaegerEnabled,
 			Sampler: &jaeger.SamplerConfig{
-				Type:              c.GetString("jaeger-sampler-type"),
-				Param:             c.GetFloat64("jaeger-sampler-param"),
-				SamplingServerURL: fmt.Sprintf("http://%s", c.GetString("jaeger-sampler-host-port")),
+				Type:              c.JaegerSamplerType,
+				Param:             c.JaegerSamplerParam,
+				SamplingServerURL: fmt.Sprintf("http://%s", c.JaegerSamplerHostPort),
 			},
 			Reporter: &jaeger.ReporterConfig{
-				LogSpans:            c.GetBool("jaeger-reporter-logspan"),
-				BufferFlushInterval: c.GetDuration("jaeger-write-interval"),
+				LogSpans:            c.JaegerReporterLogSpan,
+				BufferFlushInterval: c.JaegerWriteInterval,
 			},
 		}
-		jaegerCollectorHealthcheckURL = c.GetString("jaeger-collector-healthcheck-host-port")
+		jaegerCollectorHealthcheckURL = c.JaegerCollectorHealthcheckHostPort
+		tracingPropagators            = c.TracingPropagators
+
+		// OpenTelemetry
+		otelConfig = flakid.OTelConfig{
+			Protocol:     c.OTelProtocol,
+			HostPort:     c.OTelHostPort,
+			Insecure:     c.OTelInsecure,
+			Sampler:      c.OTelSampler,
+			SamplerRatio: c.OTelSamplerRatio,
+			Attributes:   c.OTelResourceAttributes,
+		}
+
+		// Error tracking
+		sentryDSN           = secrets["sentry-dsn"].Value()
+		trackingFilePath    = c.TrackingFilePath
+		trackingBreadcrumbs = c.TrackingBreadcrumbs
 
-		// Sentry
-		sentryDSN = c.GetString("sentry-dsn")
+		// Secrets
+		secretReloadInterval = c.SecretReloadInterval
+
+		// Configuration reloading
+		configWatch = c.ConfigWatch
 
 		// Redis
-		redisURL           = c.GetString("redis-host-port")
-		redisPassword      = c.GetString("redis-password")
-		redisDatabase      = c.GetInt("redis-database")
-		redisWriteInterval = c.GetDuration("redis-write-interval")
+		redisConfig = redis.Config{
+			HostPort:     c.RedisHostPort,
+			Password:     secrets["redis-password"].Value(),
+			Database:     c.RedisDatabase,
+			MaxIdle:      c.RedisMaxIdle,
+			MaxActive:    c.RedisMaxActive,
+			IdleTimeout:  c.RedisIdleTimeout,
+			DialTimeout:  c.RedisDialTimeout,
+			ReadTimeout:  c.RedisReadTimeout,
+			WriteTimeout: c.RedisWriteTimeout,
+			Timeout:      c.RedisTimeout,
+			FailFast:     c.RedisFailFast,
+			Backoff: redis.Backoff{
+				Initial:    c.RedisBackoffInitial,
+				Max:        c.RedisBackoffMax,
+				Multiplier: c.RedisBackoffMultiplier,
+				Jitter:     c.RedisBackoffJitter,
+			},
+		}
+		redisWriteInterval  = c.RedisWriteInterval
+		redisLogQueueSize   = c.RedisLogQueueSize
+		redisLogBatchSize   = c.RedisLogBatchSize
+		redisLogDropPolicy  = c.RedisLogDropPolicy
+		redisLogSpillTarget = c.RedisLogSpillTarget
+		redisLogSchema      = c.RedisLogSchema
+
+		// Log sinks
+		logLevel          = c.LogLevel
+		logFilePath       = c.LogFilePath
+		logFileMaxSize    = c.LogFileMaxSize
+		logFileMaxAge     = c.LogFileMaxAge
+		logFileMaxBackups = c.LogFileMaxBackups
+		logFileCompress   = c.LogFileCompress
+		logstashNetwork   = c.LogLogstashNetwork
+		logstashAddr      = c.LogLogstashHostPort
+		logstashSchema    = c.LogLogstashSchema
+		logstashTimeout   = c.LogLogstashTimeout
+		gelfAddr          = c.LogGELFHostPort
+		syslogFacility    = c.LogSyslogFacility
+		syslogTag         = c.LogSyslogTag
+
+		// Log sampling, per flaki endpoint.
+		logSamplingRules = map[string]flakid.SamplingRule{
+			"NextID": {
+				Rate:          c.LogSamplingNextIDRate,
+				SlowThreshold: c.LogSamplingNextIDSlowThreshold,
+			},
+			"NextValidID": {
+				Rate:          c.LogSamplingNextValidIDRate,
+				SlowThreshold: c.LogSamplingNextValidIDSlowThreshold,
+			},
+		}
 
 		// Cockroach
-		cockroachHostPort      = c.GetString("cockroach-host-port")
-		cockroachUsername      = c.GetString("cockroach-username")
-		cockroachPassword      = c.GetString("cockroach-password")
-		cockroachDB            = c.GetString("cockroach-database")
-		cockroachCleanInterval = c.GetDuration("cockroach-clean-interval")
+		cockroachConfig = flakid.CockroachConfig{
+			HostPort:         c.CockroachHostPort,
+			Username:         c.CockroachUsername,
+			Password:         secrets["cockroach-password"].Value(),
+			Database:         c.CockroachDatabase,
+			SSLMode:          c.CockroachSSLMode,
+			SSLRootCert:      c.CockroachSSLRootCert,
+			SSLCert:          c.CockroachSSLCert,
+			SSLKey:           c.CockroachSSLKey,
+			StatementTimeout: c.CockroachStatementTimeout,
+		}
+		cockroachPoolConfig = flakid.CockroachPoolConfig{
+			MaxOpenConns:    c.CockroachMaxOpenConns,
+			MaxIdleConns:    c.CockroachMaxIdleConns,
+			ConnMaxLifetime: c.CockroachConnMaxLifetime,
+		}
+		cockroachDialect      = c.CockroachDialect
+		cockroachMaxRetries   = c.CockroachMaxRetries
+		cockroachRetryBackoff = c.CockroachRetryBackoff
 
 		// Jobs
-		healthChecksValidity = map[string]time.Duration{
-			influxKey: c.GetDuration("job-influx-health-validity"),
-			jaegerKey: c.GetDuration("job-jaeger-health-validity"),
-			redisKey:  c.GetDuration("job-redis-health-validity"),
-			sentryKey: c.GetDuration("job-sentry-health-validity"),
-		}
+		healthChecksValidity      = validities(c)
+		jobSchedules              = schedules(c)
+		jobFailureReportThreshold = c.JobFailureReportThreshold
 
 		// Rate limiting
-		rateLimit = map[string]int{
-			"nextID":           c.GetInt("rate-next-id"),
-			"nextValidID":      c.GetInt("rate-next-valid-id"),
-			"influxHealthExec": c.GetInt("rate-influx-health-exec"),
-			"influxHealthRead": c.GetInt("rate-influx-health-read"),
-			"jaegerHealthExec": c.GetInt("rate-jaeger-health-exec"),
-			"jaegerHealthRead": c.GetInt("rate-jaeger-health-read"),
-			"redisHealthExec":  c.GetInt("rate-redis-health-exec"),
-			"redisHealthRead":  c.GetInt("rate-redis-health-read"),
-			"sentryHealthExec": c.GetInt("rate-sentry-health-exec"),
-			"sentryHealthRead": c.GetInt("rate-sentry-health-read"),
-			"allHealth":        c.GetInt("rate-all-health"),
-		}
+		rateLimit = rateLimits(c)
 	)
 
+	// Mode.
+	switch mode := pflag.Arg(0); mode {
+	case "", "serve":
+		// Start the service.
+	case "migrate":
+		var err = migrate(log.With(logger, "unit", "migration"), cockroachEnabled, cockroachConfig, cockroachPoolConfig, cockroachDialect)
+		if err != nil {
+			logger.Log("msg", "could not migrate cockroach DB schema", "error", err)
+			exitStatus = 1
+		}
+		return
+	default:
+		logger.Log("msg", "unknown mode", "mode", mode)
+		exitStatus = 1
+		return
+	}
+
+	// Influx client. It is created before the Redis client, that reports its metrics to Influx.
+	type Metrics interface {
+		NewCounter(name string) metrics.Counter
+		NewGauge(name string) metrics.Gauge
+		NewHistogram(name string) metrics.Histogram
+		WriteLoop(c <-chan time.Time)
+		Ping(timeout time.Duration) (time.Duration, string, error)
+	}
+
+	// The Influx client is created before the Redis client and the log sinks, which report their
+	// metrics to it. Its logs are written to stdout until the final logger is created.
+	var influxLogger = &log.SwapLogger{}
+	influxLogger.Swap(logger)
+
+	var influxMetrics Metrics = &flakid.NoopMetrics{}
+	if influxEnabled {
+		var logger = log.With(influxLogger, "unit", "influx")
+
+		var influxClient, err = flakid.NewRotatingInflux(influxHTTPConfig, secrets["influx-password"].Value)
+		if err != nil {
+			logger.Log("msg", "could not create Influx client", "error", err)
+			return
+		}
+		defer influxClient.Close()
+
+		var gokitInflux = gokit_influx.New(
+			map[string]string{},
+			influxBatchPointsConfig,
+			log.With(logger, "unit", "go-kit influx"),
+		)
+
+		influxMetrics = flakid.NewMetrics(influxClient, gokitInflux)
+	}
+
+	// Log sinks. The logs are always written to stdout.
+	var logWriters = []io.Writer{os.Stdout}
+
+	// File log sink.
+	if logFileEnabled {
+		var logFile, err = flakid.NewRotatingFile(logFilePath,
+			flakid.MaxSize(logFileMaxSize*1024*1024),
+			flakid.MaxAge(logFileMaxAge),
+			flakid.MaxBackups(logFileMaxBackups),
+			flakid.Compress(logFileCompress),
+		)
+		if err != nil {
+			logger.Log("msg", "could not create log file", "error", err)
+			return
+		}
+		defer logFile.Close()
+		logWriters = append(logWriters, logFile)
+	}
+
 	// Redis.
 	type Redis interface {
 		Close() error
@@ -173,19 +351,95 @@ func main() {
 
 	var redisClient Redis = &flakid.NoopRedis{}
 	if redisEnabled {
-		var err error
-		redisClient, err = redis.NewResilientConn(redisURL, redisPassword, redisDatabase)
+		redisClient = redis.NewClient(redisConfig,
+			redis.ReconnectCounter(influxMetrics.NewCounter("redis_reconnect")),
+			redis.WaitHistogram(influxMetrics.NewHistogram("redis_wait")),
+			redis.PasswordSource(secrets["redis-password"].Value),
+		)
+		defer redisClient.Close()
+
+		var dropPolicy, err = flakid.GetDropPolicy(redisLogDropPolicy)
 		if err != nil {
-			logger.Log("msg", "could not create redis client", "error", err)
+			logger.Log("msg", "could not create redis log writer", "error", err)
 			return
 		}
-		defer redisClient.Close()
 
-		// Create logger that duplicates logs to stdout and Redis.
-		logger = log.NewJSONLogger(io.MultiWriter(os.Stdout, flakid.NewLogstashRedisWriter(redisClient, ComponentName)))
-		logger = log.With(logger, "ts", log.DefaultTimestampUTC, "caller", log.DefaultCaller)
+		var spillWriter io.Writer
+		spillWriter, err = flakid.GetSpillWriter(redisLogSpillTarget)
+		if err != nil {
+			logger.Log("msg", "could not create redis log writer", "error", err)
+			return
+		}
+
+		var schema flakid.Schema
+		schema, err = flakid.GetSchema(redisLogSchema)
+		if err != nil {
+			logger.Log("msg", "could not create redis log writer", "error", err)
+			return
+		}
+
+		// The logs are queued and sent to Redis in the background, so a Redis outage does not block the service.
+		var redisWriter = flakid.NewLogstashRedisWriter(redisClient, ComponentName,
+			flakid.QueueSize(redisLogQueueSize),
+			flakid.BatchSize(redisLogBatchSize),
+			flakid.FlushInterval(redisWriteInterval),
+			flakid.WithDropPolicy(dropPolicy),
+			flakid.WithSchema(schema),
+			flakid.SpillWriter(spillWriter),
+			flakid.QueueDepthGauge(influxMetrics.NewGauge("log_queue_depth")),
+			flakid.DroppedCounter(influxMetrics.NewCounter("log_dropped")),
+		)
+		defer redisWriter.Close()
+		logWriters = append(logWriters, redisWriter)
+	}
+
+	// Logstash log sink.
+	if logstashEnabled {
+		var schema, err = flakid.GetSchema(logstashSchema)
+		if err != nil {
+			logger.Log("msg", "could not create logstash log writer", "error", err)
+			return
+		}
+
+		var logstashWriter *flakid.LogstashWriter
+		logstashWriter, err = flakid.NewLogstashWriter(logstashNetwork, logstashAddr, schema, logstashTimeout)
+		if err != nil {
+			logger.Log("msg", "could not create logstash log writer", "error", err)
+			return
+		}
+		defer logstashWriter.Close()
+		logWriters = append(logWriters, logstashWriter)
+	}
+
+	// GELF log sink.
+	if gelfEnabled {
+		var hostname, _ = os.Hostname()
+		var gelfWriter, err = flakid.NewGELFWriter(gelfAddr, hostname)
+		if err != nil {
+			logger.Log("msg", "could not create GELF log writer", "error", err)
+			return
+		}
+		defer gelfWriter.Close()
+		logWriters = append(logWriters, gelfWriter)
+	}
+
+	// Syslog log sink.
+	if syslogEnabled {
+		var syslogWriter, err = flakid.NewSyslogWriter(syslogFacility, syslogTag)
+		if err != nil {
+			logger.Log("msg", "could not create syslog log writer", "error", err)
+			return
+		}
+		defer syslogWriter.Close()
+		logWriters = append(logWriters, syslogWriter)
 	}
 
+	// Create logger that duplicates logs to stdout and to each enabled sink. A failing sink
+	// does not prevent the logs from reaching the other ones.
+	// The last logs are recorded, they are sent as breadcrumbs with the error reports.
+	var breadcrumbs = flakid.NewBreadcrumbRecorder(log.NewJSONLogger(flakid.NewFanOutWriter(logWriters...)), trackingBreadcrumbs)
+	logger = log.With(breadcrumbs, "ts", log.DefaultTimestampUTC, "caller", log.DefaultCaller)
+
 	// Flaki unique distributed ID generator.
 	var flakiGen *flaki_gen.Flaki
 	{
@@ -203,10 +457,22 @@ func main() {
 
 	// Add component name, component ID and version to the logger tags.
 	logger = log.With(logger, "component_name", ComponentName, "component_id", ComponentID, "component_version", Version)
+	influxLogger.Swap(logger)
 
 	// Log component version infos.
 	logger.Log("environment", Environment, "git_commit", GitCommit)
 
+	// Log levels of the subsystems, that can be changed at runtime with the admin route.
+	var logLevels *flakid.LogLevels
+	{
+		var err error
+		logLevels, err = flakid.NewLogLevels(logLevel)
+		if err != nil {
+			logger.Log("msg", "could not create log levels", "error", err)
+			return
+		}
+	}
+
 	// Critical errors channel.
 	var errc = make(chan error)
 	go func() {
@@ -215,62 +481,87 @@ func main() {
 		errc <- fmt.Errorf("%s", <-c)
 	}()
 
-	// Sentry.
-	type Sentry interface {
-		CaptureError(err error, tags map[string]string, interfaces ...sentry.Interface) string
-		URL() string
-		Close()
-	}
-
-	var sentryClient Sentry = &flakid.NoopSentry{}
-	if sentryEnabled {
-		var logger = log.With(logger, "unit", "sentry")
-		var err error
-		sentryClient, err = sentry.New(sentryDSN)
-		if err != nil {
-			logger.Log("msg", "could not create Sentry client", "error", err)
-			return
+	// Error tracking. The errors are sent to Sentry if it is configured, written to a local file
+	// otherwise.
+	var errorTracker flakid.ErrorTracker = &flakid.NoopTracker{}
+	{
+		var info = flakid.TrackerInfo{
+			ComponentName: ComponentName,
+			ComponentID:   ComponentID,
+			Release:       Version,
+			Environment:   Environment,
 		}
-		defer sentryClient.Close()
-	}
 
-	// Influx client.
-	type Metrics interface {
-		NewCounter(name string) metrics.Counter
-		NewGauge(name string) metrics.Gauge
-		NewHistogram(name string) metrics.Histogram
-		WriteLoop(c <-chan time.Time)
-		Ping(timeout time.Duration) (time.Duration, string, error)
+		switch {
+		case sentryEnabled:
+			var logger = log.With(logger, "unit", "sentry")
+			var tracker, err = flakid.NewSentryTracker(sentryDSN, info, flakid.Breadcrumbs(breadcrumbs))
+			if err != nil {
+				logger.Log("msg", "could not create Sentry client", "error", err)
+				return
+			}
+			errorTracker = tracker
+		case trackingFileEnabled:
+			var logger = log.With(logger, "unit", "tracking")
+			var trackingFile, err = flakid.NewRotatingFile(trackingFilePath)
+			if err != nil {
+				logger.Log("msg", "could not open error reports file", "error", err)
+				return
+			}
+			errorTracker = flakid.NewFileTracker(trackingFile, info, flakid.Breadcrumbs(breadcrumbs))
+		}
+		defer errorTracker.Close()
 	}
 
-	var influxMetrics Metrics = &flakid.NoopMetrics{}
-	if influxEnabled {
-		var logger = log.With(logger, "unit", "influx")
+	// Tracer. The OpenTelemetry tracer is used if it is configured, the Jaeger client otherwise.
+	var tracer opentracing.Tracer
+	if otelEnabled {
+		var logger = log.With(logger, "unit", "otel")
+
+		// The component name, ID and version are added to the configured resource attributes.
+		var attributes = map[string]string{
+			"service.name":           ComponentName,
+			"service.instance.id":    ComponentID,
+			"service.version":        Version,
+			"deployment.environment": Environment,
+		}
+		for k, v := range otelConfig.Attributes {
+			attributes[k] = v
+		}
+		otelConfig.Attributes = attributes
 
-		var influxClient, err = influx.NewHTTPClient(influxHTTPConfig)
+		var provider, err = flakid.NewOTelTracerProvider(context.Background(), otelConfig)
 		if err != nil {
-			logger.Log("msg", "could not create Influx client", "error", err)
+			logger.Log("msg", "could not create OpenTelemetry tracer provider", "error", err)
 			return
 		}
-		defer influxClient.Close()
-
-		var gokitInflux = gokit_influx.New(
-			map[string]string{},
-			influxBatchPointsConfig,
-			log.With(logger, "unit", "go-kit influx"),
-		)
+		defer func() {
+			var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			provider.Shutdown(ctx)
+		}()
 
-		influxMetrics = flakid.NewMetrics(influxClient, gokitInflux)
-	}
+		var propagator propagation.TextMapPropagator
+		propagator, err = flakid.NewOTelPropagator(tracingPropagators)
+		if err != nil {
+			logger.Log("msg", "could not create OpenTelemetry propagator", "error", err)
+			return
+		}
 
-	// Jaeger client.
-	var tracer opentracing.Tracer
-	{
+		// The bridge lets the OpenTracing middlewares create OpenTelemetry spans.
+		var bridgeTracer, _ = otelbridge.NewTracerPair(provider.Tracer(ComponentName))
+		bridgeTracer.SetTextMapPropagator(propagator)
+		tracer = bridgeTracer
+	} else {
 		var logger = log.With(logger, "unit", "jaeger")
-		var closer io.Closer
-		var err error
+		var propagationOpts, err = flakid.JaegerPropagationOptions(tracingPropagators)
+		if err != nil {
+			logger.Log("msg", "could not create Jaeger propagators", "error", err)
+			return
+		}
 
-		tracer, closer, err = jaegerConfig.New(ComponentName)
+		var closer io.Closer
+		tracer, closer, err = jaegerConfig.New(ComponentName, propagationOpts...)
 		if err != nil {
 			logger.Log("msg", "could not create Jaeger tracer", "error", err)
 			return
@@ -298,16 +589,26 @@ func main() {
 
 	var cockroachConn Cockroach = flakid.NoopCockroach{}
 	if cockroachEnabled {
-		var err error
-		cockroachConn, err = sql.Open("postgres", fmt.Sprintf("postgresql://%s:%s@%s/%s?sslmode=disable", cockroachUsername, cockroachPassword, cockroachHostPort, cockroachDB))
+		var db, err = flakid.OpenCockroach(cockroachConfig, cockroachPoolConfig, flakid.CockroachPasswordSource(secrets["cockroach-password"].Value))
 		if err != nil {
 			logger.Log("msg", "could not create cockroach DB connection for health DB", "error", err)
 			return
 		}
+
+		// Refuse to run against a DB schema this release does not know.
+		err = migration.NewMigrator(db, migration.SQLDialect(cockroachDialect)).Check()
+		if err != nil {
+			logger.Log("msg", "incompatible cockroach DB schema", "error", err)
+			exitStatus = 1
+			return
+		}
+		cockroachConn = db
 	}
 
 	// Flaki service.
-	var flakiLogger = log.With(logger, "svc", "flaki")
+	// The successful requests are sampled, so each ID does not produce a log line per middleware.
+	var flakiLogger log.Logger = flakid.NewSamplingLogger(logLevels.Logger("flaki", logger), logSamplingRules, flakid.SuppressedCounter(influxMetrics.NewCounter("log_suppressed")))
+	flakiLogger = log.With(flakiLogger, "svc", "flaki")
 
 	var flakiModule flaki.IDGeneratorModule
 	{
@@ -315,7 +616,7 @@ func main() {
 		flakiModule = flaki.MakeModuleInstrumentingCounterMW(influxMetrics.NewCounter("flaki_module_ctr"))(flakiModule)
 		flakiModule = flaki.MakeModuleInstrumentingMW(influxMetrics.NewHistogram("flaki_module"))(flakiModule)
 		flakiModule = flaki.MakeModuleLoggingMW(log.With(flakiLogger, "mw", "module"))(flakiModule)
-		flakiModule = flaki.MakeModuleTracingMW(tracer)(flakiModule)
+		flakiModule = flaki.MakeModuleTracingMW(tracer, flakiNodeID, flakiComponentID)(flakiModule)
 	}
 
 	var flakiComponent flaki.IDGeneratorComponent
@@ -324,13 +625,22 @@ func main() {
 		flakiComponent = flaki.MakeComponentInstrumentingMW(influxMetrics.NewHistogram("flaki_component"))(flakiComponent)
 		flakiComponent = flaki.MakeComponentLoggingMW(log.With(flakiLogger, "mw", "component"))(flakiComponent)
 		flakiComponent = flaki.MakeComponentTracingMW(tracer)(flakiComponent)
-		flakiComponent = flaki.MakeComponentTrackingMW(sentryClient, log.With(flakiLogger, "mw", "component"))(flakiComponent)
+		flakiComponent = flaki.MakeComponentTrackingMW(errorTracker, log.With(flakiLogger, "mw", "component"))(flakiComponent)
+	}
+
+	// Rate limiters, their limits are changed when the configuration is reloaded.
+	var rateLimiters = map[string]*flakid.RateLimiter{}
+	for k, l := range rateLimit {
+		rateLimiters[k] = flakid.NewRateLimiter(l)
 	}
 
+	// The rate limiter is the innermost middleware, so the rejected requests are logged, measured and traced.
 	var nextIDEndpoint endpoint.Endpoint
 	{
 		nextIDEndpoint = flaki.MakeNextIDEndpoint(flakiComponent)
-		nextIDEndpoint = flaki.MakeEndpointInstrumentingMW(influxMetrics.NewHistogram("nextid_endpoint"))(nextIDEndpoint)
+		nextIDEndpoint = ratelimit.NewErroringLimiter(rateLimiters["nextID"])(nextIDEndpoint)
+		nextIDEndpoint = flaki.MakeRateLimitTracingMW("nextid_endpoint")(nextIDEndpoint)
+		nextIDEndpoint = flaki.MakeEndpointInstrumentingMW(influxMetrics.NewHistogram("nextid_endpoint"), influxMetrics.NewCounter("nextid_endpoint_errors"))(nextIDEndpoint)
 		nextIDEndpoint = flaki.MakeEndpointLoggingMW(log.With(flakiLogger, "mw", "endpoint", "unit", "NextID"))(nextIDEndpoint)
 		nextIDEndpoint = flaki.MakeEndpointTracingMW(tracer, "nextid_endpoint")(nextIDEndpoint)
 	}
@@ -338,26 +648,29 @@ func main() {
 	var nextValidIDEndpoint endpoint.Endpoint
 	{
 		nextValidIDEndpoint = flaki.MakeNextValidIDEndpoint(flakiComponent)
-		nextValidIDEndpoint = flaki.MakeEndpointInstrumentingMW(influxMetrics.NewHistogram("nextvalidid_endpoint"))(nextValidIDEndpoint)
+		nextValidIDEndpoint = ratelimit.NewErroringLimiter(rateLimiters["nextValidID"])(nextValidIDEndpoint)
+		nextValidIDEndpoint = flaki.MakeRateLimitTracingMW("nextvalidid_endpoint")(nextValidIDEndpoint)
+		nextValidIDEndpoint = flaki.MakeEndpointInstrumentingMW(influxMetrics.NewHistogram("nextvalidid_endpoint"), influxMetrics.NewCounter("nextvalidid_endpoint_errors"))(nextValidIDEndpoint)
 		nextValidIDEndpoint = flaki.MakeEndpointLoggingMW(log.With(flakiLogger, "mw", "endpoint", "unit", "NextValidID"))(nextValidIDEndpoint)
 		nextValidIDEndpoint = flaki.MakeEndpointTracingMW(tracer, "nextvalidid_endpoint")(nextValidIDEndpoint)
 	}
 
-	// Rate limiting
-	nextIDEndpoint = ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Second), rateLimit["nextID"]))(nextIDEndpoint)
-	nextValidIDEndpoint = ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Second), rateLimit["nextValidID"]))(nextValidIDEndpoint)
-
 	var flakiEndpoints = flaki.Endpoints{
 		NextIDEndpoint:      nextIDEndpoint,
 		NextValidIDEndpoint: nextValidIDEndpoint,
 	}
 
 	// Health service.
-	var healthLogger = log.With(logger, "svc", "health")
+	var healthLogger = log.With(logLevels.Logger("health", logger), "svc", "health")
 
 	var cockroachModule *health.StorageModule
 	{
-		cockroachModule = health.NewStorageModule(ComponentName, ComponentID, cockroachConn)
+		var dialect, err = health.GetDialect(cockroachDialect)
+		if err != nil {
+			logger.Log("msg", "could not select the SQL dialect of the health DB", "error", err)
+			return
+		}
+		cockroachModule = health.NewStorageModule(ComponentName, ComponentID, cockroachConn, health.SQLDialect(dialect), health.MaxRetries(cockroachMaxRetries), health.RetryBackoff(cockroachRetryBackoff), health.Tracer(tracer))
 	}
 
 	var influxHM health.InfluxHealthChecker
@@ -377,80 +690,98 @@ func main() {
 	}
 	var sentryHM health.SentryHealthChecker
 	{
-		sentryHM = common.NewSentryModule(sentryClient, http.DefaultClient, sentryEnabled)
+		sentryHM = common.NewSentryModule(errorTracker, http.DefaultClient, sentryEnabled)
 		sentryHM = common.MakeSentryModuleLoggingMW(log.With(healthLogger, "mw", "module"))(sentryHM)
 	}
+	var healthStoreModule health.StoreModule
+	{
+		healthStoreModule = cockroachModule
+		healthStoreModule = health.MakeStoreModuleTrackingMW(errorTracker, log.With(healthLogger, "mw", "module"))(healthStoreModule)
+	}
+	// The health checks validities are changed when the configuration is reloaded.
+	var healthChecks = health.NewComponent(influxHM, jaegerHM, redisHM, sentryHM, healthStoreModule, healthChecksValidity)
+
 	var healthComponent health.HealthChecker
 	{
-		healthComponent = health.NewComponent(influxHM, jaegerHM, redisHM, sentryHM, cockroachModule, healthChecksValidity)
+		healthComponent = healthChecks
 		healthComponent = health.MakeComponentLoggingMW(log.With(healthLogger, "mw", "component"))(healthComponent)
+		healthComponent = health.MakeComponentTracingMW(tracer)(healthComponent)
 	}
 
 	var influxExecHealthEndpoint endpoint.Endpoint
 	{
 		influxExecHealthEndpoint = health.MakeExecInfluxHealthCheckEndpoint(healthComponent)
 		influxExecHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ExecInfluxHealthCheck"))(influxExecHealthEndpoint)
+		influxExecHealthEndpoint = health.MakeEndpointTracingMW(tracer, "exec_influx_health_endpoint")(influxExecHealthEndpoint)
 		influxExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiModule)(influxExecHealthEndpoint)
 	}
 	var influxReadHealthEndpoint endpoint.Endpoint
 	{
 		influxReadHealthEndpoint = health.MakeReadInfluxHealthCheckEndpoint(healthComponent)
 		influxReadHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ReadInfluxHealthCheck"))(influxReadHealthEndpoint)
+		influxReadHealthEndpoint = health.MakeEndpointTracingMW(tracer, "read_influx_health_endpoint")(influxReadHealthEndpoint)
 		influxReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiModule)(influxReadHealthEndpoint)
 	}
 	var jaegerExecHealthEndpoint endpoint.Endpoint
 	{
 		jaegerExecHealthEndpoint = health.MakeExecJaegerHealthCheckEndpoint(healthComponent)
 		jaegerExecHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ExecJaegerHealthCheck"))(jaegerExecHealthEndpoint)
+		jaegerExecHealthEndpoint = health.MakeEndpointTracingMW(tracer, "exec_jaeger_health_endpoint")(jaegerExecHealthEndpoint)
 		jaegerExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiModule)(jaegerExecHealthEndpoint)
 	}
 	var jaegerReadHealthEndpoint endpoint.Endpoint
 	{
 		jaegerReadHealthEndpoint = health.MakeReadJaegerHealthCheckEndpoint(healthComponent)
 		jaegerReadHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ReadJaegerHealthCheck"))(jaegerReadHealthEndpoint)
+		jaegerReadHealthEndpoint = health.MakeEndpointTracingMW(tracer, "read_jaeger_health_endpoint")(jaegerReadHealthEndpoint)
 		jaegerReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiModule)(jaegerReadHealthEndpoint)
 	}
 	var redisExecHealthEndpoint endpoint.Endpoint
 	{
 		redisExecHealthEndpoint = health.MakeExecRedisHealthCheckEndpoint(healthComponent)
 		redisExecHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ExecRedisHealthCheck"))(redisExecHealthEndpoint)
+		redisExecHealthEndpoint = health.MakeEndpointTracingMW(tracer, "exec_redis_health_endpoint")(redisExecHealthEndpoint)
 		redisExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiModule)(redisExecHealthEndpoint)
 	}
 	var redisReadHealthEndpoint endpoint.Endpoint
 	{
 		redisReadHealthEndpoint = health.MakeReadRedisHealthCheckEndpoint(healthComponent)
 		redisReadHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ReadRedisHealthCheck"))(redisReadHealthEndpoint)
+		redisReadHealthEndpoint = health.MakeEndpointTracingMW(tracer, "read_redis_health_endpoint")(redisReadHealthEndpoint)
 		redisReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiModule)(redisReadHealthEndpoint)
 	}
 	var sentryExecHealthEndpoint endpoint.Endpoint
 	{
 		sentryExecHealthEndpoint = health.MakeExecSentryHealthCheckEndpoint(healthComponent)
 		sentryExecHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ExecSentryHealthCheck"))(sentryExecHealthEndpoint)
+		sentryExecHealthEndpoint = health.MakeEndpointTracingMW(tracer, "exec_sentry_health_endpoint")(sentryExecHealthEndpoint)
 		sentryExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiModule)(sentryExecHealthEndpoint)
 	}
 	var sentryReadHealthEndpoint endpoint.Endpoint
 	{
 		sentryReadHealthEndpoint = health.MakeReadSentryHealthCheckEndpoint(healthComponent)
 		sentryReadHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ReadSentryHealthCheck"))(sentryReadHealthEndpoint)
+		sentryReadHealthEndpoint = health.MakeEndpointTracingMW(tracer, "read_sentry_health_endpoint")(sentryReadHealthEndpoint)
 		sentryReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiModule)(sentryReadHealthEndpoint)
 	}
 	var allHealthEndpoint endpoint.Endpoint
 	{
 		allHealthEndpoint = health.MakeAllHealthChecksEndpoint(healthComponent)
 		allHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "AllHealthCheck"))(allHealthEndpoint)
+		allHealthEndpoint = health.MakeEndpointTracingMW(tracer, "all_health_endpoint")(allHealthEndpoint)
 		allHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiModule)(allHealthEndpoint)
 	}
 
 	// Rate limiting
-	influxExecHealthEndpoint = ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Second), rateLimit["influxHealthExec"]))(influxExecHealthEndpoint)
-	influxReadHealthEndpoint = ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Second), rateLimit["influxHealthRead"]))(influxReadHealthEndpoint)
-	jaegerExecHealthEndpoint = ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Second), rateLimit["jaegerHealthExec"]))(jaegerExecHealthEndpoint)
-	jaegerReadHealthEndpoint = ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Second), rateLimit["jaegerHealthRead"]))(jaegerReadHealthEndpoint)
-	redisExecHealthEndpoint = ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Second), rateLimit["redisHealthExec"]))(redisExecHealthEndpoint)
-	redisReadHealthEndpoint = ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Second), rateLimit["redisHealthRead"]))(redisReadHealthEndpoint)
-	sentryExecHealthEndpoint = ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Second), rateLimit["sentryHealthExec"]))(sentryExecHealthEndpoint)
-	sentryReadHealthEndpoint = ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Second), rateLimit["sentryHealthRead"]))(sentryReadHealthEndpoint)
-	allHealthEndpoint = ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Second), rateLimit["allHealth"]))(allHealthEndpoint)
+	influxExecHealthEndpoint = ratelimit.NewErroringLimiter(rateLimiters["influxHealthExec"])(influxExecHealthEndpoint)
+	influxReadHealthEndpoint = ratelimit.NewErroringLimiter(rateLimiters["influxHealthRead"])(influxReadHealthEndpoint)
+	jaegerExecHealthEndpoint = ratelimit.NewErroringLimiter(rateLimiters["jaegerHealthExec"])(jaegerExecHealthEndpoint)
+	jaegerReadHealthEndpoint = ratelimit.NewErroringLimiter(rateLimiters["jaegerHealthRead"])(jaegerReadHealthEndpoint)
+	redisExecHealthEndpoint = ratelimit.NewErroringLimiter(rateLimiters["redisHealthExec"])(redisExecHealthEndpoint)
+	redisReadHealthEndpoint = ratelimit.NewErroringLimiter(rateLimiters["redisHealthRead"])(redisReadHealthEndpoint)
+	sentryExecHealthEndpoint = ratelimit.NewErroringLimiter(rateLimiters["sentryHealthExec"])(sentryExecHealthEndpoint)
+	sentryReadHealthEndpoint = ratelimit.NewErroringLimiter(rateLimiters["sentryHealthRead"])(sentryReadHealthEndpoint)
+	allHealthEndpoint = ratelimit.NewErroringLimiter(rateLimiters["allHealth"])(allHealthEndpoint)
 
 	var healthEndpoints = health.Endpoints{
 		InfluxExecHealthCheck: influxExecHealthEndpoint,
@@ -464,73 +795,85 @@ func main() {
 		AllHealthChecks:       allHealthEndpoint,
 	}
 
-	// Jobs
+	// Jobs.
+	var jobLogger = log.With(logLevels.Logger("jobs", logger), "svc", "jobs")
+
+	var jobManager *health_job.Manager
 	{
 		var ctrl = controller.NewController(ComponentName, ComponentID, &idGenerator{flakiGen}, &job_lock.NoopLocker{}, controller.EnableStatusStorage(job_status.New(cockroachConn)))
-
-		var influxJob *job.Job
-		{
-			var err error
-			influxJob, err = health_job.MakeInfluxJob(influxHM, healthChecksValidity[influxKey], cockroachModule)
-			if err != nil {
-				logger.Log("msg", "could not create influx health job", "error", err)
-				return
-			}
-			ctrl.Register(influxJob)
-			ctrl.Schedule("@minutely", influxJob.Name())
+		jobManager = health_job.NewManager(ComponentName, ctrl, job_status.New(cockroachConn))
+
+		var jobs = []*health_job.Job{
+			health_job.MakeInfluxJob(influxHM, healthChecks, cockroachModule),
+			health_job.MakeJaegerJob(jaegerHM, healthChecks, cockroachModule),
+			health_job.MakeRedisJob(redisHM, healthChecks, cockroachModule),
+			health_job.MakeSentryJob(sentryHM, healthChecks, cockroachModule),
+			health_job.MakeCleanCockroachJob(cockroachModule, log.With(logger, "job", "clean health checks")),
 		}
 
-		var jaegerJob *job.Job
-		{
-			var err error
-			jaegerJob, err = health_job.MakeJaegerJob(jaegerHM, healthChecksValidity[jaegerKey], cockroachModule)
-			if err != nil {
-				logger.Log("msg", "could not create jaeger health job", "error", err)
-				return
-			}
-			ctrl.Register(jaegerJob)
-			ctrl.Schedule("@minutely", jaegerJob.Name())
-		}
+		for _, j := range jobs {
+			j = health_job.MakeJobInstrumentingMW(influxMetrics.NewHistogram("job"))(j)
+			j = health_job.MakeJobLoggingMW(log.With(jobLogger, "mw", "job"))(j)
+			j = health_job.MakeJobTrackingMW(errorTracker, jobFailureReportThreshold, log.With(jobLogger, "mw", "job"))(j)
+			j = health_job.MakeJobTracingMW(tracer, ComponentName)(j)
+			j = health_job.MakeJobCorrelationIDMW(flakiModule)(j)
 
-		var redisJob *job.Job
-		{
-			var err error
-			redisJob, err = health_job.MakeRedisJob(redisHM, healthChecksValidity[redisKey], cockroachModule)
+			var err = jobManager.Register(j, jobSchedules[j.Name()])
 			if err != nil {
-				logger.Log("msg", "could not create redis health job", "error", err)
+				logger.Log("msg", "could not register job", "job", j.Name(), "error", err)
 				return
 			}
-			ctrl.Register(redisJob)
-			ctrl.Schedule("@minutely", redisJob.Name())
-		}
-
-		var sentryJob *job.Job
-		{
-			var err error
-			sentryJob, err = health_job.MakeSentryJob(sentryHM, healthChecksValidity[sentryKey], cockroachModule)
-			if err != nil {
-				logger.Log("msg", "could not create sentry health job", "error", err)
-				return
-			}
-			ctrl.Register(sentryJob)
-			ctrl.Schedule("@minutely", sentryJob.Name())
 		}
+		ctrl.Start()
+	}
 
-		var cleanJob *job.Job
-		{
-			var err error
-			cleanJob, err = health_job.MakeCleanCockroachJob(cockroachModule, log.With(logger, "job", "clean health checks"))
-			if err != nil {
-				logger.Log("msg", "could not create clean job", "error", err)
-				return
-			}
-			ctrl.Register(cleanJob)
-			ctrl.Schedule(fmt.Sprintf("@every %s", cockroachCleanInterval), cleanJob.Name())
+	var listJobsEndpoint endpoint.Endpoint
+	{
+		listJobsEndpoint = health_job.MakeListJobsEndpoint(jobManager)
+		listJobsEndpoint = health.MakeEndpointLoggingMW(log.With(jobLogger, "mw", "endpoint", "unit", "ListJobs"))(listJobsEndpoint)
+		listJobsEndpoint = health.MakeEndpointTracingMW(tracer, "list_jobs_endpoint")(listJobsEndpoint)
+		listJobsEndpoint = health.MakeEndpointCorrelationIDMW(flakiModule)(listJobsEndpoint)
+	}
+	var jobStatusEndpoint endpoint.Endpoint
+	{
+		jobStatusEndpoint = health_job.MakeJobStatusEndpoint(jobManager)
+		jobStatusEndpoint = health.MakeEndpointLoggingMW(log.With(jobLogger, "mw", "endpoint", "unit", "JobStatus"))(jobStatusEndpoint)
+		jobStatusEndpoint = health.MakeEndpointTracingMW(tracer, "job_status_endpoint")(jobStatusEndpoint)
+		jobStatusEndpoint = health.MakeEndpointCorrelationIDMW(flakiModule)(jobStatusEndpoint)
+	}
+	var triggerJobEndpoint endpoint.Endpoint
+	{
+		triggerJobEndpoint = health_job.MakeTriggerJobEndpoint(jobManager)
+		triggerJobEndpoint = health.MakeEndpointLoggingMW(log.With(jobLogger, "mw", "endpoint", "unit", "TriggerJob"))(triggerJobEndpoint)
+		triggerJobEndpoint = health.MakeEndpointTracingMW(tracer, "trigger_job_endpoint")(triggerJobEndpoint)
+		triggerJobEndpoint = health.MakeEndpointCorrelationIDMW(flakiModule)(triggerJobEndpoint)
+	}
+	var pauseJobEndpoint endpoint.Endpoint
+	{
+		pauseJobEndpoint = health_job.MakePauseJobEndpoint(jobManager)
+		pauseJobEndpoint = health.MakeEndpointLoggingMW(log.With(jobLogger, "mw", "endpoint", "unit", "PauseJob"))(pauseJobEndpoint)
+		pauseJobEndpoint = health.MakeEndpointTracingMW(tracer, "pause_job_endpoint")(pauseJobEndpoint)
+		pauseJobEndpoint = health.MakeEndpointCorrelationIDMW(flakiModule)(pauseJobEndpoint)
+	}
+	var resumeJobEndpoint endpoint.Endpoint
+	{
+		resumeJobEndpoint = health_job.MakeResumeJobEndpoint(jobManager)
+		resumeJobEndpoint = health.MakeEndpointLoggingMW(log.With(jobLogger, "mw", "endpoint", "unit", "ResumeJob"))(resumeJobEndpoint)
+		resumeJobEndpoint = health.MakeEndpointTracingMW(tracer, "resume_job_endpoint")(resumeJobEndpoint)
+		resumeJobEndpoint = health.MakeEndpointCorrelationIDMW(flakiModule)(resumeJobEndpoint)
+	}
 
-		}
-		ctrl.Start()
+	var jobEndpoints = health_job.Endpoints{
+		ListJobs:   listJobsEndpoint,
+		JobStatus:  jobStatusEndpoint,
+		TriggerJob: triggerJobEndpoint,
+		PauseJob:   pauseJobEndpoint,
+		ResumeJob:  resumeJobEndpoint,
 	}
 
+	// Access logs, one line per gRPC or HTTP request.
+	var accessLogger = log.With(logLevels.Logger("access", logger), "svc", "access")
+
 	// GRPC server.
 	go func() {
 		var logger = log.With(logger, "transport", "grpc")
@@ -562,7 +905,16 @@ func main() {
 		}
 
 		var grpcServer = flaki.NewGRPCServer(nextIDHandler, nextValidIDHandler)
-		var flakiServer = grpc.NewServer(grpc.CustomCodec(flatbuffers.FlatbuffersCodec{}))
+		// The panics are recovered inside the access log interceptor, so the failed requests are logged.
+		var accessLogInterceptor = flaki.MakeGRPCAccessLogInterceptor(accessLogger)
+		var recoveryInterceptor = flaki.MakeGRPCRecoveryInterceptor(errorTracker, log.With(logger, "mw", "recovery"))
+		var interceptor = func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			return accessLogInterceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return recoveryInterceptor(ctx, req, info, handler)
+			})
+		}
+
+		var flakiServer = grpc.NewServer(grpc.CustomCodec(flatbuffers.FlatbuffersCodec{}), grpc.UnaryInterceptor(interceptor))
 		fb.RegisterFlakiServer(flakiServer, grpcServer)
 
 		errc <- flakiServer.Serve(lis)
@@ -594,23 +946,41 @@ func main() {
 		// Version.
 		route.Handle("/", http.HandlerFunc(makeVersion(ComponentName, ComponentID, Version, Environment, GitCommit)))
 
+		// The health and jobs routes continue the trace of the caller, if any.
+		var tracingMW = func(operationName string, next http.Handler) http.Handler {
+			return flaki.MakeHTTPTracingMW(tracer, ComponentName, operationName)(next)
+		}
+
 		// Health checks.
 		var healthSubroute = route.PathPrefix("/health").Subrouter()
 
 		var allHealthChecksHandler = health.MakeHealthCheckHandler(healthEndpoints.AllHealthChecks)
-		healthSubroute.Handle("", allHealthChecksHandler)
+		healthSubroute.Handle("", tracingMW("http_server_all_health", allHealthChecksHandler))
 
-		healthSubroute.Handle("/influx", health.MakeHealthCheckHandler(healthEndpoints.InfluxReadHealthCheck)).Methods("GET")
-		healthSubroute.Handle("/influx", health.MakeHealthCheckHandler(healthEndpoints.InfluxExecHealthCheck)).Methods("POST")
+		healthSubroute.Handle("/influx", tracingMW("http_server_read_influx_health", health.MakeHealthCheckHandler(healthEndpoints.InfluxReadHealthCheck))).Methods("GET")
+		healthSubroute.Handle("/influx", tracingMW("http_server_exec_influx_health", health.MakeHealthCheckHandler(healthEndpoints.InfluxExecHealthCheck))).Methods("POST")
 
-		healthSubroute.Handle("/jaeger", health.MakeHealthCheckHandler(healthEndpoints.JaegerReadHealthCheck)).Methods("GET")
-		healthSubroute.Handle("/jaeger", health.MakeHealthCheckHandler(healthEndpoints.JaegerExecHealthCheck)).Methods("POST")
+		healthSubroute.Handle("/jaeger", tracingMW("http_server_read_jaeger_health", health.MakeHealthCheckHandler(healthEndpoints.JaegerReadHealthCheck))).Methods("GET")
+		healthSubroute.Handle("/jaeger", tracingMW("http_server_exec_jaeger_health", health.MakeHealthCheckHandler(healthEndpoints.JaegerExecHealthCheck))).Methods("POST")
 
-		healthSubroute.Handle("/redis", health.MakeHealthCheckHandler(healthEndpoints.RedisReadHealthCheck)).Methods("GET")
-		healthSubroute.Handle("/redis", health.MakeHealthCheckHandler(healthEndpoints.RedisExecHealthCheck)).Methods("POST")
+		healthSubroute.Handle("/redis", tracingMW("http_server_read_redis_health", health.MakeHealthCheckHandler(healthEndpoints.RedisReadHealthCheck))).Methods("GET")
+		healthSubroute.Handle("/redis", tracingMW("http_server_exec_redis_health", health.MakeHealthCheckHandler(healthEndpoints.RedisExecHealthCheck))).Methods("POST")
 
-		healthSubroute.Handle("/sentry", health.MakeHealthCheckHandler(healthEndpoints.SentryReadHealthCheck)).Methods("GET")
-		healthSubroute.Handle("/sentry", health.MakeHealthCheckHandler(healthEndpoints.SentryExecHealthCheck)).Methods("POST")
+		healthSubroute.Handle("/sentry", tracingMW("http_server_read_sentry_health", health.MakeHealthCheckHandler(healthEndpoints.SentryReadHealthCheck))).Methods("GET")
+		healthSubroute.Handle("/sentry", tracingMW("http_server_exec_sentry_health", health.MakeHealthCheckHandler(healthEndpoints.SentryExecHealthCheck))).Methods("POST")
+
+		// Jobs.
+		var jobSubroute = route.PathPrefix("/jobs").Subrouter()
+		jobSubroute.Handle("", tracingMW("http_server_list_jobs", health_job.MakeJobHandler(jobEndpoints.ListJobs))).Methods("GET")
+		jobSubroute.Handle("/{name}", tracingMW("http_server_job_status", health_job.MakeJobHandler(jobEndpoints.JobStatus))).Methods("GET")
+		jobSubroute.Handle("/{name}/trigger", tracingMW("http_server_trigger_job", health_job.MakeJobHandler(jobEndpoints.TriggerJob))).Methods("POST")
+		jobSubroute.Handle("/{name}/pause", tracingMW("http_server_pause_job", health_job.MakeJobHandler(jobEndpoints.PauseJob))).Methods("POST")
+		jobSubroute.Handle("/{name}/resume", tracingMW("http_server_resume_job", health_job.MakeJobHandler(jobEndpoints.ResumeJob))).Methods("POST")
+
+		// Log levels.
+		var adminSubroute = route.PathPrefix("/admin").Subrouter()
+		adminSubroute.Handle("/log-level", flakid.MakeLogLevelHandler(logLevels)).Methods("GET")
+		adminSubroute.Handle("/log-level/{subsystem}", flakid.MakeLogLevelHandler(logLevels)).Methods("PUT")
 
 		// Debug.
 		if pprofRouteEnabled {
@@ -622,7 +992,11 @@ func main() {
 			debugSubroute.HandleFunc("/pprof/trace", http.HandlerFunc(pprof.Trace))
 		}
 
-		errc <- http.ListenAndServe(httpAddr, route)
+		var handler http.Handler = route
+		handler = flaki.MakeHTTPRecoveryMW(errorTracker, log.With(logger, "mw", "recovery"))(handler)
+		handler = flaki.MakeHTTPAccessLogMW(accessLogger)(handler)
+
+		errc <- http.ListenAndServe(httpAddr, handler)
 	}()
 
 	// Influx writing.
@@ -634,19 +1008,140 @@ func main() {
 		}()
 	}
 
-	// Redis writing.
-	if redisEnabled {
-		go func() {
-			var tic = time.NewTicker(redisWriteInterval)
-			defer tic.Stop()
-			for range tic.C {
-				redisClient.Flush()
-			}
-		}()
+	// Secrets reloading, the rotated passwords are used by the new connections. The Sentry DSN is
+	// not reloaded, its rotation requires a restart.
+	var secretReloader = flakid.NewSecretReloader(secrets, log.With(logger, "unit", "secrets"))
+	go func() {
+		var tic = time.NewTicker(secretReloadInterval)
+		defer tic.Stop()
+		secretReloader.ReloadLoop(tic.C)
+	}()
+
+	// Configuration reloading, on SIGHUP and, if config-watch is set, when the configuration file
+	// changes. The rate limits, the log level, the health checks validities and the jobs schedules
+	// are applied live, the other changes require a restart.
+	var reloadc = make(chan string, 1)
+	var triggerReload = func(trigger string) {
+		// A reload already pending reads the latest configuration too.
+		select {
+		case reloadc <- trigger:
+		default:
+		}
 	}
+	go func() {
+		var c = make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGHUP)
+		for range c {
+			triggerReload("SIGHUP")
+		}
+	}()
+	if configWatch {
+		var w = viper.New()
+		w.SetConfigFile(c.ConfigFile)
+		w.OnConfigChange(func(fsnotify.Event) {
+			triggerReload("file change")
+		})
+		w.WatchConfig()
+	}
+	go func() {
+		var logger = log.With(logger, "unit", "config")
+
+		for trigger := range reloadc {
+			var loaded flakid.Config
+			var v, err = config(logger)
+			var errs []error
+			if err == nil {
+				loaded, errs = loadConfig(v)
+			} else {
+				errs = []error{err}
+			}
+			if len(errs) != 0 {
+				for _, err := range errs {
+					logger.Log("msg", "could not reload configuration", "trigger", trigger, "error", err)
+				}
+				continue
+			}
+
+			var reloaded flakid.Config
+			var changes []flakid.ConfigChange
+			reloaded, changes, err = c.Reload(loaded)
+			if err != nil {
+				logger.Log("msg", "could not reload configuration", "trigger", trigger, "error", err)
+				continue
+			}
+
+			var applied = map[string]bool{}
+			for _, change := range changes {
+				if !change.Applied {
+					logger.Log("msg", "configuration change ignored, it requires a restart", "key", change.Key, "old", change.Old, "new", change.New)
+					continue
+				}
+				applied[change.Key] = true
+				logger.Log("msg", "configuration changed", "key", change.Key, "old", change.Old, "new", change.New)
+			}
+
+			// The log level is only set if it changed, so the levels set with the admin route are kept.
+			if applied["log-level"] {
+				logLevels.SetDefault(reloaded.LogLevel)
+			}
+			for k, l := range rateLimits(reloaded) {
+				if rateLimiters[k].Limit() != l {
+					rateLimiters[k].SetLimit(l)
+				}
+			}
+			for unit, validity := range validities(reloaded) {
+				healthChecks.SetHealthCheckValidity(unit, validity)
+			}
+			// Rescheduling a job with its current schedule does nothing, the failed ones are retried
+			// at the next reload.
+			for name, schedule := range schedules(reloaded) {
+				var err = jobManager.Reschedule(name, schedule)
+				if err != nil {
+					logger.Log("msg", "could not reschedule job", "job", name, "error", err)
+				}
+			}
+			secretReloader.Reload()
+
+			c = reloaded
+			logger.Log("msg", "configuration reloaded", "trigger", trigger, "changes", len(changes))
+		}
+	}()
+
 	logger.Log("error", <-errc)
 }
 
+// migrate applies the schema migrations to the cockroach DB.
+func migrate(logger log.Logger, cockroachEnabled bool, cockroachConfig flakid.CockroachConfig, cockroachPoolConfig flakid.CockroachPoolConfig, dialect string) error {
+	if !cockroachEnabled {
+		return fmt.Errorf("cockroach is not configured")
+	}
+
+	var db, err = flakid.OpenCockroach(cockroachConfig, cockroachPoolConfig)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var migrator = migration.NewMigrator(db, migration.SQLDialect(dialect))
+
+	var applied []migration.Migration
+	applied, err = migrator.Up()
+	for _, m := range applied {
+		logger.Log("msg", "migration applied", "version", m.Version, "description", m.Description)
+	}
+	if err != nil {
+		return err
+	}
+
+	var version int
+	version, err = migrator.Version()
+	if err != nil {
+		return err
+	}
+	logger.Log("msg", "schema is up to date", "version", version)
+	return nil
+}
+
 type idGenerator struct {
 	flaki *flaki_gen.Flaki
 }
@@ -684,16 +1179,33 @@ func makeVersion(componentName, componentID, version, environment, gitCommit str
 	}
 }
 
-func config(logger log.Logger) *viper.Viper {
-	logger.Log("msg", "load configuration and command args")
+// derivedKeys are the configuration keys that are computed from the others, they can not be overridden.
+var derivedKeys = map[string]bool{
+	"influx":        true,
+	"sentry":        true,
+	"tracking-file": true,
+	"jaeger":        true,
+	"otel":          true,
+	"redis":         true,
+	"log-file":      true,
+	"log-logstash":  true,
+	"log-gelf":      true,
+	"cockroach":     true,
+}
 
+// defaultConfig returns the configuration with the default values only.
+func defaultConfig() *viper.Viper {
 	var v = viper.New()
 
 	// Component default.
 	v.SetDefault("config-file", "./configs/flakid.yml")
+	v.SetDefault("config-watch", false)
 	v.SetDefault("component-http-host-port", "0.0.0.0:8888")
 	v.SetDefault("component-grpc-host-port", "0.0.0.0:5555")
 
+	// Secrets default. The secrets read from files are reloaded periodically.
+	v.SetDefault("secret-reload-interval", "1m")
+
 	// Flaki generator default.
 	v.SetDefault("flaki-node-id", 0)
 	v.SetDefault("flaki-component-id", 0)
@@ -703,25 +1215,44 @@ func config(logger log.Logger) *viper.Viper {
 	v.SetDefault("influx-host-port", "")
 	v.SetDefault("influx-username", "")
 	v.SetDefault("influx-password", "")
+	v.SetDefault("influx-password-file", "")
 	v.SetDefault("influx-database", "")
 	v.SetDefault("influx-precision", "")
 	v.SetDefault("influx-retention-policy", "")
 	v.SetDefault("influx-write-consistency", "")
-	v.SetDefault("influx-write-interval", 1000)
+	v.SetDefault("influx-write-interval", "1s")
 
 	// Sentry client default.
 	v.SetDefault("sentry", false)
 	v.SetDefault("sentry-dsn", "")
+	v.SetDefault("sentry-dsn-file", "")
+
+	// Error reports file default.
+	v.SetDefault("tracking-file", false)
+	v.SetDefault("tracking-file-path", "")
+	v.SetDefault("tracking-breadcrumbs", 20)
 
 	// Jaeger tracing default.
 	v.SetDefault("jaeger", false)
 	v.SetDefault("jaeger-sampler-type", "")
-	v.SetDefault("jaeger-sampler-param", 0)
+	v.SetDefault("jaeger-sampler-param", 0.0)
 	v.SetDefault("jaeger-sampler-host-port", "")
 	v.SetDefault("jaeger-reporter-logspan", false)
 	v.SetDefault("jaeger-write-interval", "1s")
 	v.SetDefault("jaeger-collector-healthcheck-host-port", "")
 
+	// Trace context propagation default.
+	v.SetDefault("tracing-propagators", []string{"jaeger", "w3c", "b3", "b3-single"})
+
+	// OpenTelemetry tracing default.
+	v.SetDefault("otel", false)
+	v.SetDefault("otel-host-port", "")
+	v.SetDefault("otel-protocol", "grpc")
+	v.SetDefault("otel-insecure", false)
+	v.SetDefault("otel-sampler", "parentbased_always_on")
+	v.SetDefault("otel-sampler-ratio", 1.0)
+	v.SetDefault("otel-resource-attributes", map[string]string{})
+
 	// Debug routes enabled.
 	v.SetDefault("pprof-route-enabled", true)
 
@@ -729,15 +1260,67 @@ func config(logger log.Logger) *viper.Viper {
 	v.SetDefault("redis", false)
 	v.SetDefault("redis-host-port", "")
 	v.SetDefault("redis-password", "")
+	v.SetDefault("redis-password-file", "")
 	v.SetDefault("redis-database", 0)
+	v.SetDefault("redis-max-idle", 2)
+	v.SetDefault("redis-max-active", 10)
+	v.SetDefault("redis-idle-timeout", "5m")
+	v.SetDefault("redis-dial-timeout", "1s")
+	v.SetDefault("redis-read-timeout", "1s")
+	v.SetDefault("redis-write-timeout", "1s")
+	v.SetDefault("redis-timeout", "2s")
+	v.SetDefault("redis-fail-fast", false)
+	v.SetDefault("redis-backoff-initial", "100ms")
+	v.SetDefault("redis-backoff-max", "5s")
+	v.SetDefault("redis-backoff-multiplier", 2.0)
+	v.SetDefault("redis-backoff-jitter", 0.2)
 	v.SetDefault("redis-write-interval", "1s")
+	v.SetDefault("redis-log-queue-size", 10000)
+	v.SetDefault("redis-log-batch-size", 100)
+	v.SetDefault("redis-log-drop-policy", "drop-oldest")
+	v.SetDefault("redis-log-spill-target", "stderr")
+	v.SetDefault("redis-log-schema", "logstash-v1")
+
+	// Log sinks.
+	v.SetDefault("log-level", "info")
+	v.SetDefault("log-file-path", "")
+	v.SetDefault("log-file-max-size", 100)
+	v.SetDefault("log-file-max-age", "24h")
+	v.SetDefault("log-file-max-backups", 7)
+	v.SetDefault("log-file-compress", true)
+	v.SetDefault("log-logstash-network", "tcp")
+	v.SetDefault("log-logstash-host-port", "")
+	v.SetDefault("log-logstash-schema", "logstash-v1")
+	v.SetDefault("log-logstash-timeout", "1s")
+	v.SetDefault("log-gelf-host-port", "")
+	v.SetDefault("log-syslog", false)
+	v.SetDefault("log-syslog-facility", "daemon")
+	v.SetDefault("log-syslog-tag", "flakid")
+
+	// Log sampling.
+	v.SetDefault("log-sampling-nextid-rate", 1)
+	v.SetDefault("log-sampling-nextid-slow-threshold", "100ms")
+	v.SetDefault("log-sampling-nextvalidid-rate", 1)
+	v.SetDefault("log-sampling-nextvalidid-slow-threshold", "100ms")
 
 	// Cockroach.
 	v.SetDefault("cockroach", false)
 	v.SetDefault("cockroach-host-port", "")
 	v.SetDefault("cockroach-username", "")
 	v.SetDefault("cockroach-password", "")
+	v.SetDefault("cockroach-password-file", "")
 	v.SetDefault("cockroach-database", "")
+	v.SetDefault("cockroach-dialect", "cockroach")
+	v.SetDefault("cockroach-sslmode", "disable")
+	v.SetDefault("cockroach-sslrootcert", "")
+	v.SetDefault("cockroach-sslcert", "")
+	v.SetDefault("cockroach-sslkey", "")
+	v.SetDefault("cockroach-statement-timeout", "10s")
+	v.SetDefault("cockroach-max-open-conns", 10)
+	v.SetDefault("cockroach-max-idle-conns", 2)
+	v.SetDefault("cockroach-conn-max-lifetime", "30m")
+	v.SetDefault("cockroach-max-retries", 3)
+	v.SetDefault("cockroach-retry-backoff", "50ms")
 	v.SetDefault("cockroach-clean-interval", "24h")
 
 	// Jobs
@@ -745,6 +1328,12 @@ func config(logger log.Logger) *viper.Viper {
 	v.SetDefault("job-jaeger-health-validity", "1m")
 	v.SetDefault("job-redis-health-validity", "1m")
 	v.SetDefault("job-sentry-health-validity", "1m")
+	v.SetDefault("job-influx-health-schedule", "@minutely")
+	v.SetDefault("job-jaeger-health-schedule", "@minutely")
+	v.SetDefault("job-redis-health-schedule", "@minutely")
+	v.SetDefault("job-sentry-health-schedule", "@minutely")
+	v.SetDefault("job-clean-schedule", "")
+	v.SetDefault("job-failure-report-threshold", 3)
 
 	// Rate limiting
 	v.SetDefault("rate-next-id", 1000)
@@ -759,32 +1348,171 @@ func config(logger log.Logger) *viper.Viper {
 	v.SetDefault("rate-sentry-health-read", 1000)
 	v.SetDefault("rate-all-health", 1000)
 
-	// First level of override.
-	pflag.String("config-file", v.GetString("config-file"), "The configuration file path can be relative or absolute.")
-	v.BindPFlag("config-file", pflag.Lookup("config-file"))
-	pflag.Parse()
+	return v
+}
+
+func config(logger log.Logger) (*viper.Viper, error) {
+	logger.Log("msg", "load configuration and command args")
+
+	var v = defaultConfig()
+
+	// The environment variables FLAKID_<KEY> override the configuration file, e.g. FLAKID_REDIS_HOST_PORT
+	// for redis-host-port.
+	v.SetEnvPrefix("flakid")
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.AutomaticEnv()
+
+	// The command line flags override the environment variables, there is one flag per key. The
+	// flags are parsed once, the configuration reloads use the same values.
+	if !pflag.Parsed() {
+		pflag.String("config-file", v.GetString("config-file"), "The configuration file path can be relative or absolute.")
+		for _, k := range v.AllKeys() {
+			if k == "config-file" || derivedKeys[k] {
+				continue
+			}
+
+			var usage = fmt.Sprintf("overrides the configuration key '%s'", k)
+			switch d := v.Get(k).(type) {
+			case bool:
+				pflag.Bool(k, d, usage)
+			case int:
+				pflag.Int(k, d, usage)
+			case float64:
+				pflag.Float64(k, d, usage)
+			case []string:
+				pflag.StringSlice(k, d, usage)
+			case map[string]string:
+				pflag.String(k, "{}", usage+", as a JSON object")
+			default:
+				pflag.String(k, fmt.Sprint(d), usage)
+			}
+		}
+		pflag.Parse()
+	}
+	// Only the flags of the configuration keys are bound, not the ones of the commands.
+	for _, k := range v.AllKeys() {
+		if f := pflag.Lookup(k); f != nil {
+			v.BindPFlag(k, f)
+		}
+	}
 
-	// Load config.
+	// Load config. The default configuration file is optional.
+	var explicitConfigFile = pflag.Lookup("config-file").Changed || os.Getenv("FLAKID_CONFIG_FILE") != ""
 	v.SetConfigFile(v.GetString("config-file"))
 	var err = v.ReadInConfig()
-	if err != nil {
-		logger.Log("error", err)
+	switch {
+	case err == nil:
+	case os.IsNotExist(err) && !explicitConfigFile:
+		logger.Log("msg", "no configuration file", "error", err)
+	default:
+		return nil, errors.Wrapf(err, "could not read configuration file '%s'", v.GetString("config-file"))
 	}
 
 	// If the host/port is not set, we consider the components deactivated.
 	v.Set("influx", v.GetString("influx-host-port") != "")
-	v.Set("sentry", v.GetString("sentry-dsn") != "")
+	v.Set("sentry", v.GetString("sentry-dsn") != "" || v.GetString("sentry-dsn-file") != "")
+	v.Set("tracking-file", v.GetString("tracking-file-path") != "")
 	v.Set("jaeger", v.GetString("jaeger-sampler-host-port") != "")
+	v.Set("otel", v.GetString("otel-host-port") != "")
 	v.Set("redis", v.GetString("redis-host-port") != "")
+	v.Set("log-file", v.GetString("log-file-path") != "")
+	v.Set("log-logstash", v.GetString("log-logstash-host-port") != "")
+	v.Set("log-gelf", v.GetString("log-gelf-host-port") != "")
 	v.Set("cockroach", v.GetString("cockroach-host-port") != "")
 
-	// Log config in alphabetical order.
+	// The clean job is scheduled with the clean interval, unless it has its own schedule.
+	if v.GetString("job-clean-schedule") == "" {
+		v.Set("job-clean-schedule", fmt.Sprintf("@every %s", v.GetDuration("cockroach-clean-interval")))
+	}
+
+	return v, nil
+}
+
+// logConfig logs the configuration in alphabetical order, without the secrets.
+func logConfig(v *viper.Viper, logger log.Logger) {
+	var settings = redactedSettings(v)
+
 	var keys = v.AllKeys()
 	sort.Strings(keys)
 
 	for _, k := range keys {
-		logger.Log(k, v.Get(k))
+		logger.Log(k, settings[k])
 	}
+}
 
-	return v
+// redactedSettings returns the value of each configuration key, the secrets being redacted.
+func redactedSettings(v *viper.Viper) map[string]interface{} {
+	var secretKeys = map[string]bool{}
+	for _, k := range flakid.SecretKeys {
+		secretKeys[k] = true
+	}
+
+	var settings = map[string]interface{}{}
+	for _, k := range v.AllKeys() {
+		var value = v.Get(k)
+		if secretKeys[k] && v.GetString(k) != "" {
+			value = flakid.Redacted
+		}
+		settings[k] = value
+	}
+	return settings
+}
+
+// loadConfig decodes and validates the configuration. All the invalid values are returned.
+func loadConfig(v *viper.Viper) (flakid.Config, []error) {
+	var c, errs = flakid.DecodeConfig(v.AllSettings())
+	if len(errs) != 0 {
+		return c, errs
+	}
+	errs = c.Validate()
+
+	// The generator checks the ranges of the IDs.
+	if c.FlakiNodeID >= 0 && c.FlakiComponentID >= 0 {
+		var _, err = flaki_gen.New(flaki_gen.ComponentID(uint64(c.FlakiComponentID)), flaki_gen.NodeID(uint64(c.FlakiNodeID)))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid 'flaki-node-id' or 'flaki-component-id': %v", err))
+		}
+	}
+	if _, err := health.GetDialect(c.CockroachDialect); err != nil {
+		errs = append(errs, fmt.Errorf("invalid 'cockroach-dialect': %v", err))
+	}
+	return c, errs
+}
+
+// rateLimits returns the rate limit of each endpoint.
+func rateLimits(c flakid.Config) map[string]int {
+	return map[string]int{
+		"nextID":           c.RateNextID,
+		"nextValidID":      c.RateNextValidID,
+		"influxHealthExec": c.RateInfluxHealthExec,
+		"influxHealthRead": c.RateInfluxHealthRead,
+		"jaegerHealthExec": c.RateJaegerHealthExec,
+		"jaegerHealthRead": c.RateJaegerHealthRead,
+		"redisHealthExec":  c.RateRedisHealthExec,
+		"redisHealthRead":  c.RateRedisHealthRead,
+		"sentryHealthExec": c.RateSentryHealthExec,
+		"sentryHealthRead": c.RateSentryHealthRead,
+		"allHealth":        c.RateAllHealth,
+	}
+}
+
+// validities returns the validity of the health checks results of each unit.
+func validities(c flakid.Config) map[string]time.Duration {
+	return map[string]time.Duration{
+		influxKey: c.JobInfluxHealthValidity,
+		jaegerKey: c.JobJaegerHealthValidity,
+		redisKey:  c.JobRedisHealthValidity,
+		sentryKey: c.JobSentryHealthValidity,
+	}
+}
+
+// schedules returns the schedule of each job.
+func schedules(c flakid.Config) map[string]string {
+	return map[string]string{
+		influxKey: c.JobInfluxHealthSchedule,
+		jaegerKey: c.JobJaegerHealthSchedule,
+		redisKey:  c.JobRedisHealthSchedule,
+		sentryKey: c.JobSentryHealthSchedule,
+		cleanKey:  c.JobCleanSchedule,
+	}
 }
@@ -1,9 +1,132 @@
 package flakid
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/lib/pq"
 )
 
+// CockroachConfig is the configuration of the connection to the Cockroach DB.
+type CockroachConfig struct {
+	HostPort string
+	Username string
+	Password string
+	Database string
+
+	// SSLMode is one of "disable", "require", "verify-ca" or "verify-full".
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	// StatementTimeout aborts the statements that take longer. Zero means no timeout.
+	StatementTimeout time.Duration
+}
+
+// DSN returns the connection string of the Cockroach DB.
+func (c CockroachConfig) DSN() (string, error) {
+	var params = url.Values{}
+
+	switch c.SSLMode {
+	case "disable", "require", "verify-ca", "verify-full":
+		params.Set("sslmode", c.SSLMode)
+	default:
+		return "", fmt.Errorf("invalid cockroach SSL mode '%s'", c.SSLMode)
+	}
+
+	if c.SSLRootCert != "" {
+		params.Set("sslrootcert", c.SSLRootCert)
+	}
+	if c.SSLCert != "" {
+		params.Set("sslcert", c.SSLCert)
+	}
+	if c.SSLKey != "" {
+		params.Set("sslkey", c.SSLKey)
+	}
+	if c.StatementTimeout > 0 {
+		params.Set("statement_timeout", fmt.Sprintf("%d", c.StatementTimeout/time.Millisecond))
+	}
+
+	var dsn = url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(c.Username, c.Password),
+		Host:     c.HostPort,
+		Path:     "/" + c.Database,
+		RawQuery: params.Encode(),
+	}
+	return dsn.String(), nil
+}
+
+// CockroachPoolConfig is the configuration of the pool of connections to the Cockroach DB.
+type CockroachPoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// CockroachOption is an option of the connection to the Cockroach DB.
+type CockroachOption func(*cockroachConnector)
+
+// CockroachPasswordSource sets the function that returns the password of the new connections,
+// instead of the password of the configuration. It allows the rotation of the password without restart.
+func CockroachPasswordSource(f func() string) CockroachOption {
+	return func(c *cockroachConnector) {
+		c.password = f
+	}
+}
+
+// OpenCockroach opens the Cockroach DB and configures its pool of connections.
+func OpenCockroach(c CockroachConfig, pool CockroachPoolConfig, options ...CockroachOption) (*sql.DB, error) {
+	var _, err = c.DSN()
+	if err != nil {
+		return nil, err
+	}
+
+	var connector = &cockroachConnector{
+		config:   c,
+		password: func() string { return c.Password },
+		open:     pq.Open,
+	}
+	for _, opt := range options {
+		opt(connector)
+	}
+
+	var db = sql.OpenDB(connector)
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	return db, nil
+}
+
+// cockroachConnector opens the connections to the Cockroach DB, each with the current password.
+type cockroachConnector struct {
+	config   CockroachConfig
+	password func() string
+	open     func(dsn string) (driver.Conn, error)
+}
+
+// Connect implements driver.Connector.
+func (c *cockroachConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	var config = c.config
+	config.Password = c.password()
+
+	var dsn, err = config.DSN()
+	if err != nil {
+		return nil, err
+	}
+	return c.open(dsn)
+}
+
+// Driver implements driver.Connector.
+func (c *cockroachConnector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
 // NoopCockroach is a cockroach client that does nothing.
 type NoopCockroach struct{}
 
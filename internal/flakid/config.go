@@ -0,0 +1,523 @@
+package flakid
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudtrust/flaki-service/pkg/flaki"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// Config is the configuration of flakid. The fields are named after the configuration keys.
+type Config struct {
+	// Component.
+	ConfigFile   string `mapstructure:"config-file"`
+	ConfigWatch  bool   `mapstructure:"config-watch"`
+	HTTPHostPort string `mapstructure:"component-http-host-port"`
+	GRPCHostPort string `mapstructure:"component-grpc-host-port"`
+
+	// Secrets.
+	SecretReloadInterval time.Duration `mapstructure:"secret-reload-interval"`
+
+	// Flaki generator.
+	FlakiNodeID      int `mapstructure:"flaki-node-id"`
+	FlakiComponentID int `mapstructure:"flaki-component-id"`
+
+	// Influx DB client.
+	Influx                 bool          `mapstructure:"influx"`
+	InfluxHostPort         string        `mapstructure:"influx-host-port"`
+	InfluxUsername         string        `mapstructure:"influx-username"`
+	InfluxPassword         string        `mapstructure:"influx-password"`
+	InfluxPasswordFile     string        `mapstructure:"influx-password-file"`
+	InfluxDatabase         string        `mapstructure:"influx-database"`
+	InfluxPrecision        string        `mapstructure:"influx-precision"`
+	InfluxRetentionPolicy  string        `mapstructure:"influx-retention-policy"`
+	InfluxWriteConsistency string        `mapstructure:"influx-write-consistency"`
+	InfluxWriteInterval    time.Duration `mapstructure:"influx-write-interval"`
+
+	// Error tracking.
+	Sentry              bool   `mapstructure:"sentry"`
+	SentryDSN           string `mapstructure:"sentry-dsn"`
+	SentryDSNFile       string `mapstructure:"sentry-dsn-file"`
+	TrackingFile        bool   `mapstructure:"tracking-file"`
+	TrackingFilePath    string `mapstructure:"tracking-file-path"`
+	TrackingBreadcrumbs int    `mapstructure:"tracking-breadcrumbs"`
+
+	// Jaeger tracing.
+	Jaeger                             bool          `mapstructure:"jaeger"`
+	JaegerSamplerType                  string        `mapstructure:"jaeger-sampler-type"`
+	JaegerSamplerParam                 float64       `mapstructure:"jaeger-sampler-param"`
+	JaegerSamplerHostPort              string        `mapstructure:"jaeger-sampler-host-port"`
+	JaegerReporterLogSpan              bool          `mapstructure:"jaeger-reporter-logspan"`
+	JaegerWriteInterval                time.Duration `mapstructure:"jaeger-write-interval"`
+	JaegerCollectorHealthcheckHostPort string        `mapstructure:"jaeger-collector-healthcheck-host-port"`
+	TracingPropagators                 []string      `mapstructure:"tracing-propagators"`
+
+	// OpenTelemetry tracing.
+	OTel                   bool              `mapstructure:"otel"`
+	OTelHostPort           string            `mapstructure:"otel-host-port"`
+	OTelProtocol           string            `mapstructure:"otel-protocol"`
+	OTelInsecure           bool              `mapstructure:"otel-insecure"`
+	OTelSampler            string            `mapstructure:"otel-sampler"`
+	OTelSamplerRatio       float64           `mapstructure:"otel-sampler-ratio"`
+	OTelResourceAttributes map[string]string `mapstructure:"otel-resource-attributes"`
+
+	// Debug routes.
+	PprofRouteEnabled bool `mapstructure:"pprof-route-enabled"`
+
+	// Redis.
+	Redis                  bool          `mapstructure:"redis"`
+	RedisHostPort          string        `mapstructure:"redis-host-port"`
+	RedisPassword          string        `mapstructure:"redis-password"`
+	RedisPasswordFile      string        `mapstructure:"redis-password-file"`
+	RedisDatabase          int           `mapstructure:"redis-database"`
+	RedisMaxIdle           int           `mapstructure:"redis-max-idle"`
+	RedisMaxActive         int           `mapstructure:"redis-max-active"`
+	RedisIdleTimeout       time.Duration `mapstructure:"redis-idle-timeout"`
+	RedisDialTimeout       time.Duration `mapstructure:"redis-dial-timeout"`
+	RedisReadTimeout       time.Duration `mapstructure:"redis-read-timeout"`
+	RedisWriteTimeout      time.Duration `mapstructure:"redis-write-timeout"`
+	RedisTimeout           time.Duration `mapstructure:"redis-timeout"`
+	RedisFailFast          bool          `mapstructure:"redis-fail-fast"`
+	RedisBackoffInitial    time.Duration `mapstructure:"redis-backoff-initial"`
+	RedisBackoffMax        time.Duration `mapstructure:"redis-backoff-max"`
+	RedisBackoffMultiplier float64       `mapstructure:"redis-backoff-multiplier"`
+	RedisBackoffJitter     float64       `mapstructure:"redis-backoff-jitter"`
+	RedisWriteInterval     time.Duration `mapstructure:"redis-write-interval"`
+	RedisLogQueueSize      int           `mapstructure:"redis-log-queue-size"`
+	RedisLogBatchSize      int           `mapstructure:"redis-log-batch-size"`
+	RedisLogDropPolicy     string        `mapstructure:"redis-log-drop-policy"`
+	RedisLogSpillTarget    string        `mapstructure:"redis-log-spill-target"`
+	RedisLogSchema         string        `mapstructure:"redis-log-schema"`
+
+	// Log sinks.
+	LogLevel            string        `mapstructure:"log-level"`
+	LogFile             bool          `mapstructure:"log-file"`
+	LogFilePath         string        `mapstructure:"log-file-path"`
+	LogFileMaxSize      int64         `mapstructure:"log-file-max-size"`
+	LogFileMaxAge       time.Duration `mapstructure:"log-file-max-age"`
+	LogFileMaxBackups   int           `mapstructure:"log-file-max-backups"`
+	LogFileCompress     bool          `mapstructure:"log-file-compress"`
+	LogLogstash         bool          `mapstructure:"log-logstash"`
+	LogLogstashNetwork  string        `mapstructure:"log-logstash-network"`
+	LogLogstashHostPort string        `mapstructure:"log-logstash-host-port"`
+	LogLogstashSchema   string        `mapstructure:"log-logstash-schema"`
+	LogLogstashTimeout  time.Duration `mapstructure:"log-logstash-timeout"`
+	LogGELF             bool          `mapstructure:"log-gelf"`
+	LogGELFHostPort     string        `mapstructure:"log-gelf-host-port"`
+	LogSyslog           bool          `mapstructure:"log-syslog"`
+	LogSyslogFacility   string        `mapstructure:"log-syslog-facility"`
+	LogSyslogTag        string        `mapstructure:"log-syslog-tag"`
+
+	// Log sampling.
+	LogSamplingNextIDRate               int           `mapstructure:"log-sampling-nextid-rate"`
+	LogSamplingNextIDSlowThreshold      time.Duration `mapstructure:"log-sampling-nextid-slow-threshold"`
+	LogSamplingNextValidIDRate          int           `mapstructure:"log-sampling-nextvalidid-rate"`
+	LogSamplingNextValidIDSlowThreshold time.Duration `mapstructure:"log-sampling-nextvalidid-slow-threshold"`
+
+	// Cockroach.
+	Cockroach                 bool          `mapstructure:"cockroach"`
+	CockroachHostPort         string        `mapstructure:"cockroach-host-port"`
+	CockroachUsername         string        `mapstructure:"cockroach-username"`
+	CockroachPassword         string        `mapstructure:"cockroach-password"`
+	CockroachPasswordFile     string        `mapstructure:"cockroach-password-file"`
+	CockroachDatabase         string        `mapstructure:"cockroach-database"`
+	CockroachDialect          string        `mapstructure:"cockroach-dialect"`
+	CockroachSSLMode          string        `mapstructure:"cockroach-sslmode"`
+	CockroachSSLRootCert      string        `mapstructure:"cockroach-sslrootcert"`
+	CockroachSSLCert          string        `mapstructure:"cockroach-sslcert"`
+	CockroachSSLKey           string        `mapstructure:"cockroach-sslkey"`
+	CockroachStatementTimeout time.Duration `mapstructure:"cockroach-statement-timeout"`
+	CockroachMaxOpenConns     int           `mapstructure:"cockroach-max-open-conns"`
+	CockroachMaxIdleConns     int           `mapstructure:"cockroach-max-idle-conns"`
+	CockroachConnMaxLifetime  time.Duration `mapstructure:"cockroach-conn-max-lifetime"`
+	CockroachMaxRetries       int           `mapstructure:"cockroach-max-retries"`
+	CockroachRetryBackoff     time.Duration `mapstructure:"cockroach-retry-backoff"`
+	CockroachCleanInterval    time.Duration `mapstructure:"cockroach-clean-interval"`
+
+	// Jobs.
+	JobInfluxHealthValidity   time.Duration `mapstructure:"job-influx-health-validity"`
+	JobJaegerHealthValidity   time.Duration `mapstructure:"job-jaeger-health-validity"`
+	JobRedisHealthValidity    time.Duration `mapstructure:"job-redis-health-validity"`
+	JobSentryHealthValidity   time.Duration `mapstructure:"job-sentry-health-validity"`
+	JobInfluxHealthSchedule   string        `mapstructure:"job-influx-health-schedule"`
+	JobJaegerHealthSchedule   string        `mapstructure:"job-jaeger-health-schedule"`
+	JobRedisHealthSchedule    string        `mapstructure:"job-redis-health-schedule"`
+	JobSentryHealthSchedule   string        `mapstructure:"job-sentry-health-schedule"`
+	JobCleanSchedule          string        `mapstructure:"job-clean-schedule"`
+	JobFailureReportThreshold int           `mapstructure:"job-failure-report-threshold"`
+
+	// Rate limiting.
+	RateNextID           int `mapstructure:"rate-next-id"`
+	RateNextValidID      int `mapstructure:"rate-next-valid-id"`
+	RateInfluxHealthExec int `mapstructure:"rate-influx-health-exec"`
+	RateInfluxHealthRead int `mapstructure:"rate-influx-health-read"`
+	RateJaegerHealthExec int `mapstructure:"rate-jaeger-health-exec"`
+	RateJaegerHealthRead int `mapstructure:"rate-jaeger-health-read"`
+	RateRedisHealthExec  int `mapstructure:"rate-redis-health-exec"`
+	RateRedisHealthRead  int `mapstructure:"rate-redis-health-read"`
+	RateSentryHealthExec int `mapstructure:"rate-sentry-health-exec"`
+	RateSentryHealthRead int `mapstructure:"rate-sentry-health-read"`
+	RateAllHealth        int `mapstructure:"rate-all-health"`
+}
+
+// DecodeConfig decodes the configuration settings, as returned by viper's AllSettings. The values
+// are weakly typed: the durations are parsed from strings such as "1s", the lists from comma
+// separated strings and the maps from JSON objects, so they can be given as flags or environment
+// variables. All the values that cannot be decoded are returned.
+func DecodeConfig(settings map[string]interface{}) (Config, []error) {
+	var c Config
+
+	var decoder, err = mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+			stringToMapHookFunc,
+		),
+		WeaklyTypedInput: true,
+		Result:           &c,
+	})
+	if err != nil {
+		return c, []error{err}
+	}
+
+	err = decoder.Decode(settings)
+	switch e := err.(type) {
+	case nil:
+		return c, nil
+	case *mapstructure.Error:
+		var errs = make([]error, 0, len(e.Errors))
+		for _, msg := range e.Errors {
+			errs = append(errs, errors.New(msg))
+		}
+		return c, errs
+	default:
+		return c, []error{err}
+	}
+}
+
+// stringToMapHookFunc decodes the maps given as JSON objects.
+func stringToMapHookFunc(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+	if f.Kind() != reflect.String || t.Kind() != reflect.Map {
+		return data, nil
+	}
+
+	var m = map[string]string{}
+	if s := data.(string); s != "" {
+		if err := json.Unmarshal([]byte(s), &m); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Validate checks the values of the configuration, and returns one error per invalid key. The
+// settings of the disabled units are not checked.
+func (c Config) Validate() []error {
+	var v = &validator{}
+
+	// Component.
+	v.hostPort("component-http-host-port", c.HTTPHostPort)
+	v.hostPort("component-grpc-host-port", c.GRPCHostPort)
+
+	// Secrets.
+	for k, s := range c.secretSources() {
+		if s[0] != "" && s[1] != "" {
+			v.check(k, fmt.Errorf("both '%s' and '%s-file' are set", k, k))
+		}
+	}
+	v.positiveDuration("secret-reload-interval", c.SecretReloadInterval)
+
+	// Flaki generator. The IDs must fit in the bits reserved for them in the generated IDs.
+	v.between("flaki-node-id", c.FlakiNodeID, 0, flaki.MaxNodeID)
+	v.between("flaki-component-id", c.FlakiComponentID, 0, flaki.MaxComponentID)
+
+	// Influx DB client.
+	if c.Influx {
+		v.hostPort("influx-host-port", c.InfluxHostPort)
+		v.positiveDuration("influx-write-interval", c.InfluxWriteInterval)
+	}
+
+	// Error tracking.
+	v.nonNegative("tracking-breadcrumbs", c.TrackingBreadcrumbs)
+
+	// Tracing.
+	if c.Jaeger {
+		v.hostPort("jaeger-sampler-host-port", c.JaegerSamplerHostPort)
+		v.positiveDuration("jaeger-write-interval", c.JaegerWriteInterval)
+	}
+	if c.Jaeger || c.OTel {
+		var _, err = JaegerPropagationOptions(c.TracingPropagators)
+		v.check("tracing-propagators", err)
+	}
+	if c.OTel {
+		v.hostPort("otel-host-port", c.OTelHostPort)
+		if c.OTelProtocol != "grpc" && c.OTelProtocol != "http" {
+			v.check("otel-protocol", fmt.Errorf("unknown OTLP protocol '%s'", c.OTelProtocol))
+		}
+		var _, err = otelSampler(c.OTelSampler, c.OTelSamplerRatio)
+		v.check("otel-sampler", err)
+		v.ratio("otel-sampler-ratio", c.OTelSamplerRatio)
+	}
+
+	// Redis.
+	if c.Redis {
+		v.hostPort("redis-host-port", c.RedisHostPort)
+		v.nonNegative("redis-database", c.RedisDatabase)
+		v.nonNegative("redis-max-idle", c.RedisMaxIdle)
+		v.nonNegative("redis-max-active", c.RedisMaxActive)
+		v.positiveDuration("redis-backoff-initial", c.RedisBackoffInitial)
+		v.positiveDuration("redis-backoff-max", c.RedisBackoffMax)
+		if c.RedisBackoffMultiplier < 1 {
+			v.check("redis-backoff-multiplier", fmt.Errorf("%g is lower than 1", c.RedisBackoffMultiplier))
+		}
+		v.ratio("redis-backoff-jitter", c.RedisBackoffJitter)
+		v.positiveDuration("redis-write-interval", c.RedisWriteInterval)
+		v.positive("redis-log-queue-size", c.RedisLogQueueSize)
+		v.positive("redis-log-batch-size", c.RedisLogBatchSize)
+		var _, err = GetDropPolicy(c.RedisLogDropPolicy)
+		v.check("redis-log-drop-policy", err)
+		_, err = GetSpillWriter(c.RedisLogSpillTarget)
+		v.check("redis-log-spill-target", err)
+		_, err = GetSchema(c.RedisLogSchema)
+		v.check("redis-log-schema", err)
+	}
+
+	// Log sinks.
+	var _, err = parseLevel(c.LogLevel)
+	v.check("log-level", err)
+	if c.LogFile {
+		v.nonNegative("log-file-max-size", int(c.LogFileMaxSize))
+		v.nonNegative("log-file-max-backups", c.LogFileMaxBackups)
+	}
+	if c.LogLogstash {
+		v.hostPort("log-logstash-host-port", c.LogLogstashHostPort)
+		_, err = GetSchema(c.LogLogstashSchema)
+		v.check("log-logstash-schema", err)
+	}
+	if c.LogGELF {
+		v.hostPort("log-gelf-host-port", c.LogGELFHostPort)
+	}
+	if c.LogSyslog {
+		if _, ok := syslogFacilities[c.LogSyslogFacility]; !ok {
+			v.check("log-syslog-facility", fmt.Errorf("unknown syslog facility '%s'", c.LogSyslogFacility))
+		}
+	}
+
+	// Log sampling.
+	v.positive("log-sampling-nextid-rate", c.LogSamplingNextIDRate)
+	v.positive("log-sampling-nextvalidid-rate", c.LogSamplingNextValidIDRate)
+
+	// Cockroach.
+	if c.Cockroach {
+		v.hostPort("cockroach-host-port", c.CockroachHostPort)
+		_, err = CockroachConfig{SSLMode: c.CockroachSSLMode}.DSN()
+		v.check("cockroach-sslmode", err)
+		v.nonNegative("cockroach-max-open-conns", c.CockroachMaxOpenConns)
+		v.nonNegative("cockroach-max-idle-conns", c.CockroachMaxIdleConns)
+		v.nonNegative("cockroach-max-retries", c.CockroachMaxRetries)
+		v.positiveDuration("cockroach-clean-interval", c.CockroachCleanInterval)
+	}
+
+	// Jobs.
+	v.positiveDuration("job-influx-health-validity", c.JobInfluxHealthValidity)
+	v.positiveDuration("job-jaeger-health-validity", c.JobJaegerHealthValidity)
+	v.positiveDuration("job-redis-health-validity", c.JobRedisHealthValidity)
+	v.positiveDuration("job-sentry-health-validity", c.JobSentryHealthValidity)
+	v.positive("job-failure-report-threshold", c.JobFailureReportThreshold)
+
+	// Rate limiting.
+	for k, r := range map[string]int{
+		"rate-next-id":            c.RateNextID,
+		"rate-next-valid-id":      c.RateNextValidID,
+		"rate-influx-health-exec": c.RateInfluxHealthExec,
+		"rate-influx-health-read": c.RateInfluxHealthRead,
+		"rate-jaeger-health-exec": c.RateJaegerHealthExec,
+		"rate-jaeger-health-read": c.RateJaegerHealthRead,
+		"rate-redis-health-exec":  c.RateRedisHealthExec,
+		"rate-redis-health-read":  c.RateRedisHealthRead,
+		"rate-sentry-health-exec": c.RateSentryHealthExec,
+		"rate-sentry-health-read": c.RateSentryHealthRead,
+		"rate-all-health":         c.RateAllHealth,
+	} {
+		v.positive(k, r)
+	}
+
+	v.sort()
+	return v.errs
+}
+
+// Secrets returns the secrets of the configuration, indexed by their key. The secrets given as file
+// are read, the errors are returned.
+func (c Config) Secrets() (map[string]*Secret, []error) {
+	var v = &validator{}
+	var secrets = map[string]*Secret{}
+	for k, s := range c.secretSources() {
+		var secret, err = NewSecret(s[0], s[1])
+		if err != nil {
+			v.check(k+"-file", err)
+			continue
+		}
+		secrets[k] = secret
+	}
+	v.sort()
+	return secrets, v.errs
+}
+
+// secretSources returns the value and the file of each secret.
+func (c Config) secretSources() map[string][2]string {
+	return map[string][2]string{
+		"cockroach-password": {c.CockroachPassword, c.CockroachPasswordFile},
+		"influx-password":    {c.InfluxPassword, c.InfluxPasswordFile},
+		"redis-password":     {c.RedisPassword, c.RedisPasswordFile},
+		"sentry-dsn":         {c.SentryDSN, c.SentryDSNFile},
+	}
+}
+
+// reloadableKeys are the configuration keys applied live when the configuration is reloaded.
+// The changes of the other keys require a restart.
+var reloadableKeys = map[string]bool{
+	"log-level":                  true,
+	"cockroach-clean-interval":   true,
+	"job-influx-health-validity": true,
+	"job-jaeger-health-validity": true,
+	"job-redis-health-validity":  true,
+	"job-sentry-health-validity": true,
+	"job-influx-health-schedule": true,
+	"job-jaeger-health-schedule": true,
+	"job-redis-health-schedule":  true,
+	"job-sentry-health-schedule": true,
+	"job-clean-schedule":         true,
+	"rate-next-id":               true,
+	"rate-next-valid-id":         true,
+	"rate-influx-health-exec":    true,
+	"rate-influx-health-read":    true,
+	"rate-jaeger-health-exec":    true,
+	"rate-jaeger-health-read":    true,
+	"rate-redis-health-exec":     true,
+	"rate-redis-health-read":     true,
+	"rate-sentry-health-exec":    true,
+	"rate-sentry-health-read":    true,
+	"rate-all-health":            true,
+}
+
+// immutableKeys can never change: the flaki IDs would no longer be unique.
+var immutableKeys = map[string]bool{
+	"flaki-node-id":      true,
+	"flaki-component-id": true,
+}
+
+// ConfigChange is the change of a configuration key. The values of the secrets are redacted.
+type ConfigChange struct {
+	Key string
+	Old string
+	New string
+	// Applied is false if the change requires a restart.
+	Applied bool
+}
+
+// Reload returns the configuration with the reloadable values of 'loaded', and all the changes
+// between the two configurations, sorted by key. If an immutable key, i.e. the flaki node or
+// component ID, changed, the reload is rejected and the configuration is returned unchanged.
+func (c Config) Reload(loaded Config) (Config, []ConfigChange, error) {
+	var reloaded = c
+	var running = reflect.ValueOf(&reloaded).Elem()
+	var next = reflect.ValueOf(loaded)
+
+	var secrets = map[string]bool{}
+	for _, k := range SecretKeys {
+		secrets[k] = true
+	}
+
+	var changes = []ConfigChange{}
+	var rejected = []string{}
+	for i := 0; i < running.NumField(); i++ {
+		var key = running.Type().Field(i).Tag.Get("mapstructure")
+		var oldValue, newValue = running.Field(i).Interface(), next.Field(i).Interface()
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		var change = ConfigChange{
+			Key:     key,
+			Old:     fmt.Sprint(oldValue),
+			New:     fmt.Sprint(newValue),
+			Applied: reloadableKeys[key],
+		}
+		if secrets[key] {
+			change.Old, change.New = Redacted, Redacted
+		}
+		if immutableKeys[key] {
+			rejected = append(rejected, fmt.Sprintf("'%s' from %s to %s", key, change.Old, change.New))
+		}
+		if change.Applied {
+			running.Field(i).Set(next.Field(i))
+		}
+		changes = append(changes, change)
+	}
+
+	if len(rejected) != 0 {
+		sort.Strings(rejected)
+		return c, nil, fmt.Errorf("reload rejected, cannot change %s", strings.Join(rejected, ", "))
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return reloaded, changes, nil
+}
+
+// validator collects the configuration errors.
+type validator struct {
+	errs []error
+}
+
+func (v *validator) check(key string, err error) {
+	if err != nil {
+		v.errs = append(v.errs, fmt.Errorf("invalid '%s': %v", key, err))
+	}
+}
+
+func (v *validator) hostPort(key, hostPort string) {
+	var _, _, err = net.SplitHostPort(hostPort)
+	v.check(key, err)
+}
+
+func (v *validator) positive(key string, n int) {
+	if n <= 0 {
+		v.check(key, fmt.Errorf("%d is not positive", n))
+	}
+}
+
+func (v *validator) nonNegative(key string, n int) {
+	if n < 0 {
+		v.check(key, fmt.Errorf("%d is negative", n))
+	}
+}
+
+func (v *validator) between(key string, n, min, max int) {
+	if n < min || n > max {
+		v.check(key, fmt.Errorf("%d is not between %d and %d", n, min, max))
+	}
+}
+
+func (v *validator) positiveDuration(key string, d time.Duration) {
+	if d <= 0 {
+		v.check(key, fmt.Errorf("%s is not positive", d))
+	}
+}
+
+func (v *validator) ratio(key string, r float64) {
+	if r < 0 || r > 1 {
+		v.check(key, fmt.Errorf("%g is not between 0 and 1", r))
+	}
+}
+
+// sort orders the errors by key, so they are reported in the same order as the logged configuration.
+func (v *validator) sort() {
+	sort.Slice(v.errs, func(i, j int) bool { return v.errs[i].Error() < v.errs[j].Error() })
+}
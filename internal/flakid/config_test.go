@@ -0,0 +1,188 @@
+package flakid
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/flaki-service/pkg/flaki"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeConfig(t *testing.T) {
+	// The values from flags and environment variables are strings.
+	var c, errs = DecodeConfig(map[string]interface{}{
+		"component-http-host-port": "0.0.0.0:8888",
+		"flaki-node-id":            "3",
+		"redis":                    "true",
+		"redis-timeout":            "2s",
+		"redis-backoff-jitter":     "0.5",
+		"tracing-propagators":      "jaeger,w3c",
+		"otel-resource-attributes": `{"service.name": "flakid"}`,
+		"influx-write-interval":    1000,
+	})
+	assert.Empty(t, errs)
+	assert.Equal(t, "0.0.0.0:8888", c.HTTPHostPort)
+	assert.Equal(t, 3, c.FlakiNodeID)
+	assert.True(t, c.Redis)
+	assert.Equal(t, 2*time.Second, c.RedisTimeout)
+	assert.Equal(t, 0.5, c.RedisBackoffJitter)
+	assert.Equal(t, []string{"jaeger", "w3c"}, c.TracingPropagators)
+	assert.Equal(t, map[string]string{"service.name": "flakid"}, c.OTelResourceAttributes)
+	assert.Equal(t, 1000*time.Nanosecond, c.InfluxWriteInterval)
+
+	// From the config file.
+	c, errs = DecodeConfig(map[string]interface{}{
+		"tracing-propagators":      []interface{}{"b3"},
+		"otel-resource-attributes": map[string]interface{}{"service.name": "flakid"},
+	})
+	assert.Empty(t, errs)
+	assert.Equal(t, []string{"b3"}, c.TracingPropagators)
+	assert.Equal(t, map[string]string{"service.name": "flakid"}, c.OTelResourceAttributes)
+
+	// All the invalid values are reported.
+	_, errs = DecodeConfig(map[string]interface{}{
+		"redis-timeout":            "2 seconds",
+		"flaki-node-id":            "one",
+		"otel-resource-attributes": "service.name=flakid",
+	})
+	assert.Len(t, errs, 3)
+}
+
+func TestValidateConfig(t *testing.T) {
+	var c = validConfig()
+	assert.Empty(t, c.Validate())
+
+	// The settings of the disabled units are not checked.
+	c.Redis = false
+	c.RedisHostPort = "redis"
+	c.RedisLogSchema = "unknown"
+	assert.Empty(t, c.Validate())
+
+	// Invalid values.
+	c = validConfig()
+	c.GRPCHostPort = "5555"
+	c.FlakiNodeID = -1
+	c.RedisBackoffJitter = 1.5
+	c.RedisLogDropPolicy = "unknown"
+	c.RedisLogSpillTarget = "stdin"
+	c.LogLevel = "verbose"
+	c.CockroachSSLMode = "unknown"
+	c.RateNextID = 0
+	c.RedisPassword = "password"
+	c.RedisPasswordFile = "/run/secrets/redis-password"
+	var errs = c.Validate()
+	assert.Len(t, errs, 9)
+	assert.Contains(t, errs[0].Error(), "'cockroach-sslmode'")
+	assert.Contains(t, errs[1].Error(), "'component-grpc-host-port'")
+
+	// The flaki IDs are bounded by the generator.
+	c = validConfig()
+	c.FlakiNodeID = flaki.MaxNodeID
+	c.FlakiComponentID = flaki.MaxComponentID
+	assert.Empty(t, c.Validate())
+	c.FlakiNodeID = flaki.MaxNodeID + 1
+	c.FlakiComponentID = flaki.MaxComponentID + 1
+	errs = c.Validate()
+	assert.Len(t, errs, 2)
+	assert.Equal(t, fmt.Sprintf("invalid 'flaki-component-id': %d is not between 0 and %d", flaki.MaxComponentID+1, flaki.MaxComponentID), errs[0].Error())
+	assert.Equal(t, fmt.Sprintf("invalid 'flaki-node-id': %d is not between 0 and %d", flaki.MaxNodeID+1, flaki.MaxNodeID), errs[1].Error())
+}
+
+func validConfig() Config {
+	return Config{
+		SecretReloadInterval:       time.Minute,
+		HTTPHostPort:               "0.0.0.0:8888",
+		GRPCHostPort:               "0.0.0.0:5555",
+		Redis:                      true,
+		RedisHostPort:              "redis:6379",
+		RedisBackoffInitial:        100 * time.Millisecond,
+		RedisBackoffMax:            5 * time.Second,
+		RedisBackoffMultiplier:     2,
+		RedisBackoffJitter:         0.2,
+		RedisWriteInterval:         time.Second,
+		RedisLogQueueSize:          10000,
+		RedisLogBatchSize:          100,
+		RedisLogDropPolicy:         "drop-oldest",
+		RedisLogSpillTarget:        "stderr",
+		RedisLogSchema:             "logstash-v1",
+		LogLevel:                   "info",
+		LogSamplingNextIDRate:      1,
+		LogSamplingNextValidIDRate: 1,
+		Cockroach:                  true,
+		CockroachHostPort:          "cockroach:26257",
+		CockroachSSLMode:           "disable",
+		CockroachCleanInterval:     24 * time.Hour,
+		JobInfluxHealthValidity:    time.Minute,
+		JobJaegerHealthValidity:    time.Minute,
+		JobRedisHealthValidity:     time.Minute,
+		JobSentryHealthValidity:    time.Minute,
+		JobFailureReportThreshold:  3,
+		RateNextID:                 1000,
+		RateNextValidID:            1000,
+		RateInfluxHealthExec:       1000,
+		RateInfluxHealthRead:       1000,
+		RateJaegerHealthExec:       1000,
+		RateJaegerHealthRead:       1000,
+		RateRedisHealthExec:        1000,
+		RateRedisHealthRead:        1000,
+		RateSentryHealthExec:       1000,
+		RateSentryHealthRead:       1000,
+		RateAllHealth:              1000,
+	}
+}
+
+func TestConfigSecrets(t *testing.T) {
+	var c = Config{
+		RedisPassword:         "password",
+		CockroachPasswordFile: "/does/not/exist",
+	}
+
+	var secrets, errs = c.Secrets()
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "'cockroach-password-file'")
+	assert.Equal(t, "password", secrets["redis-password"].Value())
+	assert.Equal(t, "", secrets["sentry-dsn"].Value())
+	assert.NotContains(t, secrets, "cockroach-password")
+}
+
+func TestConfigReload(t *testing.T) {
+	var c = validConfig()
+	c.FlakiNodeID = 1
+
+	// No change.
+	var reloaded, changes, err = c.Reload(c)
+	assert.Nil(t, err)
+	assert.Empty(t, changes)
+	assert.Equal(t, c, reloaded)
+
+	// The reloadable changes are applied, the other ones require a restart.
+	var loaded = validConfig()
+	loaded.FlakiNodeID = 1
+	loaded.RateNextID = 10
+	loaded.LogLevel = "debug"
+	loaded.JobCleanSchedule = "@hourly"
+	loaded.RedisHostPort = "redis:6380"
+	loaded.RedisPassword = "password"
+	reloaded, changes, err = c.Reload(loaded)
+	assert.Nil(t, err)
+	assert.Equal(t, []ConfigChange{
+		{Key: "job-clean-schedule", Old: "", New: "@hourly", Applied: true},
+		{Key: "log-level", Old: "info", New: "debug", Applied: true},
+		{Key: "rate-next-id", Old: "1000", New: "10", Applied: true},
+		{Key: "redis-host-port", Old: "redis:6379", New: "redis:6380", Applied: false},
+		{Key: "redis-password", Old: Redacted, New: Redacted, Applied: false},
+	}, changes)
+	assert.Equal(t, 10, reloaded.RateNextID)
+	assert.Equal(t, "debug", reloaded.LogLevel)
+	assert.Equal(t, "redis:6379", reloaded.RedisHostPort)
+	assert.Equal(t, "", reloaded.RedisPassword)
+
+	// The IDs cannot change.
+	loaded.FlakiNodeID = 2
+	reloaded, changes, err = c.Reload(loaded)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "'flaki-node-id' from 1 to 2")
+	assert.Nil(t, changes)
+	assert.Equal(t, c, reloaded)
+}
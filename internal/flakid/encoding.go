@@ -0,0 +1,181 @@
+package flakid
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Schema is the format of the logs sent to Redis.
+type Schema string
+
+const (
+	// LogstashV1 is the logstash v1 event format, with the log fields under "@fields".
+	LogstashV1 Schema = "logstash-v1"
+	// ECS is the Elastic Common Schema.
+	ECS Schema = "ecs"
+	// Flat is a JSON object without nesting, the nested objects keys are joined with dots.
+	Flat Schema = "flat"
+)
+
+// ecsVersion is the version of the Elastic Common Schema used by the ECS encoder.
+const ecsVersion = "1.12.0"
+
+// GetSchema returns the schema named 'name'.
+func GetSchema(name string) (Schema, error) {
+	switch s := Schema(name); s {
+	case LogstashV1, ECS, Flat:
+		return s, nil
+	default:
+		return "", fmt.Errorf("unknown log schema '%s'", name)
+	}
+}
+
+// encoder encodes the decoded go-kit logs.
+type encoder func(map[string]interface{}) ([]byte, error)
+
+func (s Schema) encoder() encoder {
+	switch s {
+	case ECS:
+		return ecsEncode
+	case Flat:
+		return flatEncode
+	default:
+		return logstashEncode
+	}
+}
+
+// decodeLogs decodes the JSON logs of the go-kit JSONLogger. The numbers are kept as
+// json.Number so they are encoded back without loss of precision.
+func decodeLogs(data []byte) (map[string]interface{}, error) {
+	var d = json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var m = map[string]interface{}{}
+	var err = d.Decode(&m)
+	return m, err
+}
+
+// logstashLog is the logstash log format.
+type logstashLog struct {
+	Timestamp       string                 `json:"@timestamp"`
+	LogstashVersion int                    `json:"@version"`
+	Fields          map[string]interface{} `json:"@fields"`
+	Message         string                 `json:"@message,omitempty"`
+}
+
+func logstashEncode(m map[string]interface{}) ([]byte, error) {
+	var l = logstashLog{
+		Timestamp:       pop(m, "ts"),
+		LogstashVersion: 1,
+		Message:         pop(m, "msg"),
+		Fields:          m,
+	}
+
+	return json.Marshal(l)
+}
+
+// ecsEncode maps the well-known fields to their ECS equivalent. The other fields are
+// kept under the "flaki" namespace, as ECS recommends for custom fields.
+func ecsEncode(m map[string]interface{}) ([]byte, error) {
+	var l = map[string]interface{}{
+		"@timestamp": pop(m, "ts"),
+		"ecs":        map[string]interface{}{"version": ecsVersion},
+	}
+
+	if msg := pop(m, "msg"); msg != "" {
+		l["message"] = msg
+	}
+
+	var logField = map[string]interface{}{}
+	if level := pop(m, "level"); level != "" {
+		logField["level"] = level
+	}
+	if caller := pop(m, "caller"); caller != "" {
+		var file = map[string]interface{}{"name": caller}
+		if i := strings.LastIndex(caller, ":"); i > 0 {
+			if line, err := strconv.Atoi(caller[i+1:]); err == nil {
+				file = map[string]interface{}{"name": caller[:i], "line": line}
+			}
+		}
+		logField["origin"] = map[string]interface{}{"file": file}
+	}
+	if len(logField) > 0 {
+		l["log"] = logField
+	}
+
+	var service = map[string]interface{}{}
+	for key, ecsKey := range map[string]string{"component_name": "name", "component_id": "id", "component_version": "version", "environment": "environment"} {
+		if v := pop(m, key); v != "" {
+			service[ecsKey] = v
+		}
+	}
+	if len(service) > 0 {
+		l["service"] = service
+	}
+
+	if id := pop(m, "correlation_id"); id != "" {
+		l["transaction"] = map[string]interface{}{"id": id}
+	}
+	if id := pop(m, "trace_id"); id != "" {
+		l["trace"] = map[string]interface{}{"id": id}
+	}
+	if id := pop(m, "span_id"); id != "" {
+		l["span"] = map[string]interface{}{"id": id}
+	}
+	if e := pop(m, "error"); e != "" {
+		l["error"] = map[string]interface{}{"message": e}
+	}
+
+	if len(m) > 0 {
+		l["flaki"] = m
+	}
+
+	return json.Marshal(l)
+}
+
+// flatEncode encodes the logs as a single level JSON object, renaming "ts" to "@timestamp"
+// and "msg" to "message".
+func flatEncode(m map[string]interface{}) ([]byte, error) {
+	var l = map[string]interface{}{}
+	flatten(l, "", m)
+
+	for old, new := range map[string]string{"ts": "@timestamp", "msg": "message"} {
+		if v, ok := l[old]; ok {
+			delete(l, old)
+			l[new] = v
+		}
+	}
+
+	return json.Marshal(l)
+}
+
+func flatten(dst map[string]interface{}, prefix string, m map[string]interface{}) {
+	for k, v := range m {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+
+		if nested, ok := v.(map[string]interface{}); ok {
+			flatten(dst, k, nested)
+		} else {
+			dst[k] = v
+		}
+	}
+}
+
+// pop removes the value of 'key' from the map and returns it as a string.
+func pop(m map[string]interface{}, key string) string {
+	var v, ok = m[key]
+	if !ok {
+		return ""
+	}
+	delete(m, key)
+
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,106 @@
+package flakid
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogstashEncode(t *testing.T) {
+	var logs = map[string]interface{}{
+		"msg":               "logstash log",
+		"caller":            "flakid.go:120",
+		"component_name":    "flaki-service",
+		"component_version": "1.0",
+		"environment":       "DEV",
+		"git_commit":        "5fb7de0d7ae3f3d5f5d6a322b2344bdab645fd33",
+		"ts":                "2018-02-13T06:27:07.123915229Z",
+		"took":              json.Number("12"),
+	}
+
+	var logstashLog, err = logstashEncode(logs)
+	assert.Nil(t, err)
+
+	var m = map[string]interface{}{}
+	json.Unmarshal(logstashLog, &m)
+
+	assert.Equal(t, "2018-02-13T06:27:07.123915229Z", m["@timestamp"])
+	assert.Equal(t, float64(1), m["@version"])
+	assert.Equal(t, "logstash log", m["@message"])
+
+	var fields = m["@fields"].(map[string]interface{})
+	assert.Equal(t, "flakid.go:120", fields["caller"])
+	assert.Equal(t, "flaki-service", fields["component_name"])
+	assert.Equal(t, "1.0", fields["component_version"])
+	assert.Equal(t, "DEV", fields["environment"])
+	assert.Equal(t, "5fb7de0d7ae3f3d5f5d6a322b2344bdab645fd33", fields["git_commit"])
+	assert.Equal(t, float64(12), fields["took"])
+	var _, ok = fields["ts"]
+	assert.False(t, ok)
+	_, ok = fields["msg"]
+	assert.False(t, ok)
+}
+
+func TestECSEncode(t *testing.T) {
+	var logs, err = decodeLogs([]byte(`{"msg":"ecs log","caller":"flakid.go:120","level":"info","component_name":"flaki-service","component_id":"123","correlation_id":"456","ts":"2018-02-13T06:27:07.123915229Z","took":12,"job":{"name":"clean"}}`))
+	assert.Nil(t, err)
+
+	var ecsLog []byte
+	ecsLog, err = ecsEncode(logs)
+	assert.Nil(t, err)
+
+	var m = map[string]interface{}{}
+	json.Unmarshal(ecsLog, &m)
+
+	assert.Equal(t, "2018-02-13T06:27:07.123915229Z", m["@timestamp"])
+	assert.Equal(t, "ecs log", m["message"])
+	assert.Equal(t, ecsVersion, m["ecs"].(map[string]interface{})["version"])
+
+	var log = m["log"].(map[string]interface{})
+	assert.Equal(t, "info", log["level"])
+	var file = log["origin"].(map[string]interface{})["file"].(map[string]interface{})
+	assert.Equal(t, "flakid.go", file["name"])
+	assert.Equal(t, float64(120), file["line"])
+
+	var service = m["service"].(map[string]interface{})
+	assert.Equal(t, "flaki-service", service["name"])
+	assert.Equal(t, "123", service["id"])
+	assert.Equal(t, "456", m["transaction"].(map[string]interface{})["id"])
+
+	// The other fields are under the flaki namespace.
+	var flaki = m["flaki"].(map[string]interface{})
+	assert.Equal(t, float64(12), flaki["took"])
+	assert.Equal(t, "clean", flaki["job"].(map[string]interface{})["name"])
+}
+
+func TestFlatEncode(t *testing.T) {
+	var logs, err = decodeLogs([]byte(`{"msg":"flat log","ts":"2018-02-13T06:27:07.123915229Z","ok":false,"job":{"name":"clean","step":{"id":1}}}`))
+	assert.Nil(t, err)
+
+	var flatLog []byte
+	flatLog, err = flatEncode(logs)
+	assert.Nil(t, err)
+
+	var m = map[string]interface{}{}
+	json.Unmarshal(flatLog, &m)
+
+	assert.Equal(t, map[string]interface{}{
+		"@timestamp":  "2018-02-13T06:27:07.123915229Z",
+		"message":     "flat log",
+		"ok":          false,
+		"job.name":    "clean",
+		"job.step.id": float64(1),
+	}, m)
+}
+
+func TestGetSchema(t *testing.T) {
+	for _, name := range []string{"logstash-v1", "ecs", "flat"} {
+		var s, err = GetSchema(name)
+		assert.Nil(t, err)
+		assert.Equal(t, Schema(name), s)
+	}
+
+	var _, err = GetSchema("unknown")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,54 @@
+package flakid
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FanOutWriter duplicates the logs to several sinks. Unlike io.MultiWriter, a failing sink
+// does not prevent the logs from reaching the other ones.
+type FanOutWriter struct {
+	writers []io.Writer
+}
+
+// FanOutError is returned by FanOutWriter when some of the sinks failed. It contains the
+// error of each failing sink.
+type FanOutError struct {
+	Errors []error
+}
+
+func (e *FanOutError) Error() string {
+	var msgs = make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("could not write to %d log sink(s): %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// NewFanOutWriter returns a writer that duplicates the logs to each of the writers.
+func NewFanOutWriter(writers ...io.Writer) *FanOutWriter {
+	return &FanOutWriter{
+		writers: writers,
+	}
+}
+
+// Write writes the logs to all the sinks, even if some of them fail. The errors are
+// combined in a FanOutError.
+func (w *FanOutWriter) Write(data []byte) (int, error) {
+	var errs []error
+	for _, writer := range w.writers {
+		var n, err = writer.Write(data)
+		if err == nil && n != len(data) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) != 0 {
+		return len(data), &FanOutError{Errors: errs}
+	}
+	return len(data), nil
+}
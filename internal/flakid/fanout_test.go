@@ -0,0 +1,50 @@
+package flakid
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingWriter struct {
+	err error
+}
+
+func (w *failingWriter) Write(data []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestFanOutWriter(t *testing.T) {
+	var b1, b2 = &bytes.Buffer{}, &bytes.Buffer{}
+	var w = NewFanOutWriter(b1, b2)
+
+	var n, err = w.Write([]byte("log\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, "log\n", b1.String())
+	assert.Equal(t, "log\n", b2.String())
+}
+
+func TestFanOutWriterFail(t *testing.T) {
+	var b1, b2 = &bytes.Buffer{}, &bytes.Buffer{}
+	var w = NewFanOutWriter(&failingWriter{err: fmt.Errorf("fail 1")}, b1, &failingWriter{err: fmt.Errorf("fail 2")}, b2)
+
+	// The sinks after the failing ones still receive the logs.
+	var n, err = w.Write([]byte("log\n"))
+	assert.Equal(t, 4, n)
+	assert.Equal(t, "log\n", b1.String())
+	assert.Equal(t, "log\n", b2.String())
+
+	assert.IsType(t, &FanOutError{}, err)
+	assert.Len(t, err.(*FanOutError).Errors, 2)
+	assert.Contains(t, err.Error(), "fail 1")
+	assert.Contains(t, err.Error(), "fail 2")
+
+	// Short writes are errors.
+	w = NewFanOutWriter(&failingWriter{err: nil}, b1)
+	_, err = w.Write([]byte("log\n"))
+	assert.Equal(t, io.ErrShortWrite, err.(*FanOutError).Errors[0])
+}
@@ -0,0 +1,177 @@
+package flakid
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	gelfVersion = "1.1"
+	// gelfChunkSize is the maximum size of an UDP datagram, chunk header included.
+	gelfChunkSize   = 8192
+	gelfChunkHeader = 12
+	gelfMaxChunks   = 128
+)
+
+// gelfChunkMagic are the bytes that start each chunk of a chunked GELF message.
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+// gelfLevels maps the go-kit levels to the syslog severities used by GELF.
+var gelfLevels = map[string]int{
+	"debug": 7,
+	"info":  6,
+	"warn":  4,
+	"error": 3,
+}
+
+// GELFWriter converts the logs to the Graylog Extended Log Format and sends them,
+// gzipped, over UDP. The messages bigger than a datagram are chunked.
+type GELFWriter struct {
+	conn net.Conn
+	host string
+}
+
+// NewGELFWriter returns a writer that sends the logs to the GELF UDP input at 'addr'.
+// 'host' is the name of the host sending the logs.
+func NewGELFWriter(addr, host string) (*GELFWriter, error) {
+	var conn, err = net.Dial("udp", addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not connect to GELF input at '%s'", addr)
+	}
+
+	return &GELFWriter{
+		conn: conn,
+		host: host,
+	}, nil
+}
+
+// Write converts the logs to GELF and sends them.
+func (w *GELFWriter) Write(data []byte) (int, error) {
+	var logs, err = decodeLogs(data)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not decode JSON logs")
+	}
+
+	var msg []byte
+	msg, err = gelfEncode(logs, w.host)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not encode logs to GELF")
+	}
+
+	// Compress.
+	var buf bytes.Buffer
+	{
+		var gz = gzip.NewWriter(&buf)
+		gz.Write(msg)
+		err = gz.Close()
+		if err != nil {
+			return 0, errors.Wrap(err, "could not compress GELF message")
+		}
+	}
+
+	var chunks [][]byte
+	chunks, err = gelfChunks(buf.Bytes())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, c := range chunks {
+		_, err = w.conn.Write(c)
+		if err != nil {
+			return 0, errors.Wrap(err, "could not send GELF message")
+		}
+	}
+	return len(data), nil
+}
+
+// Close closes the UDP connection.
+func (w *GELFWriter) Close() error {
+	return w.conn.Close()
+}
+
+// gelfEncode converts the logs to a GELF message. The fields other than the message, the
+// level and the timestamp become additional fields, prefixed by an underscore.
+func gelfEncode(m map[string]interface{}, host string) ([]byte, error) {
+	var msg = pop(m, "msg")
+	if msg == "" {
+		// The short message is mandatory.
+		msg = "-"
+	}
+
+	var l = map[string]interface{}{
+		"version":       gelfVersion,
+		"host":          host,
+		"short_message": msg,
+	}
+
+	if ts, err := time.Parse(time.RFC3339Nano, pop(m, "ts")); err == nil {
+		l["timestamp"] = float64(ts.UnixNano()) / float64(time.Second)
+	}
+
+	if level, ok := gelfLevels[pop(m, "level")]; ok {
+		l["level"] = level
+	} else {
+		l["level"] = gelfLevels["info"]
+	}
+
+	// The additional fields are strings or numbers.
+	var fields = map[string]interface{}{}
+	flatten(fields, "", m)
+	for k, v := range fields {
+		if k == "id" {
+			// The field _id is reserved.
+			k = "log_id"
+		}
+
+		switch v.(type) {
+		case string, json.Number:
+			l["_"+k] = v
+		default:
+			l["_"+k] = fmt.Sprint(v)
+		}
+	}
+
+	return json.Marshal(l)
+}
+
+// gelfChunks splits the message in chunks that fit in an UDP datagram.
+func gelfChunks(msg []byte) ([][]byte, error) {
+	if len(msg) <= gelfChunkSize {
+		return [][]byte{msg}, nil
+	}
+
+	var dataSize = gelfChunkSize - gelfChunkHeader
+	var count = (len(msg) + dataSize - 1) / dataSize
+	if count > gelfMaxChunks {
+		return nil, fmt.Errorf("GELF message too big: %d bytes", len(msg))
+	}
+
+	var id = make([]byte, 8)
+	var _, err = rand.Read(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not generate GELF message ID")
+	}
+
+	var chunks = [][]byte{}
+	for i := 0; i < count; i++ {
+		var end = (i + 1) * dataSize
+		if end > len(msg) {
+			end = len(msg)
+		}
+
+		var c = make([]byte, 0, gelfChunkHeader+end-i*dataSize)
+		c = append(c, gelfChunkMagic...)
+		c = append(c, id...)
+		c = append(c, byte(i), byte(count))
+		c = append(c, msg[i*dataSize:end]...)
+		chunks = append(chunks, c)
+	}
+	return chunks, nil
+}
@@ -0,0 +1,89 @@
+package flakid
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGELFWriter(t *testing.T) {
+	var conn, err = net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	var w *GELFWriter
+	w, err = NewGELFWriter(conn.LocalAddr().String(), "flaki-host")
+	assert.Nil(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte(`{"msg":"gelf log","level":"error","ts":"2018-02-13T06:27:07.5Z","took":12,"id":"123","job":{"name":"clean"}}`))
+	assert.Nil(t, err)
+
+	var buf = make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var n int
+	n, _, err = conn.ReadFrom(buf)
+	assert.Nil(t, err)
+
+	var gz *gzip.Reader
+	gz, err = gzip.NewReader(bytes.NewReader(buf[:n]))
+	assert.Nil(t, err)
+	var data, _ = ioutil.ReadAll(gz)
+
+	var m = map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(data, &m))
+	assert.Equal(t, "1.1", m["version"])
+	assert.Equal(t, "flaki-host", m["host"])
+	assert.Equal(t, "gelf log", m["short_message"])
+	assert.Equal(t, float64(3), m["level"])
+	assert.Equal(t, 1518503227.5, m["timestamp"])
+	assert.Equal(t, float64(12), m["_took"])
+	assert.Equal(t, "123", m["_log_id"])
+	assert.Equal(t, "clean", m["_job.name"])
+}
+
+func TestGELFEncodeDefaults(t *testing.T) {
+	var msg, err = gelfEncode(map[string]interface{}{"ok": true}, "flaki-host")
+	assert.Nil(t, err)
+
+	var m = map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(msg, &m))
+	assert.Equal(t, "-", m["short_message"])
+	assert.Equal(t, float64(6), m["level"])
+	assert.Equal(t, "true", m["_ok"])
+}
+
+func TestGELFChunks(t *testing.T) {
+	// Small messages are not chunked.
+	var chunks, err = gelfChunks([]byte("message"))
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("message")}, chunks)
+
+	// Big messages are.
+	var msg = []byte(strings.Repeat("a", 2*gelfChunkSize))
+	chunks, err = gelfChunks(msg)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(chunks))
+
+	var reassembled []byte
+	for i, c := range chunks {
+		assert.True(t, len(c) <= gelfChunkSize)
+		assert.Equal(t, gelfChunkMagic, c[:2])
+		assert.Equal(t, chunks[0][2:10], c[2:10])
+		assert.Equal(t, byte(i), c[10])
+		assert.Equal(t, byte(3), c[11])
+		reassembled = append(reassembled, c[gelfChunkHeader:]...)
+	}
+	assert.Equal(t, msg, reassembled)
+
+	// Too big messages are rejected.
+	_, err = gelfChunks(make([]byte, gelfMaxChunks*gelfChunkSize))
+	assert.NotNil(t, err)
+}
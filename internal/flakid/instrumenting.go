@@ -3,6 +3,7 @@ package flakid
 //go:generate mockgen -source=instrumenting.go -destination=./mock/instrumenting.go -package=mock -mock_names=Influx=Influx,GoKitMetrics=GoKitMetrics github.com/cloudtrust/flaki-service/cmd Influx,GoKitMetrics
 
 import (
+	"sync"
 	"time"
 
 	"github.com/go-kit/kit/metrics"
@@ -64,6 +65,81 @@ func (m *InfluxMetrics) Ping(timeout time.Duration) (time.Duration, string, erro
 	return m.influx.Ping(timeout)
 }
 
+// RotatingInflux is an Influx client whose password can be rotated without restart. The client
+// is recreated with the new password at the first call after the rotation.
+type RotatingInflux struct {
+	config   influx.HTTPConfig
+	password func() string
+
+	mu      sync.Mutex
+	client  influx.Client
+	current string
+}
+
+// NewRotatingInflux returns an Influx client that takes its password from 'password'.
+func NewRotatingInflux(config influx.HTTPConfig, password func() string) (*RotatingInflux, error) {
+	var c = &RotatingInflux{
+		config:   config,
+		password: password,
+	}
+
+	var _, err = c.get()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Ping implements Influx.
+func (c *RotatingInflux) Ping(timeout time.Duration) (time.Duration, string, error) {
+	var client, err = c.get()
+	if err != nil {
+		return 0, "", err
+	}
+	return client.Ping(timeout)
+}
+
+// Write implements Influx.
+func (c *RotatingInflux) Write(bp influx.BatchPoints) error {
+	var client, err = c.get()
+	if err != nil {
+		return err
+	}
+	return client.Write(bp)
+}
+
+// Close implements Influx.
+func (c *RotatingInflux) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client.Close()
+}
+
+// get returns the client with the current password.
+func (c *RotatingInflux) get() (influx.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var password = c.password()
+	if c.client != nil && password == c.current {
+		return c.client, nil
+	}
+
+	var config = c.config
+	config.Password = password
+	var client, err = influx.NewHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Closing the previous client only closes its idle connections, the pending calls complete.
+	if c.client != nil {
+		c.client.Close()
+	}
+	c.client, c.current = client, password
+	return client, nil
+}
+
 // NoopMetrics is an Influx metrics that does nothing.
 type NoopMetrics struct{}
 
@@ -1,11 +1,14 @@
 package flakid
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/cloudtrust/flaki-service/internal/flakid/mock"
 	"github.com/golang/mock/gomock"
+	influx "github.com/influxdata/influxdb/client/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -53,3 +56,32 @@ func TestNoopMetrics(t *testing.T) {
 	assert.Equal(t, "", s)
 	assert.Nil(t, err)
 }
+
+func TestRotatingInflux(t *testing.T) {
+	var passwords []string
+	var s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var _, password, _ = r.BasicAuth()
+		passwords = append(passwords, password)
+		w.Header().Set("X-Influxdb-Version", "1.5")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer s.Close()
+
+	var password = "first"
+	var c, err = NewRotatingInflux(influx.HTTPConfig{Addr: s.URL, Username: "flaki"}, func() string { return password })
+	assert.Nil(t, err)
+	defer c.Close()
+
+	var _, version, _ = c.Ping(time.Second)
+	assert.Equal(t, "1.5", version)
+
+	// The client is recreated with the rotated password.
+	password = "second"
+	c.Ping(time.Second)
+	c.Ping(time.Second)
+	assert.Equal(t, []string{"first", "second", "second"}, passwords)
+
+	// Invalid address.
+	_, err = NewRotatingInflux(influx.HTTPConfig{Addr: "://influx"}, func() string { return "" })
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,206 @@
+package flakid
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gorilla/mux"
+)
+
+// The log levels, by increasing severity.
+const (
+	levelDebug int32 = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var levelNames = map[string]int32{
+	"debug": levelDebug,
+	"info":  levelInfo,
+	"warn":  levelWarn,
+	"error": levelError,
+}
+
+var levelValues = map[level.Value]int32{
+	level.DebugValue(): levelDebug,
+	level.InfoValue():  levelInfo,
+	level.WarnValue():  levelWarn,
+	level.ErrorValue(): levelError,
+}
+
+func parseLevel(name string) (int32, error) {
+	var l, ok = levelNames[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown log level '%s'", name)
+	}
+	return l, nil
+}
+
+func levelName(l int32) string {
+	for name, v := range levelNames {
+		if v == l {
+			return name
+		}
+	}
+	return ""
+}
+
+// LevelFilter is a logger that drops the logs below its level. Unlike the go-kit level filter,
+// its level can be changed at runtime. The logs without level are never dropped.
+type LevelFilter struct {
+	next  log.Logger
+	level int32
+}
+
+// NewLevelFilter returns a logger that drops the logs below 'lvl', i.e. "debug", "info", "warn" or "error".
+func NewLevelFilter(next log.Logger, lvl string) (*LevelFilter, error) {
+	var l, err = parseLevel(lvl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LevelFilter{
+		next:  next,
+		level: l,
+	}, nil
+}
+
+// Log implements log.Logger.
+func (f *LevelFilter) Log(keyvals ...interface{}) error {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if keyvals[i] != level.Key() {
+			continue
+		}
+
+		if v, ok := keyvals[i+1].(level.Value); ok {
+			if l, ok := levelValues[v]; ok && l < atomic.LoadInt32(&f.level) {
+				return nil
+			}
+		}
+		break
+	}
+	return f.next.Log(keyvals...)
+}
+
+// SetLevel changes the level of the filter.
+func (f *LevelFilter) SetLevel(lvl string) error {
+	var l, err = parseLevel(lvl)
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt32(&f.level, l)
+	return nil
+}
+
+// Level returns the level of the filter.
+func (f *LevelFilter) Level() string {
+	return levelName(atomic.LoadInt32(&f.level))
+}
+
+// LogLevels keeps the level filters of the subsystems (e.g. flaki, health, jobs), so their
+// level can be changed at runtime.
+type LogLevels struct {
+	mu           sync.Mutex
+	defaultLevel string
+	filters      map[string]*LevelFilter
+}
+
+// NewLogLevels returns the log levels. The subsystems start with the level 'defaultLevel'.
+func NewLogLevels(defaultLevel string) (*LogLevels, error) {
+	var _, err = parseLevel(defaultLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LogLevels{
+		defaultLevel: defaultLevel,
+		filters:      make(map[string]*LevelFilter),
+	}, nil
+}
+
+// Logger returns the logger of the subsystem.
+func (l *LogLevels) Logger(subsystem string, next log.Logger) log.Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// The default level was checked by NewLogLevels or SetDefault.
+	var f, _ = NewLevelFilter(next, l.defaultLevel)
+	l.filters[subsystem] = f
+
+	return f
+}
+
+// Set changes the level of the subsystem.
+func (l *LogLevels) Set(subsystem, lvl string) error {
+	l.mu.Lock()
+	var f, ok = l.filters[subsystem]
+	l.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("unknown subsystem '%s'", subsystem)
+	}
+	return f.SetLevel(lvl)
+}
+
+// SetDefault changes the default level and the level of all the subsystems, e.g. when the
+// configuration is reloaded.
+func (l *LogLevels) SetDefault(lvl string) error {
+	var _, err = parseLevel(lvl)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.defaultLevel = lvl
+	for _, f := range l.filters {
+		f.SetLevel(lvl)
+	}
+	return nil
+}
+
+// Levels returns the level of each subsystem.
+func (l *LogLevels) Levels() map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var levels = map[string]string{}
+	for subsystem, f := range l.filters {
+		levels[subsystem] = f.Level()
+	}
+	return levels
+}
+
+// MakeLogLevelHandler makes the HTTP handler of the log levels admin route. GET returns
+// the level of each subsystem, PUT with the body {"level": "<level>"} changes the level
+// of the subsystem given by the route variable "subsystem".
+func MakeLogLevelHandler(levels *LogLevels) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		if r.Method == http.MethodPut {
+			var body struct {
+				Level string `json:"level"`
+			}
+
+			var err = json.NewDecoder(r.Body).Decode(&body)
+			if err == nil {
+				err = levels.Set(mux.Vars(r)["subsystem"], body.Level)
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+		}
+
+		json.NewEncoder(w).Encode(levels.Levels())
+	})
+}
@@ -0,0 +1,128 @@
+package flakid
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordLogger returns a logger that stores the logs in 'logs'.
+func recordLogger(logs *[][]interface{}) log.Logger {
+	return log.LoggerFunc(func(keyvals ...interface{}) error {
+		*logs = append(*logs, keyvals)
+		return nil
+	})
+}
+
+func TestLevelFilter(t *testing.T) {
+	var logs [][]interface{}
+
+	var f, err = NewLevelFilter(recordLogger(&logs), "info")
+	assert.Nil(t, err)
+	assert.Equal(t, "info", f.Level())
+
+	// Debug logs are dropped.
+	level.Debug(f).Log("msg", "debug")
+	assert.Len(t, logs, 0)
+
+	level.Info(f).Log("msg", "info")
+	level.Error(f).Log("msg", "error")
+	// Logs without level are never dropped.
+	f.Log("msg", "no level")
+	assert.Equal(t, [][]interface{}{
+		{level.Key(), level.InfoValue(), "msg", "info"},
+		{level.Key(), level.ErrorValue(), "msg", "error"},
+		{"msg", "no level"},
+	}, logs)
+
+	// Change level.
+	logs = nil
+	assert.Nil(t, f.SetLevel("debug"))
+	assert.Equal(t, "debug", f.Level())
+	level.Debug(f).Log("msg", "debug")
+	assert.Equal(t, [][]interface{}{{level.Key(), level.DebugValue(), "msg", "debug"}}, logs)
+
+	// Unknown level.
+	assert.NotNil(t, f.SetLevel("verbose"))
+	assert.Equal(t, "debug", f.Level())
+	_, err = NewLevelFilter(recordLogger(&logs), "verbose")
+	assert.NotNil(t, err)
+}
+
+func TestLogLevels(t *testing.T) {
+	var logs [][]interface{}
+
+	var _, err = NewLogLevels("verbose")
+	assert.NotNil(t, err)
+
+	var levels *LogLevels
+	levels, err = NewLogLevels("warn")
+	assert.Nil(t, err)
+
+	var flakiLogger = levels.Logger("flaki", recordLogger(&logs))
+	levels.Logger("health", recordLogger(&logs))
+	assert.Equal(t, map[string]string{"flaki": "warn", "health": "warn"}, levels.Levels())
+
+	level.Info(flakiLogger).Log("msg", "info")
+	assert.Len(t, logs, 0)
+
+	assert.Nil(t, levels.Set("flaki", "info"))
+	assert.Equal(t, map[string]string{"flaki": "info", "health": "warn"}, levels.Levels())
+	level.Info(flakiLogger).Log("msg", "info")
+	assert.Len(t, logs, 1)
+
+	assert.NotNil(t, levels.Set("unknown", "info"))
+	assert.NotNil(t, levels.Set("flaki", "verbose"))
+
+	// The default level applies to all the subsystems, and to the new ones.
+	assert.Nil(t, levels.SetDefault("debug"))
+	levels.Logger("jobs", recordLogger(&logs))
+	assert.Equal(t, map[string]string{"flaki": "debug", "health": "debug", "jobs": "debug"}, levels.Levels())
+	assert.NotNil(t, levels.SetDefault("verbose"))
+	assert.Equal(t, map[string]string{"flaki": "debug", "health": "debug", "jobs": "debug"}, levels.Levels())
+}
+
+func TestLogLevelHandler(t *testing.T) {
+	var logs [][]interface{}
+
+	var levels, _ = NewLogLevels("info")
+	levels.Logger("flaki", recordLogger(&logs))
+	levels.Logger("jobs", recordLogger(&logs))
+
+	var route = mux.NewRouter()
+	route.Handle("/admin/log-level", MakeLogLevelHandler(levels)).Methods("GET")
+	route.Handle("/admin/log-level/{subsystem}", MakeLogLevelHandler(levels)).Methods("PUT")
+
+	var serve = func(method, url, body string) (int, map[string]string) {
+		var w = httptest.NewRecorder()
+		route.ServeHTTP(w, httptest.NewRequest(method, url, strings.NewReader(body)))
+
+		var m = map[string]string{}
+		json.Unmarshal(w.Body.Bytes(), &m)
+		return w.Code, m
+	}
+
+	var code, m = serve("GET", "http://cloudtrust.io/admin/log-level", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]string{"flaki": "info", "jobs": "info"}, m)
+
+	code, m = serve("PUT", "http://cloudtrust.io/admin/log-level/jobs", `{"level":"debug"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]string{"flaki": "info", "jobs": "debug"}, m)
+
+	// Unknown subsystem, unknown level and invalid body.
+	code, _ = serve("PUT", "http://cloudtrust.io/admin/log-level/unknown", `{"level":"debug"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = serve("PUT", "http://cloudtrust.io/admin/log-level/jobs", `{"level":"verbose"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = serve("PUT", "http://cloudtrust.io/admin/log-level/jobs", `level`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, map[string]string{"flaki": "info", "jobs": "debug"}, levels.Levels())
+}
@@ -3,81 +3,327 @@ package flakid
 //go:generate mockgen -source=logging.go -destination=./mock/logging.go -package=mock -mock_names=Redis=Redis github.com/cloudtrust/flaki-service/cmd Redis
 
 import (
-	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
 
+	"github.com/go-kit/kit/metrics"
 	"github.com/pkg/errors"
 )
 
-// logstashLog is the logstash log format.
-type logstashLog struct {
-	Timestamp       string            `json:"@timestamp"`
-	LogstashVersion int               `json:"@version"`
-	Fields          map[string]string `json:"@fields"`
-	Message         string            `json:"@message,omitempty"`
+// ErrWriterClosed is returned when logs are written after the writer was closed.
+var ErrWriterClosed = fmt.Errorf("redis log writer closed")
+
+// DropPolicy tells which logs are dropped when the queue is full.
+type DropPolicy string
+
+const (
+	// DropOldest drops the oldest logs of the queue.
+	DropOldest DropPolicy = "drop-oldest"
+	// DropNewest drops the logs that do not fit in the queue.
+	DropNewest DropPolicy = "drop-newest"
+	// Spill drops the oldest logs of the queue, but writes them to the spill writer.
+	Spill DropPolicy = "spill"
+)
+
+// GetDropPolicy returns the drop policy named 'name'.
+func GetDropPolicy(name string) (DropPolicy, error) {
+	switch p := DropPolicy(name); p {
+	case DropOldest, DropNewest, Spill:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown log drop policy '%s'", name)
+	}
 }
 
-// RedisWriter encodes logs in logstash format and writes them to Redis.
+// GetSpillWriter returns the writer of the spill target named 'name', stdout or stderr.
+func GetSpillWriter(name string) (io.Writer, error) {
+	switch name {
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	default:
+		return nil, fmt.Errorf("unknown log spill target '%s'", name)
+	}
+}
+
+// RedisWriter encodes logs in logstash format (or another schema) and writes them to Redis. The logs are
+// queued in memory and sent by batches in the background, so a Redis outage never blocks
+// the callers. When the queue is full, logs are dropped according to the drop policy.
 type RedisWriter struct {
-	redis Redis
-	key   string
+	redis  Redis
+	key    string
+	encode encoder
+
+	queueSize     int
+	batchSize     int
+	flushInterval time.Duration
+	policy        DropPolicy
+	spill         io.Writer
+	depth         metrics.Gauge
+	dropped       metrics.Counter
+
+	mu     sync.Mutex
+	queue  [][]byte
+	closed bool
+
+	full      chan struct{}
+	quit      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 // Redis is the redis client interface.
 type Redis interface {
-	Send(commandName string, args ...interface{}) error
+	Do(commandName string, args ...interface{}) (reply interface{}, err error)
+}
+
+// RedisWriterOption is an option of the Redis writer.
+type RedisWriterOption func(*RedisWriter)
+
+// WithSchema sets the format of the logs sent to Redis. The default is LogstashV1.
+func WithSchema(s Schema) RedisWriterOption {
+	return func(w *RedisWriter) {
+		w.encode = s.encoder()
+	}
+}
+
+// QueueSize sets the maximum number of logs waiting to be sent to Redis.
+func QueueSize(n int) RedisWriterOption {
+	return func(w *RedisWriter) {
+		w.queueSize = n
+	}
+}
+
+// BatchSize sets the maximum number of logs sent in a single RPUSH.
+func BatchSize(n int) RedisWriterOption {
+	return func(w *RedisWriter) {
+		w.batchSize = n
+	}
+}
+
+// FlushInterval sets the interval between two sendings of the queued logs. The logs are also
+// sent as soon as a batch is full.
+func FlushInterval(d time.Duration) RedisWriterOption {
+	return func(w *RedisWriter) {
+		w.flushInterval = d
+	}
+}
+
+// WithDropPolicy sets the drop policy applied when the queue is full.
+func WithDropPolicy(p DropPolicy) RedisWriterOption {
+	return func(w *RedisWriter) {
+		w.policy = p
+	}
+}
+
+// SpillWriter sets the writer where the dropped logs go with the Spill policy. By default they
+// are discarded; the spill writer must not be one of the other log sinks, or the dropped logs
+// are written twice.
+func SpillWriter(spill io.Writer) RedisWriterOption {
+	return func(w *RedisWriter) {
+		w.spill = spill
+	}
+}
+
+// QueueDepthGauge sets the gauge that reports the number of queued logs.
+func QueueDepthGauge(g metrics.Gauge) RedisWriterOption {
+	return func(w *RedisWriter) {
+		w.depth = g
+	}
+}
+
+// DroppedCounter sets the counter of the logs that could not be sent to Redis, spilled ones included.
+func DroppedCounter(c metrics.Counter) RedisWriterOption {
+	return func(w *RedisWriter) {
+		w.dropped = c
+	}
 }
 
-// NewLogstashRedisWriter returns a writer that writes logs into a redis DB.
-func NewLogstashRedisWriter(redis Redis, key string) *RedisWriter {
-	return &RedisWriter{
-		redis: redis,
-		key:   key,
+// NewLogstashRedisWriter returns a writer that writes logs into a redis DB. It starts the
+// goroutine that sends the logs, which is stopped by Close.
+func NewLogstashRedisWriter(redis Redis, key string, options ...RedisWriterOption) *RedisWriter {
+	var w = &RedisWriter{
+		redis:         redis,
+		key:           key,
+		encode:        LogstashV1.encoder(),
+		queueSize:     10000,
+		batchSize:     100,
+		flushInterval: time.Second,
+		policy:        DropOldest,
+		spill:         ioutil.Discard,
+		depth:         &NoopGauge{},
+		dropped:       &NoopCounter{},
+		full:          make(chan struct{}, 1),
+		quit:          make(chan struct{}),
+		done:          make(chan struct{}),
 	}
+
+	for _, opt := range options {
+		opt(w)
+	}
+
+	go w.loop()
+	return w
 }
 
-// Write encodes logs in logstash format and writes them to Redis.
+// Write encodes logs and queues them. It never blocks on Redis.
 func (w *RedisWriter) Write(data []byte) (int, error) {
 	// The current logs are JSON formatted by the go-kit JSONLogger.
-	var logs = make(map[string]string)
+	var logs map[string]interface{}
 	{
-		var err = json.Unmarshal(data, &logs)
+		var err error
+		logs, err = decodeLogs(data)
 		if err != nil {
 			return 0, errors.Wrap(err, "could not decode JSON logs")
 		}
 	}
 
-	// Encode to logstash format.
-	var logstashLog []byte
+	// Encode to the configured schema.
+	var encodedLog []byte
 	{
 		var err error
-		logstashLog, err = logstashEncode(logs)
+		encodedLog, err = w.encode(logs)
 		if err != nil {
-			return 0, errors.Wrap(err, "could not encode logs to logstash format")
+			return 0, errors.Wrap(err, "could not encode logs")
 		}
 	}
 
-	// Write to Redis.
-	var err = w.redis.Send("RPUSH", w.key, logstashLog)
-	if err != nil {
-		return 0, errors.Wrap(err, "could not write logs to Redis")
+	// Queue.
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return 0, ErrWriterClosed
+	}
+	w.queue = append(w.queue, encodedLog)
+	var dropped = w.trim()
+	var batchReady = len(w.queue) >= w.batchSize
+	w.mu.Unlock()
+
+	w.drop(dropped)
+
+	// Wake up the sending goroutine, without blocking.
+	if batchReady {
+		select {
+		case w.full <- struct{}{}:
+		default:
+		}
 	}
 	return len(data), nil
 }
 
-func logstashEncode(m map[string]string) ([]byte, error) {
-	var timestamp = m["ts"]
-	delete(m, "ts")
-	var msg = m["msg"]
-	delete(m, "msg")
+// Close sends the queued logs and stops the sending goroutine. The logs that cannot be sent
+// are dropped.
+func (w *RedisWriter) Close() error {
+	w.closeOnce.Do(func() {
+		w.mu.Lock()
+		w.closed = true
+		w.mu.Unlock()
+
+		close(w.quit)
+	})
+	<-w.done
+	return nil
+}
+
+func (w *RedisWriter) loop() {
+	defer close(w.done)
+
+	var t = time.NewTicker(w.flushInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+		case <-w.full:
+		case <-w.quit:
+			w.flush()
+
+			// The logs that could not be sent are lost.
+			w.mu.Lock()
+			var remaining = w.queue
+			w.queue = nil
+			w.depth.Set(0)
+			w.mu.Unlock()
+
+			w.drop(remaining)
+			return
+		}
+		w.flush()
+	}
+}
+
+// flush sends the queued logs to Redis, by batches. If Redis fails, the batch is put back in
+// the queue and the sending is retried at the next flush.
+func (w *RedisWriter) flush() {
+	for {
+		w.mu.Lock()
+		var n = len(w.queue)
+		if n > w.batchSize {
+			n = w.batchSize
+		}
+		var batch = w.queue[:n]
+		w.queue = w.queue[n:]
+		w.depth.Set(float64(len(w.queue)))
+		w.mu.Unlock()
+
+		if len(batch) == 0 {
+			return
+		}
+
+		var args = make([]interface{}, 0, len(batch)+1)
+		args = append(args, w.key)
+		for _, l := range batch {
+			args = append(args, l)
+		}
+
+		var _, err = w.redis.Do("RPUSH", args...)
+		if err != nil {
+			w.mu.Lock()
+			w.queue = append(append([][]byte{}, batch...), w.queue...)
+			var dropped = w.trim()
+			w.mu.Unlock()
+
+			w.drop(dropped)
+			return
+		}
+	}
+}
+
+// trim removes the logs that exceed the size of the queue, according to the drop policy.
+// It must be called with the mutex held.
+func (w *RedisWriter) trim() [][]byte {
+	var dropped [][]byte
+	if excess := len(w.queue) - w.queueSize; excess > 0 {
+		switch w.policy {
+		case DropNewest:
+			dropped = w.queue[w.queueSize:]
+			w.queue = w.queue[:w.queueSize]
+		default:
+			dropped = w.queue[:excess]
+			w.queue = w.queue[excess:]
+		}
+	}
+	w.depth.Set(float64(len(w.queue)))
+	return dropped
+}
 
-	var l = logstashLog{
-		Timestamp:       timestamp,
-		LogstashVersion: 1,
-		Fields:          m,
-		Message:         msg,
+// drop counts the dropped logs and, with the Spill policy, writes them to the spill writer.
+func (w *RedisWriter) drop(logs [][]byte) {
+	if len(logs) == 0 {
+		return
 	}
 
-	return json.Marshal(l)
+	w.dropped.Add(float64(len(logs)))
+	if w.policy == Spill {
+		for _, l := range logs {
+			w.spill.Write(append(l, '\n'))
+		}
+	}
 }
 
 // NoopRedis is a Redis client that does nothing.
@@ -1,57 +1,178 @@
 package flakid
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/cloudtrust/flaki-service/internal/flakid/mock"
+	"github.com/go-kit/kit/metrics/generic"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
 
+const jsonLog = "{\"msg\":\"logstash log\",\"caller\":\"flakid.go:120\",\"component_name\":\"flaki-service\",\"component_version\":\"1.0\",\"environment\":\"DEV\",\"git_commit\":\"5fb7de0d7ae3f3d5f5d6a322b2344bdab645fd33\",\"ts\":\"2018-02-13T06:27:07.123915229Z\"}"
+
 func TestLogstashRedisWriter(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 	var mockRedis = mock.NewRedis(mockCtrl)
 
-	var w = NewLogstashRedisWriter(mockRedis, "redisKey")
+	var w = NewLogstashRedisWriter(mockRedis, "redisKey", BatchSize(2), FlushInterval(time.Hour))
+
+	// The logs are sent by batches of 2, the last one is sent on Close.
+	gomock.InOrder(
+		mockRedis.EXPECT().Do("RPUSH", "redisKey", gomock.Any(), gomock.Any()).Return(nil, nil).Times(1),
+		mockRedis.EXPECT().Do("RPUSH", "redisKey", gomock.Any()).Return(nil, nil).Times(1),
+	)
+	for i := 0; i < 3; i++ {
+		var n, err = w.Write([]byte(jsonLog))
+		assert.Nil(t, err)
+		assert.Equal(t, len(jsonLog), n)
+	}
+	assert.Nil(t, w.Close())
+
+	// Writing after Close fails.
+	var _, err = w.Write([]byte(jsonLog))
+	assert.Equal(t, ErrWriterClosed, err)
+
+	// Invalid JSON.
+	w = NewLogstashRedisWriter(mockRedis, "redisKey")
+	_, err = w.Write([]byte("log"))
+	assert.NotNil(t, err)
+	w.Close()
+}
+
+func TestLogstashRedisWriterRedisFailure(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockRedis = mock.NewRedis(mockCtrl)
+
+	var dropped = generic.NewCounter("dropped")
+	var depth = generic.NewGauge("depth")
+	var w = NewLogstashRedisWriter(mockRedis, "redisKey", FlushInterval(time.Hour), DroppedCounter(dropped), QueueDepthGauge(depth))
 
-	var jsonLog = "{\"msg\":\"logstash log\",\"caller\":\"flakid.go:120\",\"component_name\":\"flaki-service\",\"component_version\":\"1.0\",\"environment\":\"DEV\",\"git_commit\":\"5fb7de0d7ae3f3d5f5d6a322b2344bdab645fd33\",\"ts\":\"2018-02-13T06:27:07.123915229Z\"}"
-	mockRedis.EXPECT().Send("RPUSH", "redisKey", gomock.Any()).Return(nil).Times(1)
+	// When Redis fails, the batch is put back in the queue.
+	mockRedis.EXPECT().Do("RPUSH", "redisKey", gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("fail")).Times(1)
 	w.Write([]byte(jsonLog))
+	w.Write([]byte(jsonLog))
+	w.flush()
+	assert.Equal(t, 2, len(w.queue))
+	assert.Equal(t, float64(2), depth.Value())
+	assert.Zero(t, dropped.Value())
+
+	// On Close, the logs that cannot be sent are dropped.
+	mockRedis.EXPECT().Do("RPUSH", "redisKey", gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("fail")).Times(1)
+	w.Close()
+	assert.Equal(t, float64(2), dropped.Value())
+	assert.Zero(t, depth.Value())
 }
 
-func TestLogstashEncode(t *testing.T) {
-	var logs = map[string]string{
-		"msg":               "logstash log",
-		"caller":            "flakid.go:120",
-		"component_name":    "flaki-service",
-		"component_version": "1.0",
-		"environment":       "DEV",
-		"git_commit":        "5fb7de0d7ae3f3d5f5d6a322b2344bdab645fd33",
-		"ts":                "2018-02-13T06:27:07.123915229Z",
+func TestDropPolicies(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockRedis = mock.NewRedis(mockCtrl)
+
+	var logs = []string{}
+	for i := 0; i < 3; i++ {
+		logs = append(logs, fmt.Sprintf(`{"msg":"log %d"}`, i))
+	}
+
+	var queued = func(w *RedisWriter) []string {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		var msgs = []string{}
+		for _, l := range w.queue {
+			var m = map[string]interface{}{}
+			json.Unmarshal(l, &m)
+			msgs = append(msgs, m["@message"].(string))
+		}
+		return msgs
+	}
+
+	mockRedis.EXPECT().Do("RPUSH", "redisKey", gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+	// Drop oldest.
+	{
+		var dropped = generic.NewCounter("dropped")
+		var w = NewLogstashRedisWriter(mockRedis, "redisKey", QueueSize(2), FlushInterval(time.Hour), WithDropPolicy(DropOldest), DroppedCounter(dropped))
+		for _, l := range logs {
+			w.Write([]byte(l))
+		}
+		assert.Equal(t, []string{"log 1", "log 2"}, queued(w))
+		assert.Equal(t, float64(1), dropped.Value())
+		w.Close()
 	}
 
-	var logstashLog, err = logstashEncode(logs)
+	// Drop newest.
+	{
+		var dropped = generic.NewCounter("dropped")
+		var w = NewLogstashRedisWriter(mockRedis, "redisKey", QueueSize(2), FlushInterval(time.Hour), WithDropPolicy(DropNewest), DroppedCounter(dropped))
+		for _, l := range logs {
+			w.Write([]byte(l))
+		}
+		assert.Equal(t, []string{"log 0", "log 1"}, queued(w))
+		assert.Equal(t, float64(1), dropped.Value())
+		w.Close()
+	}
+
+	// Spill.
+	{
+		var spill = &bytes.Buffer{}
+		var w = NewLogstashRedisWriter(mockRedis, "redisKey", QueueSize(2), FlushInterval(time.Hour), WithDropPolicy(Spill), SpillWriter(spill))
+		for _, l := range logs {
+			w.Write([]byte(l))
+		}
+		assert.Equal(t, []string{"log 1", "log 2"}, queued(w))
+		assert.Contains(t, spill.String(), "log 0")
+		w.Close()
+	}
+}
+
+func TestLogstashRedisWriterNonStringValues(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockRedis = mock.NewRedis(mockCtrl)
+
+	var w = NewLogstashRedisWriter(mockRedis, "redisKey", FlushInterval(time.Hour), WithSchema(Flat))
+
+	var sent []byte
+	mockRedis.EXPECT().Do("RPUSH", "redisKey", gomock.Any()).Do(func(_ string, args ...interface{}) {
+		sent = args[1].([]byte)
+	}).Return(nil, nil).Times(1)
+
+	var _, err = w.Write([]byte(`{"msg":"log","took":1.5,"ok":true,"request":{"id":12345678901234567890}}`))
+	assert.Nil(t, err)
+	w.Close()
+
+	assert.Equal(t, `{"message":"log","ok":true,"request.id":12345678901234567890,"took":1.5}`, string(sent))
+}
+
+func TestGetDropPolicy(t *testing.T) {
+	for _, name := range []string{"drop-oldest", "drop-newest", "spill"} {
+		var p, err = GetDropPolicy(name)
+		assert.Nil(t, err)
+		assert.Equal(t, DropPolicy(name), p)
+	}
+
+	var _, err = GetDropPolicy("unknown")
+	assert.NotNil(t, err)
+}
+
+func TestGetSpillWriter(t *testing.T) {
+	var w, err = GetSpillWriter("stdout")
+	assert.Nil(t, err)
+	assert.Equal(t, os.Stdout, w)
+
+	w, err = GetSpillWriter("stderr")
 	assert.Nil(t, err)
+	assert.Equal(t, os.Stderr, w)
 
-	var m = map[string]interface{}{}
-	json.Unmarshal(logstashLog, &m)
-
-	assert.Equal(t, "2018-02-13T06:27:07.123915229Z", m["@timestamp"])
-	assert.Equal(t, float64(1), m["@version"])
-	assert.Equal(t, "logstash log", m["@message"])
-
-	var fields = m["@fields"].(map[string]interface{})
-	assert.Equal(t, "flakid.go:120", fields["caller"])
-	assert.Equal(t, "flaki-service", fields["component_name"])
-	assert.Equal(t, "1.0", fields["component_version"])
-	assert.Equal(t, "DEV", fields["environment"])
-	assert.Equal(t, "5fb7de0d7ae3f3d5f5d6a322b2344bdab645fd33", fields["git_commit"])
-	var _, ok = fields["ts"]
-	assert.False(t, ok)
-	_, ok = fields["msg"]
-	assert.False(t, ok)
+	_, err = GetSpillWriter("stdin")
+	assert.NotNil(t, err)
 }
 
 func TestNoopRedis(t *testing.T) {
@@ -0,0 +1,98 @@
+package flakid
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// sinkRetryDelay is the time a network log sink waits before reconnecting after a failure.
+// In the meantime the logs are dropped, so a sink that is down does not slow down the service.
+const sinkRetryDelay = 5 * time.Second
+
+// LogstashWriter encodes logs and sends them to a Logstash TCP or UDP input, one JSON
+// document per line (json_lines codec).
+type LogstashWriter struct {
+	network string
+	addr    string
+	encode  encoder
+	timeout time.Duration
+
+	mu      sync.Mutex
+	conn    net.Conn
+	retryAt time.Time
+}
+
+// NewLogstashWriter returns a writer that sends the logs to the Logstash input at 'addr'.
+// The network is "tcp" or "udp", and 'timeout' bounds the connection and each write.
+func NewLogstashWriter(network, addr string, schema Schema, timeout time.Duration) (*LogstashWriter, error) {
+	switch network {
+	case "tcp", "udp":
+	default:
+		return nil, fmt.Errorf("invalid logstash network '%s'", network)
+	}
+
+	return &LogstashWriter{
+		network: network,
+		addr:    addr,
+		encode:  schema.encoder(),
+		timeout: timeout,
+	}, nil
+}
+
+// Write encodes logs and sends them to Logstash.
+func (w *LogstashWriter) Write(data []byte) (int, error) {
+	var logs, err = decodeLogs(data)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not decode JSON logs")
+	}
+
+	var encodedLog []byte
+	encodedLog, err = w.encode(logs)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not encode logs")
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		if time.Now().Before(w.retryAt) {
+			return 0, fmt.Errorf("logstash at '%s' unavailable", w.addr)
+		}
+
+		w.conn, err = net.DialTimeout(w.network, w.addr, w.timeout)
+		if err != nil {
+			w.retryAt = time.Now().Add(sinkRetryDelay)
+			return 0, errors.Wrapf(err, "could not connect to logstash at '%s'", w.addr)
+		}
+	}
+
+	if w.timeout > 0 {
+		w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	}
+	_, err = w.conn.Write(append(encodedLog, '\n'))
+	if err != nil {
+		w.conn.Close()
+		w.conn = nil
+		w.retryAt = time.Now().Add(sinkRetryDelay)
+		return 0, errors.Wrapf(err, "could not write logs to logstash at '%s'", w.addr)
+	}
+	return len(data), nil
+}
+
+// Close closes the connection to Logstash.
+func (w *LogstashWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	var err = w.conn.Close()
+	w.conn = nil
+	return err
+}
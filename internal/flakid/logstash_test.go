@@ -0,0 +1,103 @@
+package flakid
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogstashWriterTCP(t *testing.T) {
+	var l, err = net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+
+	var lines = make(chan string, 2)
+	go func() {
+		var conn, err = l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var s = bufio.NewScanner(conn)
+		for s.Scan() {
+			lines <- s.Text()
+		}
+	}()
+
+	var w *LogstashWriter
+	w, err = NewLogstashWriter("tcp", l.Addr().String(), LogstashV1, time.Second)
+	assert.Nil(t, err)
+	defer w.Close()
+
+	var n int
+	n, err = w.Write([]byte(jsonLog))
+	assert.Nil(t, err)
+	assert.Equal(t, len(jsonLog), n)
+	_, err = w.Write([]byte(`{"msg":"second log","took":3}`))
+	assert.Nil(t, err)
+
+	// One JSON document per line.
+	var m = map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(<-lines), &m))
+	assert.Equal(t, "logstash log", m["@message"])
+	assert.Nil(t, json.Unmarshal([]byte(<-lines), &m))
+	assert.Equal(t, "second log", m["@message"])
+	assert.Equal(t, float64(3), m["@fields"].(map[string]interface{})["took"])
+}
+
+func TestLogstashWriterUDP(t *testing.T) {
+	var conn, err = net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	var w *LogstashWriter
+	w, err = NewLogstashWriter("udp", conn.LocalAddr().String(), Flat, time.Second)
+	assert.Nil(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte(jsonLog))
+	assert.Nil(t, err)
+
+	var buf = make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var n int
+	n, _, err = conn.ReadFrom(buf)
+	assert.Nil(t, err)
+
+	var m = map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(buf[:n], &m))
+	assert.Equal(t, "logstash log", m["message"])
+}
+
+func TestLogstashWriterUnavailable(t *testing.T) {
+	var l, err = net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	var addr = l.Addr().String()
+	l.Close()
+
+	var w *LogstashWriter
+	w, err = NewLogstashWriter("tcp", addr, LogstashV1, time.Second)
+	assert.Nil(t, err)
+
+	// The connection fails, then the logs are dropped without trying to reconnect.
+	_, err = w.Write([]byte(jsonLog))
+	assert.NotNil(t, err)
+	assert.False(t, w.retryAt.IsZero())
+	_, err = w.Write([]byte(jsonLog))
+	assert.NotNil(t, err)
+
+	// Invalid JSON.
+	_, err = w.Write([]byte("log"))
+	assert.NotNil(t, err)
+
+	assert.Nil(t, w.Close())
+}
+
+func TestNewLogstashWriterInvalidNetwork(t *testing.T) {
+	var _, err = NewLogstashWriter("unix", "/tmp/logstash.sock", LogstashV1, time.Second)
+	assert.NotNil(t, err)
+}
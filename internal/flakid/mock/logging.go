@@ -32,19 +32,20 @@ func (m *Redis) EXPECT() *RedisMockRecorder {
 	return m.recorder
 }
 
-// Send mocks base method
-func (m *Redis) Send(commandName string, args ...interface{}) error {
+// Do mocks base method
+func (m *Redis) Do(commandName string, args ...interface{}) (interface{}, error) {
 	varargs := []interface{}{commandName}
 	for _, a := range args {
 		varargs = append(varargs, a)
 	}
-	ret := m.ctrl.Call(m, "Send", varargs...)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Do", varargs...)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Send indicates an expected call of Send
-func (mr *RedisMockRecorder) Send(commandName interface{}, args ...interface{}) *gomock.Call {
+// Do indicates an expected call of Do
+func (mr *RedisMockRecorder) Do(commandName interface{}, args ...interface{}) *gomock.Call {
 	varargs := append([]interface{}{commandName}, args...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*Redis)(nil).Send), varargs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*Redis)(nil).Do), varargs...)
 }
@@ -0,0 +1,95 @@
+package flakid
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// OTelConfig is the configuration of the OpenTelemetry tracer provider.
+type OTelConfig struct {
+	// Protocol is the protocol of the OTLP exporter: "grpc" or "http".
+	Protocol string
+	// HostPort is the address of the OTel collector.
+	HostPort string
+	// Insecure disables TLS between the exporter and the collector.
+	Insecure bool
+	// Sampler is the name of the sampler, as in OTEL_TRACES_SAMPLER: "always_on", "always_off",
+	// "traceidratio", "parentbased_always_on", "parentbased_always_off" or "parentbased_traceidratio".
+	Sampler string
+	// SamplerRatio is the ratio of the sampled traces, for the traceidratio samplers.
+	SamplerRatio float64
+	// Attributes are the resource attributes, e.g. "service.name".
+	Attributes map[string]string
+}
+
+// NewOTelTracerProvider returns an OpenTelemetry tracer provider that exports the spans to an OTel
+// collector with OTLP, by batches. It must be shut down to send the remaining spans.
+func NewOTelTracerProvider(ctx context.Context, c OTelConfig) (*sdktrace.TracerProvider, error) {
+	var sampler, err = otelSampler(c.Sampler, c.SamplerRatio)
+	if err != nil {
+		return nil, err
+	}
+
+	var client otlptrace.Client
+	switch c.Protocol {
+	case "grpc":
+		var opts = []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(c.HostPort)}
+		if c.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		client = otlptracegrpc.NewClient(opts...)
+	case "http":
+		var opts = []otlptracehttp.Option{otlptracehttp.WithEndpoint(c.HostPort)}
+		if c.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		client = otlptracehttp.NewClient(opts...)
+	default:
+		return nil, fmt.Errorf("invalid OTLP protocol '%s'", c.Protocol)
+	}
+
+	var exporter *otlptrace.Exporter
+	exporter, err = otlptrace.New(ctx, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create OTLP exporter")
+	}
+
+	var attributes = []attribute.KeyValue{}
+	for k, v := range c.Attributes {
+		attributes = append(attributes, attribute.String(k, v))
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(resource.NewSchemaless(attributes...)),
+	), nil
+}
+
+// otelSampler returns the sampler named 'name'.
+func otelSampler(name string, ratio float64) (sdktrace.Sampler, error) {
+	switch name {
+	case "always_on":
+		return sdktrace.AlwaysSample(), nil
+	case "always_off":
+		return sdktrace.NeverSample(), nil
+	case "traceidratio":
+		return sdktrace.TraceIDRatioBased(ratio), nil
+	case "parentbased_always_on":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	case "parentbased_traceidratio":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)), nil
+	default:
+		return nil, fmt.Errorf("unknown OpenTelemetry sampler '%s'", name)
+	}
+}
@@ -0,0 +1,81 @@
+package flakid
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestOTelSampler(t *testing.T) {
+	var samplers = map[string]string{
+		"always_on":                "AlwaysOnSampler",
+		"always_off":               "AlwaysOffSampler",
+		"traceidratio":             "TraceIDRatioBased{0.5}",
+		"parentbased_always_on":    "ParentBased{root:AlwaysOnSampler,",
+		"parentbased_always_off":   "ParentBased{root:AlwaysOffSampler,",
+		"parentbased_traceidratio": "ParentBased{root:TraceIDRatioBased{0.5},",
+	}
+
+	for name, desc := range samplers {
+		var s, err = otelSampler(name, 0.5)
+		assert.Nil(t, err)
+		assert.Contains(t, s.Description(), desc)
+	}
+
+	var _, err = otelSampler("unknown", 0.5)
+	assert.NotNil(t, err)
+}
+
+func TestNewOTelTracerProvider(t *testing.T) {
+	var config = OTelConfig{
+		HostPort:     "127.0.0.1:4317",
+		Insecure:     true,
+		Sampler:      "always_on",
+		SamplerRatio: 1,
+		Attributes:   map[string]string{"service.name": "flaki-service", "service.version": "1.0"},
+	}
+
+	for _, protocol := range []string{"grpc", "http"} {
+		config.Protocol = protocol
+
+		var p, err = NewOTelTracerProvider(context.Background(), config)
+		assert.Nil(t, err)
+
+		var _, span = p.Tracer("flaki-service").Start(context.Background(), "test")
+		assert.True(t, span.SpanContext().IsSampled())
+		span.End()
+
+		// The collector is down, the spans are dropped.
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+		p.Shutdown(ctx)
+		cancel()
+	}
+
+	// Resource attributes.
+	{
+		config.Protocol = "grpc"
+		var p, _ = NewOTelTracerProvider(context.Background(), config)
+		var _, span = p.Tracer("flaki-service").Start(context.Background(), "test")
+		var attributes = span.(sdktrace.ReadOnlySpan).Resource().Set()
+		var v, ok = attributes.Value(attribute.Key("service.name"))
+		assert.True(t, ok)
+		assert.Equal(t, "flaki-service", v.AsString())
+		span.End()
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+		p.Shutdown(ctx)
+		cancel()
+	}
+
+	// Invalid protocol and sampler.
+	config.Protocol = "udp"
+	var _, err = NewOTelTracerProvider(context.Background(), config)
+	assert.NotNil(t, err)
+	config.Protocol = "grpc"
+	config.Sampler = "unknown"
+	_, err = NewOTelTracerProvider(context.Background(), config)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,322 @@
+package flakid
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	jaeger "github.com/uber/jaeger-client-go"
+	jaeger_config "github.com/uber/jaeger-client-go/config"
+	"github.com/uber/jaeger-client-go/zipkin"
+	otel_b3 "go.opentelemetry.io/contrib/propagators/b3"
+	otel_jaeger "go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// The trace context propagators are:
+//  - "jaeger": the Jaeger native headers uber-trace-id and uberctx-*.
+//  - "w3c": the W3C Trace Context headers traceparent and tracestate.
+//  - "b3": the B3 multi headers X-B3-TraceId, X-B3-SpanId, X-B3-ParentSpanId and X-B3-Sampled.
+//  - "b3-single": the B3 single header b3.
+// The span contexts are injected with all the propagators, and extracted with the first
+// propagator, in the configured order, that finds one.
+
+// tracestateBaggageKey is the baggage item where the Jaeger span contexts keep the W3C tracestate.
+// Only the W3C propagator injects it; the other propagators would send it as baggage.
+const tracestateBaggageKey = "w3c-tracestate"
+
+// JaegerPropagationOptions returns the Jaeger tracer options that propagate the span contexts in
+// the HTTP headers and the text maps (gRPC metadata) with the propagators 'names'.
+func JaegerPropagationOptions(names []string) ([]jaeger_config.Option, error) {
+	var httpPropagator, textMapPropagator = &jaegerPropagator{}, &jaegerPropagator{}
+
+	for _, name := range names {
+		switch name {
+		case "jaeger":
+			httpPropagator.add(jaeger.NewHTTPHeaderPropagator((&jaeger.HeadersConfig{}).ApplyDefaults(), *jaeger.NewNullMetrics()))
+			textMapPropagator.add(jaeger.NewTextMapPropagator((&jaeger.HeadersConfig{}).ApplyDefaults(), *jaeger.NewNullMetrics()))
+		case "w3c":
+			httpPropagator.add(&w3cPropagator{})
+			textMapPropagator.add(&w3cPropagator{})
+		case "b3":
+			httpPropagator.add(zipkin.NewZipkinB3HTTPHeaderPropagator())
+			textMapPropagator.add(zipkin.NewZipkinB3HTTPHeaderPropagator())
+		case "b3-single":
+			httpPropagator.add(&b3SinglePropagator{})
+			textMapPropagator.add(&b3SinglePropagator{})
+		default:
+			return nil, fmt.Errorf("unknown trace propagator '%s'", name)
+		}
+	}
+
+	return []jaeger_config.Option{
+		jaeger_config.Injector(opentracing.HTTPHeaders, httpPropagator),
+		jaeger_config.Extractor(opentracing.HTTPHeaders, httpPropagator),
+		jaeger_config.Injector(opentracing.TextMap, textMapPropagator),
+		jaeger_config.Extractor(opentracing.TextMap, textMapPropagator),
+	}, nil
+}
+
+// NewOTelPropagator returns the OpenTelemetry propagator made of the propagators 'names'. The "w3c"
+// propagator also propagates the W3C baggage.
+func NewOTelPropagator(names []string) (propagation.TextMapPropagator, error) {
+	var propagators = []propagation.TextMapPropagator{}
+
+	for _, name := range names {
+		switch name {
+		case "jaeger":
+			propagators = append(propagators, otel_jaeger.Jaeger{})
+		case "w3c":
+			propagators = append(propagators, propagation.TraceContext{}, propagation.Baggage{})
+		case "b3":
+			propagators = append(propagators, otel_b3.New(otel_b3.WithInjectEncoding(otel_b3.B3MultipleHeader)))
+		case "b3-single":
+			propagators = append(propagators, otel_b3.New(otel_b3.WithInjectEncoding(otel_b3.B3SingleHeader)))
+		default:
+			return nil, fmt.Errorf("unknown trace propagator '%s'", name)
+		}
+	}
+
+	// The composite propagator extracts with each propagator in turn, and the last span context
+	// found wins. The order is reversed so that the first configured propagator has priority.
+	for i, j := 0, len(propagators)-1; i < j; i, j = i+1, j-1 {
+		propagators[i], propagators[j] = propagators[j], propagators[i]
+	}
+
+	return propagation.NewCompositeTextMapPropagator(propagators...), nil
+}
+
+type jaegerInjectorExtractor interface {
+	jaeger.Injector
+	jaeger.Extractor
+}
+
+// jaegerPropagator injects the span contexts with all its propagators, and extracts them with
+// the first propagator that finds one.
+type jaegerPropagator struct {
+	propagators []jaegerInjectorExtractor
+}
+
+func (p *jaegerPropagator) add(propagator jaegerInjectorExtractor) {
+	p.propagators = append(p.propagators, propagator)
+}
+
+// Inject implements jaeger.Injector. The W3C tracestate is not injected as baggage by the
+// Jaeger and B3 propagators.
+func (p *jaegerPropagator) Inject(sc jaeger.SpanContext, carrier interface{}) error {
+	var scWithoutTracestate = withoutTracestate(sc)
+
+	for _, propagator := range p.propagators {
+		var err error
+		if _, ok := propagator.(*w3cPropagator); ok {
+			err = propagator.Inject(sc, carrier)
+		} else {
+			err = propagator.Inject(scWithoutTracestate, carrier)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Extract implements jaeger.Extractor. If no span context is found, the error of the first
+// propagator that found a corrupted one is returned.
+func (p *jaegerPropagator) Extract(carrier interface{}) (jaeger.SpanContext, error) {
+	var extractErr = opentracing.ErrSpanContextNotFound
+
+	for _, propagator := range p.propagators {
+		var sc, err = propagator.Extract(carrier)
+		switch {
+		case err == nil:
+			return sc, nil
+		case err != opentracing.ErrSpanContextNotFound && extractErr == opentracing.ErrSpanContextNotFound:
+			extractErr = err
+		}
+	}
+	return jaeger.SpanContext{}, extractErr
+}
+
+// withoutTracestate returns the span context 'sc' without the W3C tracestate baggage item.
+func withoutTracestate(sc jaeger.SpanContext) jaeger.SpanContext {
+	var found bool
+	sc.ForeachBaggageItem(func(k, v string) bool {
+		found = k == tracestateBaggageKey
+		return !found
+	})
+	if !found {
+		return sc
+	}
+
+	// The span context is rebuilt from its string form, which keeps the flags.
+	var stripped, err = jaeger.ContextFromString(sc.String())
+	if err != nil {
+		return sc
+	}
+	sc.ForeachBaggageItem(func(k, v string) bool {
+		if k != tracestateBaggageKey {
+			stripped = stripped.WithBaggageItem(k, v)
+		}
+		return true
+	})
+	return stripped
+}
+
+// w3cPropagator propagates the span contexts with the W3C Trace Context headers. The tracestate
+// is kept in a baggage item, so it is passed on to the downstream services.
+type w3cPropagator struct{}
+
+// Inject implements jaeger.Injector.
+func (p *w3cPropagator) Inject(sc jaeger.SpanContext, carrier interface{}) error {
+	var w, ok = carrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+
+	var flags = "00"
+	if sc.IsSampled() {
+		flags = "01"
+	}
+	w.Set("traceparent", fmt.Sprintf("00-%016x%016x-%016x-%s", sc.TraceID().High, sc.TraceID().Low, uint64(sc.SpanID()), flags))
+
+	sc.ForeachBaggageItem(func(k, v string) bool {
+		if k == tracestateBaggageKey {
+			w.Set("tracestate", v)
+		}
+		return true
+	})
+	return nil
+}
+
+// Extract implements jaeger.Extractor.
+func (p *w3cPropagator) Extract(carrier interface{}) (jaeger.SpanContext, error) {
+	var r, ok = carrier.(opentracing.TextMapReader)
+	if !ok {
+		return jaeger.SpanContext{}, opentracing.ErrInvalidCarrier
+	}
+
+	var traceparent, tracestate string
+	r.ForeachKey(func(k, v string) error {
+		switch strings.ToLower(k) {
+		case "traceparent":
+			traceparent = v
+		case "tracestate":
+			tracestate = v
+		}
+		return nil
+	})
+	if traceparent == "" {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextNotFound
+	}
+
+	// The traceparent is version-traceid-parentid-flags. The future versions may add fields.
+	var parts = strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+
+	var traceID, err = jaeger.TraceIDFromString(parts[1])
+	if err != nil || !traceID.IsValid() {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+	var spanID jaeger.SpanID
+	spanID, err = jaeger.SpanIDFromString(parts[2])
+	if err != nil || spanID == 0 {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+	var flags uint64
+	flags, err = strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+
+	var baggage map[string]string
+	if tracestate != "" {
+		baggage = map[string]string{tracestateBaggageKey: tracestate}
+	}
+	return jaeger.NewSpanContext(traceID, spanID, 0, flags&1 == 1, baggage), nil
+}
+
+// b3SinglePropagator propagates the span contexts with the B3 single header, whose value is
+// traceid-spanid-sampled-parentspanid.
+type b3SinglePropagator struct{}
+
+// Inject implements jaeger.Injector.
+func (p *b3SinglePropagator) Inject(sc jaeger.SpanContext, carrier interface{}) error {
+	var w, ok = carrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+
+	var traceID = fmt.Sprintf("%016x", sc.TraceID().Low)
+	if sc.TraceID().High != 0 {
+		traceID = fmt.Sprintf("%016x%016x", sc.TraceID().High, sc.TraceID().Low)
+	}
+	var sampled = "0"
+	if sc.IsSampled() {
+		sampled = "1"
+	}
+
+	var b3 = fmt.Sprintf("%s-%016x-%s", traceID, uint64(sc.SpanID()), sampled)
+	if sc.ParentID() != 0 {
+		b3 = fmt.Sprintf("%s-%016x", b3, uint64(sc.ParentID()))
+	}
+	w.Set("b3", b3)
+	return nil
+}
+
+// Extract implements jaeger.Extractor.
+func (p *b3SinglePropagator) Extract(carrier interface{}) (jaeger.SpanContext, error) {
+	var r, ok = carrier.(opentracing.TextMapReader)
+	if !ok {
+		return jaeger.SpanContext{}, opentracing.ErrInvalidCarrier
+	}
+
+	var b3 string
+	r.ForeachKey(func(k, v string) error {
+		if strings.ToLower(k) == "b3" {
+			b3 = v
+		}
+		return nil
+	})
+	// A lone sampling state carries no span context.
+	var parts = strings.Split(strings.TrimSpace(b3), "-")
+	if len(parts) < 2 {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextNotFound
+	}
+	if len(parts) > 4 {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+
+	var traceID, err = jaeger.TraceIDFromString(parts[0])
+	if err != nil || !traceID.IsValid() || (len(parts[0]) != 16 && len(parts[0]) != 32) {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+	var spanID jaeger.SpanID
+	spanID, err = jaeger.SpanIDFromString(parts[1])
+	if err != nil || spanID == 0 {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+
+	var sampled bool
+	if len(parts) > 2 {
+		switch parts[2] {
+		case "1", "d":
+			sampled = true
+		case "0":
+		default:
+			return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+		}
+	}
+
+	var parentID jaeger.SpanID
+	if len(parts) > 3 {
+		parentID, err = jaeger.SpanIDFromString(parts[3])
+		if err != nil {
+			return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+		}
+	}
+	return jaeger.NewSpanContext(traceID, spanID, parentID, sampled, nil), nil
+}
@@ -0,0 +1,169 @@
+package flakid
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	jaeger "github.com/uber/jaeger-client-go"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestW3CPropagator(t *testing.T) {
+	var p = &w3cPropagator{}
+	var sc = jaeger.NewSpanContext(jaeger.TraceID{High: 1, Low: 2}, jaeger.SpanID(3), 0, true, map[string]string{tracestateBaggageKey: "vendor=value"})
+
+	// Inject.
+	var carrier = opentracing.HTTPHeadersCarrier(http.Header{})
+	assert.Nil(t, p.Inject(sc, carrier))
+	assert.Equal(t, "00-00000000000000010000000000000002-0000000000000003-01", http.Header(carrier).Get("traceparent"))
+	assert.Equal(t, "vendor=value", http.Header(carrier).Get("tracestate"))
+
+	// Extract.
+	var extracted, err = p.Extract(carrier)
+	assert.Nil(t, err)
+	assert.Equal(t, sc.TraceID(), extracted.TraceID())
+	assert.Equal(t, sc.SpanID(), extracted.SpanID())
+	assert.True(t, extracted.IsSampled())
+	var tracestate string
+	extracted.ForeachBaggageItem(func(k, v string) bool {
+		if k == tracestateBaggageKey {
+			tracestate = v
+		}
+		return true
+	})
+	assert.Equal(t, "vendor=value", tracestate)
+
+	// Not sampled, lower case text map.
+	extracted, err = p.Extract(opentracing.TextMapCarrier{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"})
+	assert.Nil(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", extracted.TraceID().String())
+	assert.Equal(t, "f067aa0ba902b7", extracted.SpanID().String())
+	assert.False(t, extracted.IsSampled())
+
+	// Missing and corrupted.
+	_, err = p.Extract(opentracing.TextMapCarrier{})
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+	for _, traceparent := range []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"} {
+		_, err = p.Extract(opentracing.TextMapCarrier{"traceparent": traceparent})
+		assert.Equal(t, opentracing.ErrSpanContextCorrupted, err, traceparent)
+	}
+
+	// Future versions may have more fields.
+	_, err = p.Extract(opentracing.TextMapCarrier{"traceparent": "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"})
+	assert.Nil(t, err)
+}
+
+func TestB3SinglePropagator(t *testing.T) {
+	var p = &b3SinglePropagator{}
+
+	// Inject.
+	var carrier = opentracing.TextMapCarrier{}
+	assert.Nil(t, p.Inject(jaeger.NewSpanContext(jaeger.TraceID{Low: 1}, jaeger.SpanID(2), 0, true, nil), carrier))
+	assert.Equal(t, "0000000000000001-0000000000000002-1", carrier["b3"])
+	assert.Nil(t, p.Inject(jaeger.NewSpanContext(jaeger.TraceID{High: 1, Low: 2}, jaeger.SpanID(3), jaeger.SpanID(4), false, nil), carrier))
+	assert.Equal(t, "00000000000000010000000000000002-0000000000000003-0-0000000000000004", carrier["b3"])
+
+	// Extract.
+	var sc, err = p.Extract(carrier)
+	assert.Nil(t, err)
+	assert.Equal(t, jaeger.TraceID{High: 1, Low: 2}, sc.TraceID())
+	assert.Equal(t, jaeger.SpanID(3), sc.SpanID())
+	assert.Equal(t, jaeger.SpanID(4), sc.ParentID())
+	assert.False(t, sc.IsSampled())
+
+	sc, err = p.Extract(opentracing.HTTPHeadersCarrier(http.Header{"B3": []string{"80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-d"}}))
+	assert.Nil(t, err)
+	assert.Equal(t, "80f198ee56343ba864fe8b2a57d3eff7", sc.TraceID().String())
+	assert.True(t, sc.IsSampled())
+
+	// Missing, sampling state only and corrupted.
+	_, err = p.Extract(opentracing.TextMapCarrier{})
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+	_, err = p.Extract(opentracing.TextMapCarrier{"b3": "0"})
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+	for _, b3 := range []string{"123-e457b5a2e4d86bd1", "80f198ee56343ba8-0000000000000000", "80f198ee56343ba8-e457b5a2e4d86bd1-x", "80f198ee56343ba8-e457b5a2e4d86bd1-1-x", "a-b-c-d-e"} {
+		_, err = p.Extract(opentracing.TextMapCarrier{"b3": b3})
+		assert.Equal(t, opentracing.ErrSpanContextCorrupted, err, b3)
+	}
+}
+
+func TestJaegerPropagationOptions(t *testing.T) {
+	var _, err = JaegerPropagationOptions([]string{"jaeger", "unknown"})
+	assert.NotNil(t, err)
+
+	// One injector and one extractor for the HTTP headers and the text maps.
+	var opts, _ = JaegerPropagationOptions([]string{"w3c", "b3", "jaeger", "b3-single"})
+	assert.Len(t, opts, 4)
+
+	// The context is injected with all the propagators, and extracted with the first that finds one.
+	var p = &jaegerPropagator{}
+	p.add(&w3cPropagator{})
+	p.add(&b3SinglePropagator{})
+
+	var carrier = opentracing.TextMapCarrier{}
+	assert.Nil(t, p.Inject(jaeger.NewSpanContext(jaeger.TraceID{Low: 1}, jaeger.SpanID(2), 0, true, nil), carrier))
+	assert.Contains(t, carrier, "traceparent")
+	assert.Contains(t, carrier, "b3")
+
+	carrier["b3"] = "0000000000000005-0000000000000006-1"
+	var sc jaeger.SpanContext
+	sc, err = p.Extract(carrier)
+	assert.Nil(t, err)
+	assert.Equal(t, jaeger.TraceID{Low: 1}, sc.TraceID())
+
+	// The W3C context is corrupted, the B3 one is used.
+	carrier["traceparent"] = "corrupted"
+	sc, err = p.Extract(carrier)
+	assert.Nil(t, err)
+	assert.Equal(t, jaeger.TraceID{Low: 5}, sc.TraceID())
+
+	// No valid context, the first corruption is reported.
+	carrier["b3"] = "corrupted-context"
+	_, err = p.Extract(carrier)
+	assert.Equal(t, opentracing.ErrSpanContextCorrupted, err)
+	_, err = p.Extract(opentracing.TextMapCarrier{})
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+}
+
+func TestJaegerPropagatorTracestate(t *testing.T) {
+	var p = &jaegerPropagator{}
+	p.add(&w3cPropagator{})
+	p.add(jaeger.NewTextMapPropagator((&jaeger.HeadersConfig{}).ApplyDefaults(), *jaeger.NewNullMetrics()))
+
+	var sc = jaeger.NewSpanContext(jaeger.TraceID{Low: 1}, jaeger.SpanID(2), 0, true, map[string]string{tracestateBaggageKey: "vendor=value", "user": "flaki"})
+	var carrier = opentracing.TextMapCarrier{}
+	assert.Nil(t, p.Inject(sc, carrier))
+
+	// The tracestate is only sent in the W3C header, the other baggage items are kept.
+	assert.Equal(t, "vendor=value", carrier["tracestate"])
+	assert.Equal(t, "flaki", carrier["uberctx-user"])
+	assert.NotContains(t, carrier, "uberctx-"+tracestateBaggageKey)
+	assert.Equal(t, "1:2:0:1", carrier["uber-trace-id"])
+}
+
+func TestNewOTelPropagator(t *testing.T) {
+	var _, err = NewOTelPropagator([]string{"w3c", "unknown"})
+	assert.NotNil(t, err)
+
+	var p propagation.TextMapPropagator
+	p, err = NewOTelPropagator([]string{"w3c", "b3", "b3-single", "jaeger"})
+	assert.Nil(t, err)
+	assert.Subset(t, p.Fields(), []string{"traceparent", "tracestate", "baggage", "x-b3-traceid", "b3", "uber-trace-id"})
+
+	// The first propagator has priority.
+	var carrier = propagation.MapCarrier{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"b3":          "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1",
+	}
+	var sc = trace.SpanContextFromContext(p.Extract(context.Background(), carrier))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID().String())
+
+	p, _ = NewOTelPropagator([]string{"b3-single", "w3c"})
+	sc = trace.SpanContextFromContext(p.Extract(context.Background(), carrier))
+	assert.Equal(t, "80f198ee56343ba864fe8b2a57d3eff7", sc.TraceID().String())
+}
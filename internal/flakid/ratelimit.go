@@ -0,0 +1,47 @@
+package flakid
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter is a rate limiter whose limit can be changed at runtime, e.g. when the configuration
+// is reloaded. It implements the go-kit ratelimit.Allower interface.
+type RateLimiter struct {
+	mu      sync.RWMutex
+	limit   int
+	limiter *rate.Limiter
+}
+
+// NewRateLimiter returns a limiter with a bucket of 'limit' requests, refilled with one request
+// per second.
+func NewRateLimiter(limit int) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		limiter: rate.NewLimiter(rate.Every(time.Second), limit),
+	}
+}
+
+// Allow reports whether a request may happen now.
+func (l *RateLimiter) Allow() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.limiter.Allow()
+}
+
+// SetLimit changes the limit. The bucket is replaced by a full bucket of 'limit' requests.
+func (l *RateLimiter) SetLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.limiter = rate.NewLimiter(rate.Every(time.Second), limit)
+}
+
+// Limit returns the current limit.
+func (l *RateLimiter) Limit() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.limit
+}
@@ -0,0 +1,23 @@
+package flakid
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	var l = NewRateLimiter(2)
+	assert.Equal(t, 2, l.Limit())
+	assert.True(t, l.Allow())
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	// The new limit applies immediately.
+	l.SetLimit(3)
+	assert.Equal(t, 3, l.Limit())
+	assert.True(t, l.Allow())
+	assert.True(t, l.Allow())
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+}
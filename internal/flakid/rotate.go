@@ -0,0 +1,234 @@
+package flakid
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// backupTimeFormat is the format of the timestamp appended to the rotated files names. It sorts
+// in chronological order.
+const backupTimeFormat = "2006-01-02T15-04-05.000000000"
+
+// RotatingFile is a log file that is rotated when it reaches a maximum size or age. The rotated
+// files are named '<path>.<timestamp>', gzipped if compression is enabled, and the oldest ones
+// are removed.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	compress   bool
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// bgMu serialises the compression and cleaning of the rotated files, that are done in
+	// the background.
+	bgMu sync.Mutex
+	wg   sync.WaitGroup
+}
+
+// RotatingFileOption is an option of the rotating file.
+type RotatingFileOption func(*RotatingFile)
+
+// MaxSize sets the size in bytes above which the file is rotated. Zero means no limit.
+func MaxSize(bytes int64) RotatingFileOption {
+	return func(f *RotatingFile) {
+		f.maxSize = bytes
+	}
+}
+
+// MaxAge sets the age above which the file is rotated. Zero means no limit.
+func MaxAge(d time.Duration) RotatingFileOption {
+	return func(f *RotatingFile) {
+		f.maxAge = d
+	}
+}
+
+// MaxBackups sets the number of rotated files that are kept. Zero means they are all kept.
+func MaxBackups(n int) RotatingFileOption {
+	return func(f *RotatingFile) {
+		f.maxBackups = n
+	}
+}
+
+// Compress enables the gzip compression of the rotated files.
+func Compress(compress bool) RotatingFileOption {
+	return func(f *RotatingFile) {
+		f.compress = compress
+	}
+}
+
+// NewRotatingFile opens, or creates, the log file at 'path'.
+func NewRotatingFile(path string, options ...RotatingFileOption) (*RotatingFile, error) {
+	var f = &RotatingFile{
+		path: path,
+	}
+
+	for _, opt := range options {
+		opt(f)
+	}
+
+	var err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not create log directory for '%s'", path)
+	}
+
+	err = f.open()
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes the logs to the file, after rotating it if needed.
+func (f *RotatingFile) Write(data []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, errors.Errorf("log file '%s' closed", f.path)
+	}
+
+	var tooBig = f.maxSize > 0 && f.size > 0 && f.size+int64(len(data)) > f.maxSize
+	var tooOld = f.maxAge > 0 && time.Since(f.openedAt) >= f.maxAge
+	if tooBig || tooOld {
+		var err = f.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var n, err = f.file.Write(data)
+	f.size += int64(n)
+	if err != nil {
+		return n, errors.Wrapf(err, "could not write logs to '%s'", f.path)
+	}
+	return n, nil
+}
+
+// Close closes the file and waits for the end of the background compression.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+
+	f.wg.Wait()
+	return err
+}
+
+func (f *RotatingFile) open() error {
+	var file, err = os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "could not open log file '%s'", f.path)
+	}
+
+	var info os.FileInfo
+	info, err = file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.Wrapf(err, "could not read log file '%s'", f.path)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+	return nil
+}
+
+// rotate renames the current file and opens a new one. It must be called with the mutex held.
+func (f *RotatingFile) rotate() error {
+	var err = f.file.Close()
+	f.file = nil
+	if err != nil {
+		return errors.Wrapf(err, "could not close log file '%s'", f.path)
+	}
+
+	var backup = f.path + "." + time.Now().UTC().Format(backupTimeFormat)
+	err = os.Rename(f.path, backup)
+	if err != nil {
+		return errors.Wrapf(err, "could not rotate log file '%s'", f.path)
+	}
+
+	err = f.open()
+	if err != nil {
+		return err
+	}
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+
+		f.bgMu.Lock()
+		defer f.bgMu.Unlock()
+
+		if f.compress {
+			gzipFile(backup)
+		}
+		f.removeOldBackups()
+	}()
+	return nil
+}
+
+// removeOldBackups keeps only the maxBackups most recent rotated files.
+func (f *RotatingFile) removeOldBackups() {
+	if f.maxBackups <= 0 {
+		return
+	}
+
+	var backups, err = filepath.Glob(f.path + ".*")
+	if err != nil || len(backups) <= f.maxBackups {
+		return
+	}
+
+	sort.Strings(backups)
+	for _, b := range backups[:len(backups)-f.maxBackups] {
+		os.Remove(b)
+	}
+}
+
+// gzipFile compresses the file to '<path>.gz' and removes it. On failure, the file is kept
+// uncompressed.
+func gzipFile(path string) error {
+	var src, err = os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	var dst *os.File
+	dst, err = os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	var gz = gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if err == nil {
+		err = gz.Close()
+	}
+	if err == nil {
+		err = dst.Close()
+	} else {
+		dst.Close()
+	}
+
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
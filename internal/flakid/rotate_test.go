@@ -0,0 +1,77 @@
+package flakid
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingFileSize(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "flakid-logs")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "logs", "flakid.log")
+
+	var f *RotatingFile
+	f, err = NewRotatingFile(path, MaxSize(10), MaxBackups(2))
+	assert.Nil(t, err)
+
+	// Each write exceeds the maximum size, so the file is rotated before each write but the first.
+	for _, l := range []string{"log 0 ----\n", "log 1 ----\n", "log 2 ----\n", "log 3 ----\n"} {
+		var n, err = f.Write([]byte(l))
+		assert.Nil(t, err)
+		assert.Equal(t, len(l), n)
+	}
+	assert.Nil(t, f.Close())
+
+	var data, _ = ioutil.ReadFile(path)
+	assert.Equal(t, "log 3 ----\n", string(data))
+
+	// Only the 2 most recent backups are kept.
+	var backups, _ = filepath.Glob(path + ".*")
+	assert.Equal(t, 2, len(backups))
+	data, _ = ioutil.ReadFile(backups[0])
+	assert.Equal(t, "log 1 ----\n", string(data))
+	data, _ = ioutil.ReadFile(backups[1])
+	assert.Equal(t, "log 2 ----\n", string(data))
+
+	// Writing after Close fails.
+	_, err = f.Write([]byte("log"))
+	assert.NotNil(t, err)
+}
+
+func TestRotatingFileAgeAndCompression(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "flakid-logs")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "flakid.log")
+
+	var f *RotatingFile
+	f, err = NewRotatingFile(path, MaxAge(10*time.Millisecond), Compress(true))
+	assert.Nil(t, err)
+
+	_, err = f.Write([]byte("old log\n"))
+	assert.Nil(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = f.Write([]byte("new log\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+
+	var backups, _ = filepath.Glob(path + ".*")
+	assert.Equal(t, 1, len(backups))
+	assert.True(t, strings.HasSuffix(backups[0], ".gz"))
+
+	var gzFile, _ = os.Open(backups[0])
+	defer gzFile.Close()
+	var gz, _ = gzip.NewReader(gzFile)
+	var data, _ = ioutil.ReadAll(gz)
+	assert.Equal(t, "old log\n", string(data))
+}
@@ -0,0 +1,111 @@
+package flakid
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/metrics"
+)
+
+// SamplingRule tells how the successful requests of an endpoint are sampled.
+type SamplingRule struct {
+	// Rate is N in "log 1 request in N". 0 or 1 means every request is logged.
+	Rate int
+	// SlowThreshold is the duration above which a request is always logged. 0 means no threshold.
+	SlowThreshold time.Duration
+}
+
+// SamplingLogger logs only a sample of the successful requests, so the high-throughput endpoints
+// do not flood the logs. The rules are given per endpoint, i.e. per value of the key "unit".
+// Each middleware (key "mw") of an endpoint is sampled separately: 1 log in N is kept, with
+// the number of logs suppressed since the previous one. The errors, the requests slower than
+// the threshold and the logs of the endpoints without rule are always logged.
+type SamplingLogger struct {
+	next       log.Logger
+	rules      map[string]SamplingRule
+	suppressed metrics.Counter
+
+	mu     sync.Mutex
+	counts map[string]*sampleCount
+}
+
+type sampleCount struct {
+	seen       int
+	suppressed int
+}
+
+// SamplingLoggerOption is an option of the sampling logger.
+type SamplingLoggerOption func(*SamplingLogger)
+
+// SuppressedCounter sets the counter of the suppressed logs.
+func SuppressedCounter(c metrics.Counter) SamplingLoggerOption {
+	return func(l *SamplingLogger) {
+		l.suppressed = c
+	}
+}
+
+// NewSamplingLogger returns a logger that samples the logs of the endpoints with a rule in 'rules'.
+func NewSamplingLogger(next log.Logger, rules map[string]SamplingRule, options ...SamplingLoggerOption) *SamplingLogger {
+	var l = &SamplingLogger{
+		next:       next,
+		rules:      rules,
+		suppressed: &NoopCounter{},
+		counts:     make(map[string]*sampleCount),
+	}
+
+	for _, opt := range options {
+		opt(l)
+	}
+	return l
+}
+
+// Log implements log.Logger.
+func (l *SamplingLogger) Log(keyvals ...interface{}) error {
+	var unit, mw string
+	var took time.Duration
+	var isError bool
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		switch keyvals[i] {
+		case "unit":
+			unit, _ = keyvals[i+1].(string)
+		case "mw":
+			mw, _ = keyvals[i+1].(string)
+		case "took":
+			took, _ = keyvals[i+1].(time.Duration)
+		case level.Key():
+			isError = keyvals[i+1] == level.ErrorValue()
+		}
+	}
+
+	var rule, ok = l.rules[unit]
+	switch {
+	case !ok, rule.Rate <= 1, isError:
+		return l.next.Log(keyvals...)
+	case rule.SlowThreshold > 0 && took >= rule.SlowThreshold:
+		return l.next.Log(keyvals...)
+	}
+
+	l.mu.Lock()
+	var c, found = l.counts[mw+"/"+unit]
+	if !found {
+		c = &sampleCount{}
+		l.counts[mw+"/"+unit] = c
+	}
+	c.seen++
+	if (c.seen-1)%rule.Rate != 0 {
+		c.suppressed++
+		l.mu.Unlock()
+		l.suppressed.Add(1)
+		return nil
+	}
+	var suppressed = c.suppressed
+	c.suppressed = 0
+	l.mu.Unlock()
+
+	if suppressed == 0 {
+		return l.next.Log(keyvals...)
+	}
+	return l.next.Log(append(keyvals, "suppressed", suppressed)...)
+}
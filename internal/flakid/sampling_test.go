@@ -0,0 +1,57 @@
+package flakid
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/metrics/generic"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSamplingLogger(t *testing.T) {
+	var logs [][]interface{}
+	var counter = generic.NewCounter("log_suppressed")
+
+	var sampler = NewSamplingLogger(recordLogger(&logs), map[string]SamplingRule{
+		"NextValidID": {Rate: 3, SlowThreshold: 100 * time.Millisecond},
+	}, SuppressedCounter(counter))
+
+	var endpointLogger = log.With(sampler, "mw", "endpoint", "unit", "NextValidID")
+	var componentLogger = log.With(sampler, "mw", "component")
+
+	// 1 log in 3 is kept, separately for each middleware.
+	for i := 0; i < 7; i++ {
+		level.Info(endpointLogger).Log("took", time.Millisecond)
+		level.Debug(componentLogger).Log("unit", "NextValidID", "took", time.Millisecond)
+	}
+	assert.Len(t, logs, 6)
+	assert.Equal(t, []interface{}{level.Key(), level.InfoValue(), "mw", "endpoint", "unit", "NextValidID", "took", time.Millisecond}, logs[0])
+	assert.Equal(t, []interface{}{level.Key(), level.InfoValue(), "mw", "endpoint", "unit", "NextValidID", "took", time.Millisecond, "suppressed", 2}, logs[2])
+	assert.Equal(t, []interface{}{level.Key(), level.DebugValue(), "mw", "component", "unit", "NextValidID", "took", time.Millisecond, "suppressed", 2}, logs[5])
+	assert.Equal(t, 8.0, counter.Value())
+
+	// Errors, slow requests and endpoints without rule are always logged.
+	logs = nil
+	for i := 0; i < 3; i++ {
+		level.Error(endpointLogger).Log("took", time.Millisecond, "error", "fail")
+		level.Info(endpointLogger).Log("took", time.Second)
+		level.Info(log.With(sampler, "mw", "endpoint", "unit", "NextID")).Log("took", time.Millisecond)
+	}
+	assert.Len(t, logs, 9)
+	assert.Equal(t, 8.0, counter.Value())
+}
+
+func TestSamplingLoggerNoSampling(t *testing.T) {
+	var logs [][]interface{}
+
+	var sampler = NewSamplingLogger(recordLogger(&logs), map[string]SamplingRule{
+		"NextID": {Rate: 1},
+	})
+
+	for i := 0; i < 5; i++ {
+		sampler.Log("unit", "NextID", "took", time.Millisecond)
+	}
+	assert.Len(t, logs, 5)
+}
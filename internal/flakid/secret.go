@@ -0,0 +1,126 @@
+package flakid
+
+import (
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// Redacted replaces the secrets in the logs.
+const Redacted = "[redacted]"
+
+// SecretKeys are the configuration keys of the secrets. Each secret can also be read from the file
+// given by the key with the suffix "-file", e.g. a Docker or Kubernetes secret.
+var SecretKeys = []string{"cockroach-password", "influx-password", "redis-password", "sentry-dsn"}
+
+// startupSecretKeys are the secrets only read at startup, their rotation requires a restart: the
+// Sentry client is created once, with the DSN.
+var startupSecretKeys = map[string]bool{"sentry-dsn": true}
+
+// Secret is a secret of the configuration, e.g. a password. The secrets read from a file are
+// reloaded with Reload, so they can be rotated without restart.
+type Secret struct {
+	path string
+
+	mu    sync.RWMutex
+	value string
+}
+
+// NewSecret returns the secret 'value', or the content of the file 'path' if it is set. The
+// trailing new lines of the file are ignored.
+func NewSecret(value, path string) (*Secret, error) {
+	var s = &Secret{path: path, value: value}
+	if path != "" {
+		var _, err = s.Reload()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Value returns the current value of the secret.
+func (s *Secret) Value() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.value
+}
+
+// String implements fmt.Stringer, so the secret is never logged by mistake.
+func (s *Secret) String() string {
+	return Redacted
+}
+
+// Reload reads the file of the secret again. It returns true if the value changed. The secrets
+// without file never change.
+func (s *Secret) Reload() (bool, error) {
+	if s.path == "" {
+		return false, nil
+	}
+
+	var data, err = ioutil.ReadFile(s.path)
+	if err != nil {
+		return false, errors.Wrapf(err, "could not read secret file '%s'", s.path)
+	}
+	var value = strings.TrimRight(string(data), "\r\n")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var changed = value != s.value
+	s.value = value
+	return changed, nil
+}
+
+// SecretReloader reloads the secrets read from files, and logs their rotations.
+type SecretReloader struct {
+	secrets map[string]*Secret
+	logger  log.Logger
+}
+
+// NewSecretReloader returns a reloader of the secrets, indexed by their configuration key. The
+// secrets only read at startup, i.e. the Sentry DSN, are not reloaded.
+func NewSecretReloader(secrets map[string]*Secret, logger log.Logger) *SecretReloader {
+	var reloaded = map[string]*Secret{}
+	for k, s := range secrets {
+		if !startupSecretKeys[k] {
+			reloaded[k] = s
+		}
+	}
+
+	return &SecretReloader{
+		secrets: reloaded,
+		logger:  logger,
+	}
+}
+
+// Reload reloads all the secrets. A secret that cannot be read keeps its previous value.
+func (r *SecretReloader) Reload() {
+	var keys = make([]string, 0, len(r.secrets))
+	for k := range r.secrets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		var changed, err = r.secrets[k].Reload()
+		switch {
+		case err != nil:
+			level.Error(r.logger).Log("msg", "could not reload secret", "key", k, "error", err)
+		case changed:
+			level.Info(r.logger).Log("msg", "secret rotated", "key", k)
+		}
+	}
+}
+
+// ReloadLoop reloads the secrets at each tick of 'c'.
+func (r *SecretReloader) ReloadLoop(c <-chan time.Time) {
+	for range c {
+		r.Reload()
+	}
+}
@@ -0,0 +1,111 @@
+package flakid
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecret(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "flakid-secret")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// Secret from the configuration.
+	var s *Secret
+	s, err = NewSecret("value", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "value", s.Value())
+	var changed bool
+	changed, err = s.Reload()
+	assert.False(t, changed)
+	assert.Nil(t, err)
+
+	// Secret from a file, the trailing new line is ignored.
+	var path = filepath.Join(dir, "password")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("first\n"), 0600))
+	s, err = NewSecret("", path)
+	assert.Nil(t, err)
+	assert.Equal(t, "first", s.Value())
+
+	// The secret is never printed.
+	assert.Equal(t, Redacted, fmt.Sprint(s))
+
+	// Rotation.
+	changed, err = s.Reload()
+	assert.False(t, changed)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(path, []byte("second\n"), 0600))
+	changed, err = s.Reload()
+	assert.True(t, changed)
+	assert.Nil(t, err)
+	assert.Equal(t, "second", s.Value())
+
+	// The value is kept when the file cannot be read.
+	assert.Nil(t, os.Remove(path))
+	_, err = s.Reload()
+	assert.NotNil(t, err)
+	assert.Equal(t, "second", s.Value())
+
+	// Missing file.
+	_, err = NewSecret("", path)
+	assert.NotNil(t, err)
+}
+
+func TestSecretReloader(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "flakid-secret")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "password")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("first"), 0600))
+	var s *Secret
+	s, err = NewSecret("", path)
+	assert.Nil(t, err)
+
+	var logs [][]interface{}
+	var r = NewSecretReloader(map[string]*Secret{"redis-password": s}, recordLogger(&logs))
+
+	// Nothing is logged while the secret does not change.
+	r.Reload()
+	assert.Empty(t, logs)
+
+	// Rotation.
+	assert.Nil(t, ioutil.WriteFile(path, []byte("second"), 0600))
+	r.Reload()
+	assert.Len(t, logs, 1)
+	assert.Contains(t, logs[0], "secret rotated")
+	assert.Contains(t, logs[0], "redis-password")
+	assert.NotContains(t, logs[0], "second")
+
+	// Error.
+	assert.Nil(t, os.Remove(path))
+	r.Reload()
+	assert.Len(t, logs, 2)
+	assert.Contains(t, logs[1], "could not reload secret")
+}
+
+func TestSecretReloaderStartupSecrets(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "flakid-secret")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "dsn")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("first"), 0600))
+	var s *Secret
+	s, err = NewSecret("", path)
+	assert.Nil(t, err)
+
+	var logs [][]interface{}
+	var r = NewSecretReloader(map[string]*Secret{"sentry-dsn": s}, recordLogger(&logs))
+
+	// The Sentry DSN is only read at startup.
+	assert.Nil(t, ioutil.WriteFile(path, []byte("second"), 0600))
+	r.Reload()
+	assert.Empty(t, logs)
+	assert.Equal(t, "first", s.Value())
+}
@@ -0,0 +1,101 @@
+package flakid
+
+import (
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/pkg/errors"
+)
+
+// sentryFlushTimeout is the time given to Sentry to send the pending reports on Close.
+const sentryFlushTimeout = 2 * time.Second
+
+// SentryTracker sends the error reports to Sentry. The reports are tagged with the component
+// name and ID, and have the release and environment of the service.
+type SentryTracker struct {
+	hub     *sentry.Hub
+	dsn     *sentry.Dsn
+	options *trackerOptions
+}
+
+// NewSentryTracker returns an error tracker that sends the reports to the Sentry project 'dsn'.
+func NewSentryTracker(dsn string, info TrackerInfo, options ...TrackerOption) (*SentryTracker, error) {
+	var opts = newTrackerOptions(options)
+
+	var parsedDSN, err = sentry.NewDsn(dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse Sentry DSN")
+	}
+
+	var client *sentry.Client
+	client, err = sentry.NewClient(sentry.ClientOptions{
+		Dsn:         dsn,
+		Release:     info.Release,
+		Environment: info.Environment,
+		Transport:   opts.transport,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create Sentry client")
+	}
+
+	var scope = sentry.NewScope()
+	scope.SetTags(map[string]string{"component_name": info.ComponentName, "component_id": info.ComponentID})
+
+	return &SentryTracker{
+		hub:     sentry.NewHub(client, scope),
+		dsn:     parsedDSN,
+		options: opts,
+	}, nil
+}
+
+// CaptureError sends the report of err to Sentry, with the recent logs as breadcrumbs.
+func (t *SentryTracker) CaptureError(err error, tags map[string]string) string {
+	var hub = t.hub.Clone()
+
+	var scope = hub.Scope()
+	scope.SetTags(tags)
+	var breadcrumbs = t.options.recentLogs()
+	for _, b := range breadcrumbs {
+		var data = map[string]interface{}{}
+		for k, v := range b.Data {
+			data[k] = v
+		}
+		scope.AddBreadcrumb(&sentry.Breadcrumb{
+			Category:  "log",
+			Level:     sentryLevel(b.Level),
+			Message:   b.Message,
+			Data:      data,
+			Timestamp: b.Time,
+		}, len(breadcrumbs))
+	}
+
+	var id = hub.CaptureException(err)
+	if id == nil {
+		return ""
+	}
+	return string(*id)
+}
+
+// URL returns the URL of the Sentry API, used by the health checks.
+func (t *SentryTracker) URL() string {
+	return t.dsn.GetAPIURL().String()
+}
+
+// Close sends the pending reports.
+func (t *SentryTracker) Close() {
+	t.hub.Flush(sentryFlushTimeout)
+}
+
+// sentryLevel returns the Sentry level of the log level l.
+func sentryLevel(l string) sentry.Level {
+	switch l {
+	case "debug":
+		return sentry.LevelDebug
+	case "warn":
+		return sentry.LevelWarning
+	case "error":
+		return sentry.LevelError
+	default:
+		return sentry.LevelInfo
+	}
+}
@@ -0,0 +1,69 @@
+package flakid
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-kit/kit/log/level"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordTransport is a Sentry transport that keeps the events.
+type recordTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *recordTransport) Configure(options sentry.ClientOptions) {}
+
+func (t *recordTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func (t *recordTransport) Flush(timeout time.Duration) bool { return true }
+
+func TestSentryTracker(t *testing.T) {
+	var logs [][]interface{}
+	var r = NewBreadcrumbRecorder(recordLogger(&logs), 10)
+	level.Warn(r).Log("msg", "before the error", "unit", "flaki")
+
+	var transport = &recordTransport{}
+	var info = TrackerInfo{ComponentName: "flaki-service", ComponentID: "123", Release: "1.0", Environment: "test"}
+	var tracker, err = NewSentryTracker("https://key@sentry.example.com/42", info, Breadcrumbs(r), func(o *trackerOptions) {
+		o.transport = transport
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://sentry.example.com/api/42/envelope/", tracker.URL())
+
+	var id = tracker.CaptureError(fmt.Errorf("fail"), map[string]string{"correlation_id": "456"})
+	assert.NotZero(t, id)
+
+	assert.Len(t, transport.events, 1)
+	var event = transport.events[0]
+	assert.Equal(t, id, string(event.EventID))
+	assert.Equal(t, "1.0", event.Release)
+	assert.Equal(t, "test", event.Environment)
+	assert.Equal(t, "flaki-service", event.Tags["component_name"])
+	assert.Equal(t, "123", event.Tags["component_id"])
+	assert.Equal(t, "456", event.Tags["correlation_id"])
+	assert.Equal(t, "fail", event.Exception[0].Value)
+	assert.Len(t, event.Breadcrumbs, 1)
+	assert.Equal(t, "before the error", event.Breadcrumbs[0].Message)
+	assert.Equal(t, sentry.LevelWarning, event.Breadcrumbs[0].Level)
+	assert.Equal(t, "flaki", event.Breadcrumbs[0].Data["unit"])
+
+	// The tags of a report are not kept for the next ones.
+	tracker.CaptureError(fmt.Errorf("fail"), nil)
+	assert.NotContains(t, transport.events[1].Tags, "correlation_id")
+
+	tracker.Close()
+
+	// Invalid DSN.
+	_, err = NewSentryTracker("not a dsn", info)
+	assert.NotNil(t, err)
+}
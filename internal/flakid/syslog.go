@@ -0,0 +1,87 @@
+package flakid
+
+//go:generate mockgen -source=syslog.go -destination=./mock/syslog.go -package=mock -mock_names=Syslog=Syslog github.com/cloudtrust/flaki-service/internal/flakid Syslog
+
+import (
+	"bytes"
+	"fmt"
+	"log/syslog"
+
+	"github.com/pkg/errors"
+)
+
+// syslogFacilities maps the facility names to their syslog value.
+var syslogFacilities = map[string]syslog.Priority{
+	"user":   syslog.LOG_USER,
+	"daemon": syslog.LOG_DAEMON,
+	"local0": syslog.LOG_LOCAL0,
+	"local1": syslog.LOG_LOCAL1,
+	"local2": syslog.LOG_LOCAL2,
+	"local3": syslog.LOG_LOCAL3,
+	"local4": syslog.LOG_LOCAL4,
+	"local5": syslog.LOG_LOCAL5,
+	"local6": syslog.LOG_LOCAL6,
+	"local7": syslog.LOG_LOCAL7,
+}
+
+// Syslog is the interface of the syslog writer.
+type Syslog interface {
+	Debug(m string) error
+	Info(m string) error
+	Warning(m string) error
+	Err(m string) error
+	Close() error
+}
+
+// SyslogWriter writes the logs to the local syslog daemon. The severity of each log
+// is given by its level.
+type SyslogWriter struct {
+	syslog Syslog
+}
+
+// NewSyslogWriter returns a writer to the local syslog daemon, with the facility
+// named 'facility' (e.g. "daemon", "local0") and the tag 'tag'.
+func NewSyslogWriter(facility, tag string) (*SyslogWriter, error) {
+	var p, ok = syslogFacilities[facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility '%s'", facility)
+	}
+
+	var w, err = syslog.New(p|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to syslog")
+	}
+	return &SyslogWriter{syslog: w}, nil
+}
+
+// Write writes the logs to syslog.
+func (w *SyslogWriter) Write(data []byte) (int, error) {
+	var msg = string(bytes.TrimSpace(data))
+
+	var level string
+	if logs, err := decodeLogs(data); err == nil {
+		level = pop(logs, "level")
+	}
+
+	var err error
+	switch level {
+	case "debug":
+		err = w.syslog.Debug(msg)
+	case "warn":
+		err = w.syslog.Warning(msg)
+	case "error":
+		err = w.syslog.Err(msg)
+	default:
+		err = w.syslog.Info(msg)
+	}
+
+	if err != nil {
+		return 0, errors.Wrap(err, "could not write logs to syslog")
+	}
+	return len(data), nil
+}
+
+// Close closes the connection to syslog.
+func (w *SyslogWriter) Close() error {
+	return w.syslog.Close()
+}
@@ -0,0 +1,43 @@
+package flakid
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cloudtrust/flaki-service/internal/flakid/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyslogWriter(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockSyslog = mock.NewSyslog(mockCtrl)
+
+	var w = &SyslogWriter{syslog: mockSyslog}
+
+	// The severity depends on the log level.
+	mockSyslog.EXPECT().Debug(`{"level":"debug"}`).Return(nil).Times(1)
+	mockSyslog.EXPECT().Info(`{"level":"info"}`).Return(nil).Times(1)
+	mockSyslog.EXPECT().Warning(`{"level":"warn"}`).Return(nil).Times(1)
+	mockSyslog.EXPECT().Err(`{"level":"error"}`).Return(nil).Times(1)
+	mockSyslog.EXPECT().Info(`{"msg":"no level"}`).Return(nil).Times(1)
+	for _, l := range []string{`{"level":"debug"}`, `{"level":"info"}`, `{"level":"warn"}`, `{"level":"error"}`, `{"msg":"no level"}`} {
+		var n, err = w.Write([]byte(l + "\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, len(l)+1, n)
+	}
+
+	// Syslog error.
+	mockSyslog.EXPECT().Info(gomock.Any()).Return(fmt.Errorf("fail")).Times(1)
+	var _, err = w.Write([]byte(`{"msg":"log"}`))
+	assert.NotNil(t, err)
+
+	mockSyslog.EXPECT().Close().Return(nil).Times(1)
+	assert.Nil(t, w.Close())
+}
+
+func TestNewSyslogWriterUnknownFacility(t *testing.T) {
+	var _, err = NewSyslogWriter("unknown", "flakid")
+	assert.NotNil(t, err)
+}
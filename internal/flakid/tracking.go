@@ -1,24 +1,201 @@
 package flakid
 
 import (
-	sentry "github.com/getsentry/raven-go"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 )
 
-// Sentry is the Sentry client interface.
-type Sentry interface {
+// ErrorTracker is the interface of the error tracking providers. CaptureError returns the ID
+// of the report.
+type ErrorTracker interface {
+	CaptureError(err error, tags map[string]string) string
 	URL() string
+	Close()
 }
 
-// NoopSentry is a Sentry client that does nothing.
-type NoopSentry struct{}
+// TrackerInfo describes the running service in the error reports.
+type TrackerInfo struct {
+	ComponentName string
+	ComponentID   string
+	Release       string
+	Environment   string
+}
 
-// CaptureError does nothing.
-func (s *NoopSentry) CaptureError(err error, tags map[string]string, interfaces ...sentry.Interface) string {
-	return ""
+type trackerOptions struct {
+	breadcrumbs *BreadcrumbRecorder
+	// transport replaces the Sentry transport in the tests.
+	transport sentry.Transport
+}
+
+// TrackerOption is an option of the error trackers.
+type TrackerOption func(*trackerOptions)
+
+// Breadcrumbs sets the recorder of the recent logs, that are sent as breadcrumbs with the reports.
+func Breadcrumbs(r *BreadcrumbRecorder) TrackerOption {
+	return func(o *trackerOptions) {
+		o.breadcrumbs = r
+	}
+}
+
+func newTrackerOptions(options []TrackerOption) *trackerOptions {
+	var o = &trackerOptions{}
+	for _, opt := range options {
+		opt(o)
+	}
+	return o
+}
+
+// recentLogs returns the recent logs, if a recorder is configured.
+func (o *trackerOptions) recentLogs() []Breadcrumb {
+	if o.breadcrumbs == nil {
+		return nil
+	}
+	return o.breadcrumbs.Breadcrumbs()
+}
+
+// Breadcrumb is a log line recorded by the BreadcrumbRecorder.
+type Breadcrumb struct {
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+// BreadcrumbRecorder is a logger that keeps the last 'size' log lines before passing them
+// on to the next logger.
+type BreadcrumbRecorder struct {
+	next log.Logger
+	size int
+
+	mu     sync.Mutex
+	crumbs []Breadcrumb
+}
+
+// NewBreadcrumbRecorder returns a logger that records the last 'size' log lines.
+func NewBreadcrumbRecorder(next log.Logger, size int) *BreadcrumbRecorder {
+	return &BreadcrumbRecorder{
+		next: next,
+		size: size,
+	}
+}
+
+// Log implements log.Logger.
+func (r *BreadcrumbRecorder) Log(keyvals ...interface{}) error {
+	if r.size > 0 {
+		var b = Breadcrumb{Time: time.Now(), Data: map[string]string{}}
+		for i := 0; i+1 < len(keyvals); i += 2 {
+			var key, value = fmt.Sprint(keyvals[i]), fmt.Sprint(keyvals[i+1])
+			switch key {
+			case fmt.Sprint(level.Key()):
+				b.Level = value
+			case "msg":
+				b.Message = value
+			default:
+				b.Data[key] = value
+			}
+		}
+
+		r.mu.Lock()
+		r.crumbs = append(r.crumbs, b)
+		if len(r.crumbs) > r.size {
+			r.crumbs = r.crumbs[len(r.crumbs)-r.size:]
+		}
+		r.mu.Unlock()
+	}
+	return r.next.Log(keyvals...)
 }
 
+// Breadcrumbs returns the recorded log lines, the oldest first.
+func (r *BreadcrumbRecorder) Breadcrumbs() []Breadcrumb {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Breadcrumb(nil), r.crumbs...)
+}
+
+// FileTracker writes the error reports as JSON lines, for the deployments without Sentry.
+type FileTracker struct {
+	w       io.WriteCloser
+	info    TrackerInfo
+	options *trackerOptions
+	mu      sync.Mutex
+}
+
+type fileReport struct {
+	ID            string            `json:"id"`
+	Time          time.Time         `json:"time"`
+	Error         string            `json:"error"`
+	Tags          map[string]string `json:"tags,omitempty"`
+	ComponentName string            `json:"component_name"`
+	ComponentID   string            `json:"component_id"`
+	Release       string            `json:"release"`
+	Environment   string            `json:"environment"`
+	Breadcrumbs   []Breadcrumb      `json:"breadcrumbs,omitempty"`
+}
+
+// NewFileTracker returns an error tracker that writes the reports to w.
+func NewFileTracker(w io.WriteCloser, info TrackerInfo, options ...TrackerOption) *FileTracker {
+	return &FileTracker{
+		w:       w,
+		info:    info,
+		options: newTrackerOptions(options),
+	}
+}
+
+// CaptureError writes the report of err.
+func (t *FileTracker) CaptureError(err error, tags map[string]string) string {
+	var id = make([]byte, 16)
+	rand.Read(id)
+
+	var report = fileReport{
+		ID:            hex.EncodeToString(id),
+		Time:          time.Now().UTC(),
+		Error:         err.Error(),
+		Tags:          tags,
+		ComponentName: t.info.ComponentName,
+		ComponentID:   t.info.ComponentID,
+		Release:       t.info.Release,
+		Environment:   t.info.Environment,
+		Breadcrumbs:   t.options.recentLogs(),
+	}
+
+	var data, jsonErr = json.Marshal(report)
+	if jsonErr != nil {
+		return ""
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := t.w.Write(append(data, '\n')); err != nil {
+		return ""
+	}
+	return report.ID
+}
+
+// URL returns an empty string, the reports are not sent to a server.
+func (t *FileTracker) URL() string { return "" }
+
+// Close closes the reports file.
+func (t *FileTracker) Close() {
+	t.w.Close()
+}
+
+// NoopTracker is an error tracker that does nothing.
+type NoopTracker struct{}
+
+// CaptureError does nothing.
+func (t *NoopTracker) CaptureError(err error, tags map[string]string) string { return "" }
+
 // URL does nothing.
-func (s *NoopSentry) URL() string { return "" }
+func (t *NoopTracker) URL() string { return "" }
 
 // Close does nothing.
-func (s *NoopSentry) Close() {}
+func (t *NoopTracker) Close() {}
@@ -1,19 +1,91 @@
 package flakid
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"testing"
 
+	"github.com/go-kit/kit/log/level"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestNoopSentry(t *testing.T) {
-	var noopSentry = &NoopSentry{}
+// bufferCloser is a bytes.Buffer that can be closed.
+type bufferCloser struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *bufferCloser) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestNoopTracker(t *testing.T) {
+	var noopTracker = &NoopTracker{}
 
 	// CaptureError
-	assert.Zero(t, noopSentry.CaptureError(nil, nil))
-	assert.Zero(t, noopSentry.CaptureError(fmt.Errorf("fail"), map[string]string{"key": "val"}))
+	assert.Zero(t, noopTracker.CaptureError(nil, nil))
+	assert.Zero(t, noopTracker.CaptureError(fmt.Errorf("fail"), map[string]string{"key": "val"}))
 
 	// URL
-	assert.Zero(t, noopSentry.URL())
+	assert.Zero(t, noopTracker.URL())
+}
+
+func TestBreadcrumbRecorder(t *testing.T) {
+	var logs [][]interface{}
+	var r = NewBreadcrumbRecorder(recordLogger(&logs), 2)
+
+	level.Info(r).Log("msg", "first", "unit", "flaki")
+	level.Warn(r).Log("msg", "second")
+	level.Error(r).Log("msg", "third", "error", "fail")
+
+	// The logs are passed on.
+	assert.Len(t, logs, 3)
+
+	// Only the last ones are kept.
+	var crumbs = r.Breadcrumbs()
+	assert.Len(t, crumbs, 2)
+	assert.Equal(t, "warn", crumbs[0].Level)
+	assert.Equal(t, "second", crumbs[0].Message)
+	assert.Equal(t, "error", crumbs[1].Level)
+	assert.Equal(t, map[string]string{"error": "fail"}, crumbs[1].Data)
+
+	// Without size, nothing is recorded.
+	r = NewBreadcrumbRecorder(recordLogger(&logs), 0)
+	r.Log("msg", "first")
+	assert.Empty(t, r.Breadcrumbs())
+}
+
+func TestFileTracker(t *testing.T) {
+	var logs [][]interface{}
+	var r = NewBreadcrumbRecorder(recordLogger(&logs), 10)
+	level.Info(r).Log("msg", "before the error")
+
+	var w = &bufferCloser{}
+	var info = TrackerInfo{ComponentName: "flaki-service", ComponentID: "123", Release: "1.0", Environment: "test"}
+	var tracker = NewFileTracker(w, info, Breadcrumbs(r))
+
+	var id = tracker.CaptureError(fmt.Errorf("fail"), map[string]string{"correlation_id": "456"})
+	assert.Len(t, id, 32)
+
+	var report fileReport
+	assert.Nil(t, json.Unmarshal(w.Bytes(), &report))
+	assert.Equal(t, id, report.ID)
+	assert.Equal(t, "fail", report.Error)
+	assert.Equal(t, map[string]string{"correlation_id": "456"}, report.Tags)
+	assert.Equal(t, "flaki-service", report.ComponentName)
+	assert.Equal(t, "123", report.ComponentID)
+	assert.Equal(t, "1.0", report.Release)
+	assert.Equal(t, "test", report.Environment)
+	assert.Len(t, report.Breadcrumbs, 1)
+	assert.Equal(t, "before the error", report.Breadcrumbs[0].Message)
+
+	// One report per line.
+	tracker.CaptureError(fmt.Errorf("fail"), nil)
+	assert.Equal(t, 2, bytes.Count(w.Bytes(), []byte("\n")))
+
+	assert.Zero(t, tracker.URL())
+	tracker.Close()
+	assert.True(t, w.closed)
 }
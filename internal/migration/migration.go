@@ -0,0 +1,246 @@
+package migration
+
+//go:generate mockgen -destination=./mock/migration.go -package=mock -mock_names=DB=DB github.com/cloudtrust/flaki-service/internal/migration DB
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	createMigrationsTblStmt = `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT,
+		description TEXT,
+		applied_at TIMESTAMPTZ,
+		PRIMARY KEY (version))`
+	createLockTblStmt = `CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+		id INT,
+		PRIMARY KEY (id))`
+	insertLockStmt      = `INSERT INTO schema_migrations_lock (id) VALUES (1) ON CONFLICT (id) DO NOTHING`
+	lockStmt            = `SELECT id FROM schema_migrations_lock WHERE id = 1 FOR UPDATE`
+	migrationsTblStmt   = `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = 'schema_migrations'`
+	selectVersionStmt   = `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`
+	insertMigrationStmt = `INSERT INTO schema_migrations (version, description, applied_at) VALUES ($1, $2, $3)`
+)
+
+// Migration is a change of the DB schema. The migrations are applied in order of version.
+type Migration struct {
+	Version     int
+	Description string
+	// Up is the statement of the migration for Cockroach. The statements for the other SQL
+	// dialects, e.g. "postgres", are in Dialects.
+	Up       string
+	Dialects map[string]string
+}
+
+// Migrations is the ordered list of the migrations of the flaki-service DB schema.
+// New migrations must be appended with the next version number, existing ones must never be modified.
+// If a statement is not valid for PostgreSQL, e.g. it uses the Cockroach type STRING, the migration
+// has a PostgreSQL statement in Dialects.
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create health table",
+		Up: `CREATE TABLE IF NOT EXISTS health (
+			component_name STRING,
+			component_id STRING,
+			unit STRING,
+			json JSONB,
+			last_updated TIMESTAMPTZ,
+			valid_until TIMESTAMPTZ,
+			PRIMARY KEY (component_name, component_id, unit))`,
+		Dialects: map[string]string{
+			"postgres": `CREATE TABLE IF NOT EXISTS health (
+			component_name TEXT,
+			component_id TEXT,
+			unit TEXT,
+			json JSONB,
+			last_updated TIMESTAMPTZ,
+			valid_until TIMESTAMPTZ,
+			PRIMARY KEY (component_name, component_id, unit))`,
+		},
+	},
+}
+
+// statement returns the statement of the migration for the SQL dialect.
+func (m Migration) statement(dialect string) string {
+	if stmt, ok := m.Dialects[dialect]; ok {
+		return stmt
+	}
+	return m.Up
+}
+
+// LatestVersion returns the schema version expected by this release of flaki-service.
+func LatestVersion() int {
+	return Migrations[len(Migrations)-1].Version
+}
+
+// DB is the interface of the DB.
+type DB interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	Begin() (*sql.Tx, error)
+}
+
+// querier is implemented by both the DB and its transactions.
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Migrator applies the migrations to the DB and checks the schema version.
+type Migrator struct {
+	db         DB
+	dialect    string
+	migrations []Migration
+}
+
+// MigratorOption is an option of the migrator.
+type MigratorOption func(*Migrator)
+
+// SQLDialect sets the name of the SQL dialect of the DB, i.e. "cockroach" or "postgres". The
+// default is "cockroach".
+func SQLDialect(name string) MigratorOption {
+	return func(m *Migrator) {
+		m.dialect = name
+	}
+}
+
+// NewMigrator returns a migrator for the flaki-service migrations.
+func NewMigrator(db DB, options ...MigratorOption) *Migrator {
+	var m = &Migrator{
+		db:         db,
+		dialect:    "cockroach",
+		migrations: Migrations,
+	}
+
+	for _, opt := range options {
+		opt(m)
+	}
+	return m
+}
+
+// Version returns the current schema version of the DB, 0 if no migration was applied. It does
+// not modify the DB.
+func (m *Migrator) Version() (int, error) {
+	var tables, err = queryInt(m.db, migrationsTblStmt)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not look for schema_migrations table")
+	}
+	if tables == 0 {
+		return 0, nil
+	}
+
+	var version int
+	version, err = queryInt(m.db, selectVersionStmt)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not read schema version")
+	}
+	return version, nil
+}
+
+// Up applies, in order, the migrations that are not yet applied. It returns the list of applied migrations.
+// Each migration is applied with its schema_migrations record in a transaction that holds the migrations
+// lock, so instances running Up concurrently apply it once.
+func (m *Migrator) Up() ([]Migration, error) {
+	for _, stmt := range []string{createMigrationsTblStmt, createLockTblStmt, insertLockStmt} {
+		var _, err = m.db.Exec(stmt)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create schema_migrations tables")
+		}
+	}
+
+	var applied = []Migration{}
+	for _, migration := range m.migrations {
+		var ok, err = m.apply(migration)
+		if err != nil {
+			return applied, err
+		}
+		if ok {
+			applied = append(applied, migration)
+		}
+	}
+	return applied, nil
+}
+
+// apply applies the migration, unless it was already applied. It returns true if it was applied.
+func (m *Migrator) apply(migration Migration) (bool, error) {
+	var tx, err = m.db.Begin()
+	if err != nil {
+		return false, errors.Wrapf(err, "could not start transaction of migration %d", migration.Version)
+	}
+	// Rollback does nothing once the transaction is committed.
+	defer tx.Rollback()
+
+	// The lock is held until the end of the transaction.
+	_, err = tx.Exec(lockStmt)
+	if err != nil {
+		return false, errors.Wrap(err, "could not lock schema_migrations")
+	}
+
+	var version int
+	version, err = queryInt(tx, selectVersionStmt)
+	if err != nil {
+		return false, errors.Wrap(err, "could not read schema version")
+	}
+	if migration.Version <= version {
+		return false, nil
+	}
+
+	_, err = tx.Exec(migration.statement(m.dialect))
+	if err != nil {
+		return false, errors.Wrapf(err, "could not apply migration %d (%s)", migration.Version, migration.Description)
+	}
+
+	_, err = tx.Exec(insertMigrationStmt, migration.Version, migration.Description, time.Now().UTC())
+	if err != nil {
+		return false, errors.Wrapf(err, "could not record migration %d (%s)", migration.Version, migration.Description)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, errors.Wrapf(err, "could not commit migration %d (%s)", migration.Version, migration.Description)
+	}
+	return true, nil
+}
+
+// Check returns an error if the schema version of the DB is not the one expected by this release.
+// It does not modify the DB.
+func (m *Migrator) Check() error {
+	var version, err = m.Version()
+	if err != nil {
+		return err
+	}
+
+	var latest = m.migrations[len(m.migrations)-1].Version
+	switch {
+	case version < latest:
+		return fmt.Errorf("schema version %d is older than the expected version %d, run 'flakid migrate'", version, latest)
+	case version > latest:
+		return fmt.Errorf("schema version %d is newer than the expected version %d, upgrade flakid", version, latest)
+	}
+	return nil
+}
+
+// queryInt returns the integer selected by the statement.
+func queryInt(q querier, stmt string) (int, error) {
+	var rows, err = q.Query(stmt)
+	if err != nil {
+		return 0, err
+	}
+	if rows == nil {
+		return 0, fmt.Errorf("rows should not be nil")
+	}
+	defer rows.Close()
+
+	var i = 0
+	for rows.Next() {
+		err = rows.Scan(&i)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return i, rows.Err()
+}
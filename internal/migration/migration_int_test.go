@@ -0,0 +1,95 @@
+// +build integration
+
+package migration
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"sync"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	hostPort = flag.String("hostport", "127.0.0.1:26257", "cockroach host:port")
+	user     = flag.String("user", "cockroach", "user name")
+	db       = flag.String("db", "health", "database name")
+)
+
+func TestIntMigrator(t *testing.T) {
+	var db = setupCleanDB(t)
+	var m = NewMigrator(db)
+
+	// Empty DB.
+	var version, err = m.Version()
+	assert.Nil(t, err)
+	assert.Zero(t, version)
+	assert.NotNil(t, m.Check())
+
+	// Check does not create schema_migrations.
+	_, err = db.Exec("SELECT * from schema_migrations")
+	assert.NotNil(t, err)
+
+	// Apply all migrations.
+	var applied []Migration
+	applied, err = m.Up()
+	assert.Nil(t, err)
+	assert.Equal(t, Migrations, applied)
+
+	version, err = m.Version()
+	assert.Nil(t, err)
+	assert.Equal(t, LatestVersion(), version)
+	assert.Nil(t, m.Check())
+
+	// The health table exists.
+	_, err = db.Exec("SELECT * from health")
+	assert.Nil(t, err)
+
+	// Applying the migrations again does nothing.
+	applied, err = m.Up()
+	assert.Nil(t, err)
+	assert.Zero(t, len(applied))
+
+	// Schema newer than the expected one.
+	_, err = db.Exec(insertMigrationStmt, LatestVersion()+1, "future migration", "2018-01-01T00:00:00Z")
+	assert.Nil(t, err)
+	assert.NotNil(t, m.Check())
+}
+
+func TestIntMigratorConcurrentUp(t *testing.T) {
+	var db = setupCleanDB(t)
+
+	// Each migration is applied by exactly one of the migrators.
+	var wg sync.WaitGroup
+	var applied = make([][]Migration, 4)
+	var errs = make([]error, 4)
+	for i := range applied {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			applied[i], errs[i] = NewMigrator(db).Up()
+		}(i)
+	}
+	wg.Wait()
+
+	var count = 0
+	for i := range applied {
+		assert.Nil(t, errs[i])
+		count += len(applied[i])
+	}
+	assert.Equal(t, len(Migrations), count)
+	assert.Nil(t, NewMigrator(db).Check())
+}
+
+func setupCleanDB(t *testing.T) *sql.DB {
+	var db, err = sql.Open("postgres", fmt.Sprintf("postgresql://%s@%s/%s?sslmode=disable", *user, *hostPort, *db))
+	assert.Nil(t, err)
+	// Clean
+	db.Exec("DROP table health")
+	db.Exec("DROP table schema_migrations")
+	db.Exec("DROP table schema_migrations_lock")
+	return db
+}
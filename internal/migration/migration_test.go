@@ -0,0 +1,108 @@
+package migration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cloudtrust/flaki-service/internal/migration/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationsOrder(t *testing.T) {
+	// The migrations versions must start at 1 and be consecutive.
+	for i, m := range Migrations {
+		assert.Equal(t, i+1, m.Version)
+		assert.NotZero(t, m.Description)
+		assert.NotZero(t, m.Up)
+	}
+	assert.Equal(t, len(Migrations), LatestVersion())
+}
+
+func TestMigrationStatement(t *testing.T) {
+	var m = Migration{
+		Version: 1,
+		Up:      "CREATE TABLE t (s STRING)",
+		Dialects: map[string]string{
+			"postgres": "CREATE TABLE t (s TEXT)",
+		},
+	}
+
+	assert.Equal(t, "CREATE TABLE t (s STRING)", m.statement("cockroach"))
+	assert.Equal(t, "CREATE TABLE t (s TEXT)", m.statement("postgres"))
+
+	// Without dialect specific statement.
+	m.Dialects = nil
+	assert.Equal(t, "CREATE TABLE t (s STRING)", m.statement("postgres"))
+}
+
+func TestVersionFail(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockDB = mock.NewDB(mockCtrl)
+
+	var m = NewMigrator(mockDB)
+
+	// Lookup of schema_migrations fails.
+	mockDB.EXPECT().Query(migrationsTblStmt).Return(nil, fmt.Errorf("fail")).Times(1)
+	{
+		var _, err = m.Version()
+		assert.NotNil(t, err)
+	}
+
+	// Nil rows.
+	mockDB.EXPECT().Query(migrationsTblStmt).Return(nil, nil).Times(1)
+	{
+		var _, err = m.Version()
+		assert.NotNil(t, err)
+	}
+}
+
+func TestCheckReadOnly(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockDB = mock.NewDB(mockCtrl)
+
+	var m = NewMigrator(mockDB)
+
+	// Check only queries the DB, any Exec or Begin fails the test.
+	mockDB.EXPECT().Query(migrationsTblStmt).Return(nil, fmt.Errorf("fail")).Times(1)
+	assert.NotNil(t, m.Check())
+}
+
+func TestUpFail(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockDB = mock.NewDB(mockCtrl)
+
+	var m = NewMigrator(mockDB)
+
+	// Creation of schema_migrations fails.
+	mockDB.EXPECT().Exec(createMigrationsTblStmt).Return(nil, fmt.Errorf("fail")).Times(1)
+	{
+		var applied, err = m.Up()
+		assert.NotNil(t, err)
+		assert.Zero(t, len(applied))
+	}
+
+	// Creation of the lock fails.
+	mockDB.EXPECT().Exec(createMigrationsTblStmt).Return(nil, nil).Times(1)
+	mockDB.EXPECT().Exec(createLockTblStmt).Return(nil, nil).Times(1)
+	mockDB.EXPECT().Exec(insertLockStmt).Return(nil, fmt.Errorf("fail")).Times(1)
+	{
+		var applied, err = m.Up()
+		assert.NotNil(t, err)
+		assert.Zero(t, len(applied))
+	}
+
+	// Transaction fails.
+	mockDB.EXPECT().Exec(createMigrationsTblStmt).Return(nil, nil).Times(1)
+	mockDB.EXPECT().Exec(createLockTblStmt).Return(nil, nil).Times(1)
+	mockDB.EXPECT().Exec(insertLockStmt).Return(nil, nil).Times(1)
+	mockDB.EXPECT().Begin().Return(nil, fmt.Errorf("fail")).Times(1)
+	{
+		var applied, err = m.Up()
+		assert.NotNil(t, err)
+		assert.Zero(t, len(applied))
+	}
+}
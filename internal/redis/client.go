@@ -1,196 +1,334 @@
 package redis
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
 	"sync"
 	"time"
 
+	"github.com/go-kit/kit/metrics"
 	"github.com/gomodule/redigo/redis"
+	"github.com/pkg/errors"
 )
 
-const (
-	backoffInit = 100 * time.Millisecond
-	backoffMax  = 5 * time.Second
-)
+// ErrUnavailable is returned in fail-fast mode when Redis is down and the client is waiting
+// before the next reconnection attempt.
+var ErrUnavailable = fmt.Errorf("redis unavailable")
 
-// Redis is the interface of the Redis client.
-type Redis interface {
-	Do(commandName string, args ...interface{}) (reply interface{}, err error)
-	Send(commandName string, args ...interface{}) error
-	Flush() error
-	Close() error
-}
+// ErrClosed is returned when the client is used after Close.
+var ErrClosed = fmt.Errorf("redis client closed")
+
+// Config is the configuration of the Redis client.
+type Config struct {
+	HostPort string
+	Password string
+	Database int
+
+	// MaxIdle is the maximum number of idle connections in the pool, MaxActive the maximum
+	// number of connections (0 means no limit). IdleTimeout closes the connections that
+	// remain idle for longer.
+	MaxIdle     int
+	MaxActive   int
+	IdleTimeout time.Duration
+
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
 
-// Conn is the redis resilient connection.
-type Conn struct {
-	conn     Redis
-	redisURL string
-	redisPWD string
-	redisDB  int
+	// Timeout is the deadline of the calls made without context, i.e. Do and Flush.
+	Timeout time.Duration
 
-	notifyErr chan struct{}
-	quit      chan struct{}
-	backoff   time.Duration
+	// In fail-fast mode, the calls made while Redis is down return ErrUnavailable immediately,
+	// instead of waiting for the next reconnection attempt.
+	FailFast bool
 
-	// the mutex is used with the Cond as a rendezvous point for
-	// the goroutines waiting for a valid connection.
-	mu   *sync.Mutex
-	cond *sync.Cond
+	Backoff Backoff
 }
 
-// NewResilientConn returns a connection that automatically recover from failures.
-func NewResilientConn(url, pwd string, db int) (*Conn, error) {
-	var mu = &sync.Mutex{}
-	var cond = sync.NewCond(mu)
+// Backoff is the configuration of the wait between two reconnection attempts. The wait starts at
+// Initial and is multiplied by Multiplier after each failed attempt, up to Max. A random part of
+// the wait, at most Jitter (between 0 and 1) of it, is removed so the instances do not reconnect
+// all at the same time.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+}
 
-	var redisConn redis.Conn
-	{
-		var err error
-		redisConn, err = redis.Dial("tcp", url, redis.DialDatabase(db), redis.DialPassword(pwd))
-		if err != nil {
-			return nil, err
-		}
+// Duration returns the wait after the given number of consecutive failures.
+func (b Backoff) Duration(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
 	}
 
-	var conn = &Conn{
-		conn:      redisConn,
-		redisURL:  url,
-		redisDB:   db,
-		redisPWD:  pwd,
-		mu:        mu,
-		cond:      cond,
-		notifyErr: make(chan struct{}),
-		quit:      make(chan struct{}),
-		backoff:   backoffInit,
-	}
-
-	go func() {
-		var recovery = false
-		var t = time.NewTimer(backoffInit)
-	loop:
-		for {
-			if recovery {
-				// recovery mode: try periodically to reconnect, until it succeeds.
-				select {
-				case <-t.C:
-				case <-conn.quit:
-					break loop
-				}
-				var redisConn, err = redis.Dial("tcp", conn.redisURL, redis.DialDatabase(conn.redisDB), redis.DialPassword(conn.redisPWD))
-				conn.updateBackoff()
-				conn.resetTimer(t, conn.backoff)
-
-				if err == nil {
-					// Connection successful: recover, stop timer, and quit recovery mode (wait for next error)
-					recovery = false
-					conn.recover(redisConn)
-				}
-			} else {
-				// wait a goroutines signaling an error
-				select {
-				case <-conn.notifyErr:
-				case <-conn.quit:
-					break loop
-				}
-
-				recovery = true
-				conn.resetTimer(t, conn.backoff)
-			}
-		}
-	}()
+	var d = float64(b.Initial) * math.Pow(b.Multiplier, float64(failures-1))
+	if max := float64(b.Max); b.Max > 0 && d > max {
+		d = max
+	}
+	d -= d * b.Jitter * rand.Float64()
+	return time.Duration(d)
+}
 
-	return conn, nil
+// Client is a Redis client backed by a pool of connections. When Redis is down, the
+// reconnections are attempted with an exponential backoff.
+type Client struct {
+	pool   *redis.Pool
+	config Config
+
+	reconnects metrics.Counter
+	wait       metrics.Histogram
+	password   func() string
+
+	// mu protects the fields below.
+	mu       sync.Mutex
+	failures int
+	retryAt  time.Time
+	pending  []command
+	closed   bool
 }
 
-func (c *Conn) Do(commandName string, args ...interface{}) (interface{}, error) {
-	var reply interface{}
-	var err error
-	for {
-		c.mu.Lock()
-		reply, err = c.conn.Do(commandName, args...)
-
-		if err == nil {
-			c.mu.Unlock()
-			break
-		}
+type command struct {
+	name string
+	args []interface{}
+}
 
-		// signal an error in a non-blocking way
-		select {
-		case c.notifyErr <- struct{}{}:
-		default:
-		}
+// Option is an option of the Redis client.
+type Option func(*Client)
 
-		// rdv point, we wait on the signal that a new connection was successfully created
-		c.cond.Wait()
-		c.mu.Unlock()
+// ReconnectCounter sets the counter incremented each time the connection to Redis is recovered.
+func ReconnectCounter(c metrics.Counter) Option {
+	return func(client *Client) {
+		client.reconnects = c
 	}
+}
 
-	return reply, err
+// WaitHistogram sets the histogram that records, in seconds, the time spent waiting for a connection.
+func WaitHistogram(h metrics.Histogram) Option {
+	return func(client *Client) {
+		client.wait = h
+	}
 }
 
-func (c *Conn) Send(commandName string, args ...interface{}) error {
-	var err error
-	for {
-		c.mu.Lock()
-		err = c.conn.Send(commandName, args...)
+// PasswordSource sets the function that returns the password of the new connections, instead of
+// the password of the configuration. It allows the rotation of the password without restart.
+func PasswordSource(f func() string) Option {
+	return func(client *Client) {
+		client.password = f
+	}
+}
 
-		if err == nil {
-			c.mu.Unlock()
-			break
-		}
+// NewClient returns a Redis client. No connection is opened until the first call.
+func NewClient(config Config, options ...Option) *Client {
+	var c = &Client{
+		config:     config,
+		reconnects: noopCounter{},
+		wait:       noopHistogram{},
+		password:   func() string { return config.Password },
+	}
 
-		// signal an error in a non-blocking way
-		select {
-		case c.notifyErr <- struct{}{}:
-		default:
-		}
+	for _, opt := range options {
+		opt(c)
+	}
 
-		// rdv point, we wait on the signal that a new connection was successfully created
-		c.cond.Wait()
-		c.mu.Unlock()
+	c.pool = &redis.Pool{
+		MaxIdle:     config.MaxIdle,
+		MaxActive:   config.MaxActive,
+		IdleTimeout: config.IdleTimeout,
+		Wait:        true,
+		Dial:        c.dial,
+		TestOnBorrow: func(conn redis.Conn, lastUsed time.Time) error {
+			// Check the connections that were idle for a while, Redis may have closed them.
+			if time.Since(lastUsed) < time.Minute {
+				return nil
+			}
+			var _, err = conn.Do("PING")
+			return err
+		},
+	}
+	return c
+}
+
+// Do sends the command to Redis and returns its reply. It gives up after the configured timeout.
+func (c *Client) Do(commandName string, args ...interface{}) (interface{}, error) {
+	var ctx, cancel = c.timeoutContext()
+	defer cancel()
+
+	return c.DoContext(ctx, commandName, args...)
+}
+
+// DoContext sends the command to Redis and returns its reply. It gives up when the context is done.
+func (c *Client) DoContext(ctx context.Context, commandName string, args ...interface{}) (interface{}, error) {
+	var conn, err = c.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var reply interface{}
+	if deadline, ok := ctx.Deadline(); ok {
+		reply, err = redis.DoWithTimeout(conn, time.Until(deadline), commandName, args...)
+	} else {
+		reply, err = conn.Do(commandName, args...)
 	}
 
-	return err
+	if err != nil {
+		return nil, errors.Wrapf(err, "redis command '%s' failed", commandName)
+	}
+	return reply, nil
 }
 
-func (c *Conn) Flush() error {
-	return c.conn.Flush()
+// Send buffers the command. The buffered commands are sent to Redis by Flush.
+func (c *Client) Send(commandName string, args ...interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	c.pending = append(c.pending, command{name: commandName, args: args})
+	return nil
 }
 
-// Close terminates the goroutine that recover lost connections.
-func (c *Conn) Close() error {
-	c.quit <- struct{}{}
-	return c.conn.Close()
+// Flush sends the buffered commands to Redis in a single pipeline. It gives up after the configured
+// timeout, the commands that could not be sent are lost.
+func (c *Client) Flush() error {
+	var ctx, cancel = c.timeoutContext()
+	defer cancel()
+
+	return c.FlushContext(ctx)
 }
 
-func (c *Conn) recover(new Redis) {
-	// update connection
-	c.conn = new
+// FlushContext sends the buffered commands to Redis in a single pipeline. It gives up when the
+// context is done, the commands that could not be sent are lost.
+func (c *Client) FlushContext(ctx context.Context) error {
+	c.mu.Lock()
+	var cmds = c.pending
+	c.pending = nil
+	c.mu.Unlock()
 
-	// reset backoff time
-	c.backoff = backoffInit
+	if len(cmds) == 0 {
+		return nil
+	}
 
-	// wake goroutines that are waiting on the successfull connection
-	c.cond.Broadcast()
+	var conn, err = c.conn(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "%d redis commands lost", len(cmds))
+	}
+	defer conn.Close()
 
-	// Empty error channel, there may be other goroutines signaling the same connection failure.
-	for len(c.notifyErr) > 0 {
-		<-c.notifyErr
+	for _, cmd := range cmds {
+		err = conn.Send(cmd.name, cmd.args...)
+		if err != nil {
+			return errors.Wrapf(err, "%d redis commands lost", len(cmds))
+		}
 	}
+
+	// The empty command flushes the pipeline and receives all the pending replies.
+	if deadline, ok := ctx.Deadline(); ok {
+		_, err = redis.DoWithTimeout(conn, time.Until(deadline), "")
+	} else {
+		_, err = conn.Do("")
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not flush %d redis commands", len(cmds))
+	}
+	return nil
 }
 
-// update the backoff time. A linear backoff limited to backoff max is used.
-func (c *Conn) updateBackoff() {
-	if c.backoff < backoffMax {
-		c.backoff = c.backoff + backoffInit
+// Close closes the pool of connections. The buffered commands that were not flushed are lost.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
 	}
+	c.closed = true
+	c.pending = nil
+	c.mu.Unlock()
+
+	return c.pool.Close()
 }
 
-func (c *Conn) resetTimer(t *time.Timer, d time.Duration) {
-	if !t.Stop() {
+// conn returns a connection from the pool. If Redis is down, it waits for the next reconnection
+// attempt, or returns ErrUnavailable in fail-fast mode.
+func (c *Client) conn(ctx context.Context) (redis.Conn, error) {
+	var start = time.Now()
+	defer func() {
+		c.wait.Observe(time.Since(start).Seconds())
+	}()
+
+	c.mu.Lock()
+	var closed, retryAt = c.closed, c.retryAt
+	c.mu.Unlock()
+
+	if closed {
+		return nil, ErrClosed
+	}
+
+	if wait := time.Until(retryAt); wait > 0 {
+		if c.config.FailFast {
+			return nil, ErrUnavailable
+		}
+
+		var t = time.NewTimer(wait)
+		defer t.Stop()
 		select {
 		case <-t.C:
-		default:
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "waiting for redis")
 		}
 	}
-	t.Reset(d)
+
+	var conn, err = c.pool.GetContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get redis connection")
+	}
+	return conn, nil
 }
+
+// dial opens a new connection and keeps track of the failures to compute the backoff.
+func (c *Client) dial() (redis.Conn, error) {
+	var conn, err = redis.Dial("tcp", c.config.HostPort,
+		redis.DialDatabase(c.config.Database),
+		redis.DialPassword(c.password()),
+		redis.DialConnectTimeout(c.config.DialTimeout),
+		redis.DialReadTimeout(c.config.ReadTimeout),
+		redis.DialWriteTimeout(c.config.WriteTimeout),
+	)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil {
+		c.failures++
+		c.retryAt = time.Now().Add(c.config.Backoff.Duration(c.failures))
+		return nil, err
+	}
+
+	if c.failures > 0 {
+		c.reconnects.Add(1)
+	}
+	c.failures = 0
+	c.retryAt = time.Time{}
+	return conn, nil
+}
+
+func (c *Client) timeoutContext() (context.Context, context.CancelFunc) {
+	if c.config.Timeout > 0 {
+		return context.WithTimeout(context.Background(), c.config.Timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+type noopCounter struct{}
+
+func (noopCounter) With(labelValues ...string) metrics.Counter { return noopCounter{} }
+func (noopCounter) Add(delta float64)                          {}
+
+type noopHistogram struct{}
+
+func (noopHistogram) With(labelValues ...string) metrics.Histogram { return noopHistogram{} }
+func (noopHistogram) Observe(value float64)                        {}
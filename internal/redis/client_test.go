@@ -0,0 +1,288 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	var b = Backoff{
+		Initial:    100 * time.Millisecond,
+		Max:        time.Second,
+		Multiplier: 2,
+	}
+
+	assert.Equal(t, time.Duration(0), b.Duration(0))
+	assert.Equal(t, 100*time.Millisecond, b.Duration(1))
+	assert.Equal(t, 200*time.Millisecond, b.Duration(2))
+	assert.Equal(t, 400*time.Millisecond, b.Duration(3))
+	assert.Equal(t, time.Second, b.Duration(10))
+
+	// With jitter, the wait is reduced by at most Jitter of it.
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		var d = b.Duration(2)
+		assert.True(t, d > 100*time.Millisecond && d <= 200*time.Millisecond)
+	}
+}
+
+func TestFailFast(t *testing.T) {
+	var c = NewClient(Config{
+		HostPort: closedAddr(t),
+		FailFast: true,
+		Backoff:  Backoff{Initial: time.Minute, Max: time.Minute, Multiplier: 2},
+	})
+	defer c.Close()
+
+	// The first call tries to connect, the next ones fail immediately until the backoff elapsed.
+	var _, err = c.Do("PING")
+	assert.NotNil(t, err)
+	assert.NotEqual(t, ErrUnavailable, err)
+
+	_, err = c.Do("PING")
+	assert.Equal(t, ErrUnavailable, err)
+}
+
+func TestWaitContext(t *testing.T) {
+	var c = NewClient(Config{
+		HostPort: closedAddr(t),
+		Backoff:  Backoff{Initial: time.Minute, Max: time.Minute, Multiplier: 2},
+	})
+	defer c.Close()
+
+	var _, err = c.Do("PING")
+	assert.NotNil(t, err)
+
+	// The caller waits for the next reconnection attempt, until its context is done.
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var start = time.Now()
+	_, err = c.DoContext(ctx, "PING")
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestReconnect(t *testing.T) {
+	var s = newFakeRedis(t)
+	var counter = &counter{}
+	var c = NewClient(Config{
+		HostPort: s.addr,
+		MaxIdle:  1,
+		Timeout:  time.Second,
+		Backoff:  Backoff{Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 2},
+	}, ReconnectCounter(counter))
+	defer c.Close()
+
+	var reply, err = c.Do("PING")
+	assert.Nil(t, err)
+	assert.Equal(t, "PONG", reply)
+	assert.Zero(t, counter.value())
+
+	// Redis goes down: the pooled connection is broken, then the reconnection fails.
+	s.close()
+	_, err = c.Do("PING")
+	assert.NotNil(t, err)
+	_, err = c.Do("PING")
+	assert.NotNil(t, err)
+
+	// Redis comes back.
+	s = newFakeRedisAt(t, s.addr)
+	defer s.close()
+	time.Sleep(5 * time.Millisecond)
+
+	reply, err = c.Do("PING")
+	assert.Nil(t, err)
+	assert.Equal(t, "PONG", reply)
+	assert.Equal(t, 1.0, counter.value())
+}
+
+func TestSendFlush(t *testing.T) {
+	var s = newFakeRedis(t)
+	defer s.close()
+
+	var c = NewClient(Config{
+		HostPort: s.addr,
+		Timeout:  time.Second,
+	})
+
+	assert.Nil(t, c.Flush())
+
+	assert.Nil(t, c.Send("RPUSH", "key", "a"))
+	assert.Nil(t, c.Send("RPUSH", "key", "b"))
+	assert.Nil(t, c.Flush())
+	assert.Equal(t, []string{"RPUSH", "RPUSH"}, s.received())
+
+	assert.Nil(t, c.Close())
+	assert.Nil(t, c.Close())
+	assert.Equal(t, ErrClosed, c.Send("RPUSH", "key", "c"))
+	var _, err = c.Do("PING")
+	assert.Equal(t, ErrClosed, err)
+}
+
+func TestPasswordSource(t *testing.T) {
+	var s = newFakeRedis(t)
+	defer s.close()
+
+	var password = "first"
+	var c = NewClient(Config{
+		HostPort: s.addr,
+		Password: "config",
+		Timeout:  time.Second,
+	}, PasswordSource(func() string { return password }))
+	defer c.Close()
+
+	var _, err = c.Do("PING")
+	assert.Nil(t, err)
+
+	// Without idle connection, the next call opens a connection with the rotated password.
+	password = "second"
+	_, err = c.Do("PING")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"first", "second"}, s.authenticated())
+}
+
+// closedAddr returns an address where nothing listens.
+func closedAddr(t *testing.T) string {
+	var l, err = net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	var addr = l.Addr().String()
+	l.Close()
+	return addr
+}
+
+type counter struct {
+	mu sync.Mutex
+	v  float64
+}
+
+func (c *counter) With(labelValues ...string) metrics.Counter { return c }
+func (c *counter) Add(delta float64) {
+	c.mu.Lock()
+	c.v += delta
+	c.mu.Unlock()
+}
+func (c *counter) value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.v
+}
+
+// fakeRedis is a minimal Redis server that replies PONG to PING and OK to the other commands.
+type fakeRedis struct {
+	addr string
+	l    net.Listener
+
+	mu        sync.Mutex
+	conns     []net.Conn
+	commands  []string
+	passwords []string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	return newFakeRedisAt(t, "127.0.0.1:0")
+}
+
+func newFakeRedisAt(t *testing.T, addr string) *fakeRedis {
+	var l, err = net.Listen("tcp", addr)
+	assert.Nil(t, err)
+
+	var s = &fakeRedis{addr: l.Addr().String(), l: l}
+	go func() {
+		for {
+			var conn, err = l.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	var r = bufio.NewReader(conn)
+	for {
+		var args, err = readCommand(r)
+		if err != nil {
+			return
+		}
+
+		var cmd = strings.ToUpper(args[0])
+		s.mu.Lock()
+		s.commands = append(s.commands, cmd)
+		if cmd == "AUTH" {
+			s.passwords = append(s.passwords, args[1])
+		}
+		s.mu.Unlock()
+
+		if cmd == "PING" {
+			conn.Write([]byte("+PONG\r\n"))
+		} else {
+			conn.Write([]byte("+OK\r\n"))
+		}
+	}
+}
+
+func (s *fakeRedis) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.commands...)
+}
+
+func (s *fakeRedis) authenticated() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.passwords...)
+}
+
+func (s *fakeRedis) close() {
+	s.l.Close()
+	s.mu.Lock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+}
+
+// readCommand reads a command encoded as a RESP array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	var line, err = r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	var n int
+	n, err = strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	var args = []string{}
+	for i := 0; i < n; i++ {
+		// Skip the bulk string length.
+		_, err = r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line, err = r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, strings.TrimSpace(line))
+	}
+	return args, nil
+}
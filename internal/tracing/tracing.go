@@ -0,0 +1,29 @@
+// Package tracing contains the tracing helpers shared by the flaki and health packages.
+package tracing
+
+import (
+	opentracing "github.com/opentracing/opentracing-go"
+	jaeger "github.com/uber/jaeger-client-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otelSpanContext is implemented by the span contexts of the OpenTelemetry bridge.
+type otelSpanContext interface {
+	TraceID() trace.TraceID
+	SpanID() trace.SpanID
+}
+
+// TraceIDs returns the trace and span IDs of the span. They are empty if the span is nil or
+// if it is neither a Jaeger nor an OpenTelemetry span.
+func TraceIDs(span opentracing.Span) (traceID, spanID string) {
+	if span == nil {
+		return "", ""
+	}
+	switch sc := span.Context().(type) {
+	case jaeger.SpanContext:
+		return sc.TraceID().String(), sc.SpanID().String()
+	case otelSpanContext:
+		return sc.TraceID().String(), sc.SpanID().String()
+	}
+	return "", ""
+}
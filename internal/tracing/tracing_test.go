@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"math/rand"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	jaeger "github.com/uber/jaeger-client-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanStub is a span that only has a context.
+type spanStub struct {
+	opentracing.Span
+	sc opentracing.SpanContext
+}
+
+func (s spanStub) Context() opentracing.SpanContext { return s.sc }
+
+// otelSpanContextStub is an OpenTelemetry span context, as returned by the bridge.
+type otelSpanContextStub struct {
+	trace.SpanContext
+}
+
+func (otelSpanContextStub) ForeachBaggageItem(handler func(k, v string) bool) {}
+
+func TestTraceIDs(t *testing.T) {
+	// No span.
+	var traceID, spanID = TraceIDs(nil)
+	assert.Equal(t, "", traceID)
+	assert.Equal(t, "", spanID)
+
+	// Jaeger span.
+	var sc = jaeger.NewSpanContext(jaeger.TraceID{Low: rand.Uint64()}, jaeger.SpanID(rand.Uint64()), 0, true, nil)
+	traceID, spanID = TraceIDs(spanStub{sc: sc})
+	assert.Equal(t, sc.TraceID().String(), traceID)
+	assert.Equal(t, sc.SpanID().String(), spanID)
+
+	// OpenTelemetry span.
+	var otelSC = otelSpanContextStub{trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}})}
+	traceID, spanID = TraceIDs(spanStub{sc: otelSC})
+	assert.Equal(t, otelSC.TraceID().String(), traceID)
+	assert.Equal(t, otelSC.SpanID().String(), spanID)
+
+	// Other span.
+	traceID, spanID = TraceIDs(spanStub{sc: opentracing.NoopTracer{}.StartSpan("op").Context()})
+	assert.Equal(t, "", traceID)
+	assert.Equal(t, "", spanID)
+}
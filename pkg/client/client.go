@@ -0,0 +1,139 @@
+// Package client is the Go client of the flaki service. The gRPC and HTTP clients implement the
+// same Client interface: the correlation ID and the span of the context are propagated to the
+// service, and the calls rejected because the service is unavailable or rate limited are retried
+// with an exponential backoff.
+package client
+
+//go:generate mockgen -destination=./mock/client.go -package=mock -mock_names=Client=Client github.com/cloudtrust/flaki-service/pkg/client Client
+//go:generate mockgen -destination=./mock/tracing.go -package=mock -mock_names=Tracer=Tracer,Span=Span,SpanContext=SpanContext github.com/opentracing/opentracing-go Tracer,Span,SpanContext
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	otag "github.com/opentracing/opentracing-go/ext"
+)
+
+const (
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = 100 * time.Millisecond
+
+	// correlationIDKey is the context key of the correlation ID, the same as in the flaki service.
+	correlationIDKey = "correlation_id"
+)
+
+// Client is the client of the flaki service.
+type Client interface {
+	NextID(ctx context.Context) (string, error)
+	NextValidID(ctx context.Context) (string, error)
+}
+
+// Option is an option of the gRPC and HTTP clients.
+type Option func(*options)
+
+type options struct {
+	maxRetries   int
+	retryBackoff time.Duration
+	httpClient   *http.Client
+}
+
+// MaxRetries sets the number of times a call is retried when the service is unavailable or
+// rate limited. The default is 3, 0 disables the retries.
+func MaxRetries(n int) Option {
+	return func(o *options) {
+		o.maxRetries = n
+	}
+}
+
+// RetryBackoff sets the wait before the first retry. It doubles after each retry. The default
+// is 100ms.
+func RetryBackoff(d time.Duration) Option {
+	return func(o *options) {
+		o.retryBackoff = d
+	}
+}
+
+// HTTPClient sets the client used by the HTTP flaki client. Its connections are reused by all
+// the calls. The default is http.DefaultClient.
+func HTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = c
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	var o = &options{
+		maxRetries:   defaultMaxRetries,
+		retryBackoff: defaultRetryBackoff,
+		httpClient:   http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithCorrelationID returns a context with the correlation ID, that is sent to the flaki service
+// with the calls made with this context.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, correlationID)
+}
+
+// correlationID returns the correlation ID of the context, or an empty string.
+func correlationID(ctx context.Context) string {
+	var id, _ = ctx.Value(correlationIDKey).(string)
+	return id
+}
+
+// retry executes the call until it succeeds, fails with an error that is not retryable, or the
+// retries are exhausted. The retries are recorded in the span, if any.
+func (o *options) retry(ctx context.Context, span opentracing.Span, call func() error, retryable func(error) bool) error {
+	var backoff = o.retryBackoff
+
+	for i := 0; ; i++ {
+		var err = call()
+		if err == nil || !retryable(err) || i >= o.maxRetries {
+			return err
+		}
+
+		if span != nil {
+			span.LogKV("event", "retry", "attempt", i+2, "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// startSpan starts the span of the call, child of the span of the context. Without span in the
+// context, the call is not traced and the returned span is nil.
+func startSpan(ctx context.Context, operationName, transport string) opentracing.Span {
+	var parent = opentracing.SpanFromContext(ctx)
+	if parent == nil {
+		return nil
+	}
+
+	var span = parent.Tracer().StartSpan(operationName, opentracing.ChildOf(parent.Context()))
+	otag.SpanKindRPCClient.Set(span)
+	span.SetTag("transport", transport)
+	return span
+}
+
+// finishSpan records the error, if any, and finishes the span.
+func finishSpan(span opentracing.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		otag.Error.Set(span, true)
+		span.LogKV("error", err.Error())
+	}
+	span.Finish()
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/flaki-service/pkg/client/mock"
+	"github.com/golang/mock/gomock"
+	flatbuffers "github.com/google/flatbuffers/go"
+	opentracing "github.com/opentracing/opentracing-go"
+	otag "github.com/opentracing/opentracing-go/ext"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGRPCClientTracing(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockFlakiClient = mock.NewFlakiClient(mockCtrl)
+	var mockTracer = mock.NewTracer(mockCtrl)
+	var mockParent = mock.NewSpan(mockCtrl)
+	var mockSpan = mock.NewSpan(mockCtrl)
+	var mockSpanContext = mock.NewSpanContext(mockCtrl)
+	mockParent.EXPECT().Tracer().Return(mockTracer).AnyTimes()
+	mockSpan.EXPECT().Tracer().Return(mockTracer).AnyTimes()
+
+	var ctx = opentracing.ContextWithSpan(context.Background(), mockParent)
+	var c = NewGRPCClient(mockFlakiClient)
+
+	rand.Seed(time.Now().UnixNano())
+	var flakiID = strconv.FormatUint(rand.Uint64(), 10)
+
+	// The span of the call is a child of the span of the context.
+	mockParent.EXPECT().Context().Return(mockSpanContext).Times(1)
+	mockTracer.EXPECT().StartSpan("flaki_client_nextid", gomock.Any()).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag(string(otag.SpanKind), otag.SpanKindRPCClientEnum).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("transport", "grpc").Return(mockSpan).Times(1)
+	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
+	mockTracer.EXPECT().Inject(mockSpanContext, opentracing.TextMap, gomock.Any()).Do(func(_ opentracing.SpanContext, _ interface{}, carrier interface{}) {
+		carrier.(opentracing.TextMapCarrier).Set("uber-trace-id", "trace")
+	}).Return(nil).Times(1)
+	mockSpan.EXPECT().Finish().Return().Times(1)
+
+	mockFlakiClient.EXPECT().NextID(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, _ *flatbuffers.Builder, _ ...grpc.CallOption) {
+		// The span context is injected in the metadata.
+		var md, _ = metadata.FromOutgoingContext(ctx)
+		assert.Equal(t, []string{"trace"}, md["uber-trace-id"])
+	}).Return(createFlakiReply(flakiID), nil).Times(1)
+
+	var _, err = c.NextID(ctx)
+	assert.Nil(t, err)
+}
+
+func TestHTTPClientTracing(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockTracer = mock.NewTracer(mockCtrl)
+	var mockParent = mock.NewSpan(mockCtrl)
+	var mockSpan = mock.NewSpan(mockCtrl)
+	var mockSpanContext = mock.NewSpanContext(mockCtrl)
+	mockParent.EXPECT().Tracer().Return(mockTracer).AnyTimes()
+	mockSpan.EXPECT().Tracer().Return(mockTracer).AnyTimes()
+
+	var ctx = opentracing.ContextWithSpan(context.Background(), mockParent)
+
+	var s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The span context is injected in the headers.
+		assert.Equal(t, "trace", r.Header.Get("Uber-Trace-Id"))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	var c = NewHTTPClient(s.URL)
+
+	// The failed call is tagged with error.
+	mockParent.EXPECT().Context().Return(mockSpanContext).Times(1)
+	mockTracer.EXPECT().StartSpan("flaki_client_nextvalidid", gomock.Any()).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag(string(otag.SpanKind), otag.SpanKindRPCClientEnum).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("transport", "http").Return(mockSpan).Times(1)
+	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
+	mockTracer.EXPECT().Inject(mockSpanContext, opentracing.HTTPHeaders, gomock.Any()).Do(func(_ opentracing.SpanContext, _ interface{}, carrier interface{}) {
+		carrier.(opentracing.HTTPHeadersCarrier).Set("Uber-Trace-Id", "trace")
+	}).Return(nil).Times(1)
+	mockSpan.EXPECT().SetTag("error", true).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().LogKV("error", gomock.Any()).Return().Times(1)
+	mockSpan.EXPECT().Finish().Return().Times(1)
+
+	var _, err = c.NextValidID(ctx)
+	assert.NotNil(t, err)
+}
+
+func TestRetryContextDone(t *testing.T) {
+	var o = newOptions(MaxRetries(5), RetryBackoff(time.Hour))
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	var calls = 0
+	var err = o.retry(ctx, nil, func() error {
+		calls++
+		cancel()
+		return fmt.Errorf("unavailable")
+	}, func(error) bool { return true })
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,104 @@
+package client
+
+//go:generate mockgen -destination=./mock/flaki.go -package=mock -mock_names=FlakiClient=FlakiClient github.com/cloudtrust/flaki-service/api/fb FlakiClient
+
+import (
+	"context"
+
+	"github.com/cloudtrust/flaki-service/api/fb"
+	flatbuffers "github.com/google/flatbuffers/go"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type grpcClient struct {
+	client  fb.FlakiClient
+	options *options
+}
+
+// NewGRPCClient returns a gRPC client of the flaki service. All the calls share the connection
+// of 'client', e.g.:
+//
+//	var conn, err = grpc.Dial("flaki:5555", grpc.WithInsecure(), grpc.WithCodec(flatbuffers.FlatbuffersCodec{}))
+//	var c = client.NewGRPCClient(fb.NewFlakiClient(conn))
+//
+// The calls failing with the codes Unavailable or ResourceExhausted are retried.
+func NewGRPCClient(client fb.FlakiClient, opts ...Option) Client {
+	return &grpcClient{
+		client:  client,
+		options: newOptions(opts...),
+	}
+}
+
+// NextID returns a unique ID.
+func (c *grpcClient) NextID(ctx context.Context) (string, error) {
+	var id, err = c.call(ctx, "flaki_client_nextid", c.client.NextID)
+	if err != nil {
+		return "", errors.Wrap(err, "could not get next ID")
+	}
+	return id, nil
+}
+
+// NextValidID returns a unique ID.
+func (c *grpcClient) NextValidID(ctx context.Context) (string, error) {
+	var id, err = c.call(ctx, "flaki_client_nextvalidid", c.client.NextValidID)
+	if err != nil {
+		return "", errors.Wrap(err, "could not get next valid ID")
+	}
+	return id, nil
+}
+
+type grpcMethod func(ctx context.Context, in *flatbuffers.Builder, opts ...grpc.CallOption) (*fb.FlakiReply, error)
+
+// call executes the method with the correlation ID and the span context in the metadata.
+func (c *grpcClient) call(ctx context.Context, operationName string, method grpcMethod) (string, error) {
+	var span = startSpan(ctx, operationName, "grpc")
+
+	var md = metadata.MD{}
+	if span != nil {
+		var carrier = make(opentracing.TextMapCarrier)
+		span.Tracer().Inject(span.Context(), opentracing.TextMap, carrier)
+		for k, v := range carrier {
+			md.Set(k, v)
+		}
+	}
+	if id := correlationID(ctx); id != "" {
+		md.Set("correlation_id", id)
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	var id string
+	var err = c.options.retry(ctx, span, func() error {
+		var reply, err = method(ctx, flakiRequest())
+		if err != nil {
+			return err
+		}
+		id = string(reply.Id())
+		return nil
+	}, isGRPCRetryable)
+
+	finishSpan(span, err)
+	return id, err
+}
+
+// isGRPCRetryable returns true if the service is unavailable or rate limited.
+func isGRPCRetryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
+// flakiRequest returns the flatbuffer flaki request, it is empty.
+func flakiRequest() *flatbuffers.Builder {
+	var b = flatbuffers.NewBuilder(0)
+	fb.FlakiRequestStart(b)
+	b.Finish(fb.FlakiRequestEnd(b))
+	return b
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/flaki-service/api/fb"
+	"github.com/cloudtrust/flaki-service/pkg/client/mock"
+	"github.com/golang/mock/gomock"
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCClient(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockFlakiClient = mock.NewFlakiClient(mockCtrl)
+
+	var c = NewGRPCClient(mockFlakiClient)
+
+	rand.Seed(time.Now().UnixNano())
+	var flakiID = strconv.FormatUint(rand.Uint64(), 10)
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+	var ctx = WithCorrelationID(context.Background(), corrID)
+
+	var checkMetadata = func(ctx context.Context, _ *flatbuffers.Builder, _ ...grpc.CallOption) {
+		var md, ok = metadata.FromOutgoingContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, []string{corrID}, md["correlation_id"])
+	}
+
+	// NextID.
+	{
+		mockFlakiClient.EXPECT().NextID(gomock.Any(), gomock.Any()).Do(checkMetadata).Return(createFlakiReply(flakiID), nil).Times(1)
+		var id, err = c.NextID(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, flakiID, id)
+	}
+
+	// NextValidID.
+	{
+		mockFlakiClient.EXPECT().NextValidID(gomock.Any(), gomock.Any()).Do(checkMetadata).Return(createFlakiReply(flakiID), nil).Times(1)
+		var id, err = c.NextValidID(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, flakiID, id)
+	}
+}
+
+func TestGRPCClientRetry(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockFlakiClient = mock.NewFlakiClient(mockCtrl)
+
+	var c = NewGRPCClient(mockFlakiClient, MaxRetries(2), RetryBackoff(time.Millisecond))
+
+	rand.Seed(time.Now().UnixNano())
+	var flakiID = strconv.FormatUint(rand.Uint64(), 10)
+
+	// Retry on Unavailable and ResourceExhausted.
+	{
+		gomock.InOrder(
+			mockFlakiClient.EXPECT().NextID(gomock.Any(), gomock.Any()).Return(nil, status.Error(codes.Unavailable, "unavailable")).Times(1),
+			mockFlakiClient.EXPECT().NextID(gomock.Any(), gomock.Any()).Return(nil, status.Error(codes.ResourceExhausted, "rate limited")).Times(1),
+			mockFlakiClient.EXPECT().NextID(gomock.Any(), gomock.Any()).Return(createFlakiReply(flakiID), nil).Times(1),
+		)
+		var id, err = c.NextID(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, flakiID, id)
+	}
+
+	// Retries exhausted.
+	{
+		mockFlakiClient.EXPECT().NextValidID(gomock.Any(), gomock.Any()).Return(nil, status.Error(codes.Unavailable, "unavailable")).Times(3)
+		var id, err = c.NextValidID(context.Background())
+		assert.NotNil(t, err)
+		assert.Equal(t, codes.Unavailable, status.Code(errors.Cause(err)))
+		assert.Zero(t, id)
+	}
+
+	// No retry on other errors.
+	{
+		mockFlakiClient.EXPECT().NextID(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("fail")).Times(1)
+		var id, err = c.NextID(context.Background())
+		assert.NotNil(t, err)
+		assert.Zero(t, id)
+	}
+}
+
+func createFlakiReply(id string) *fb.FlakiReply {
+	var b = flatbuffers.NewBuilder(0)
+	var str = b.CreateString(id)
+
+	fb.FlakiReplyStart(b)
+	fb.FlakiReplyAddId(b, str)
+	b.Finish(fb.FlakiReplyEnd(b))
+
+	return fb.GetRootAsFlakiReply(b.FinishedBytes(), 0)
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/cloudtrust/flaki-service/api/fb"
+	flatbuffers "github.com/google/flatbuffers/go"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidReply is returned by the HTTP client when the reply of the flaki service is not a
+// flatbuffer flaki reply.
+var ErrInvalidReply = fmt.Errorf("invalid flaki reply")
+
+// HTTPError is returned by the HTTP client when the flaki service replies with an error status.
+type HTTPError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("flaki service replied with status %d: %s", e.StatusCode, e.Message)
+}
+
+type httpClient struct {
+	url     string
+	options *options
+}
+
+// NewHTTPClient returns a HTTP client of the flaki service listening at 'url', e.g.
+// "http://flaki:8888". The calls failing with the status 429 (Too Many Requests) or 503
+// (Service Unavailable), or that could not reach the service, are retried.
+func NewHTTPClient(url string, opts ...Option) Client {
+	return &httpClient{
+		url:     strings.TrimSuffix(url, "/"),
+		options: newOptions(opts...),
+	}
+}
+
+// NextID returns a unique ID.
+func (c *httpClient) NextID(ctx context.Context) (string, error) {
+	var id, err = c.call(ctx, "flaki_client_nextid", "/nextid")
+	if err != nil {
+		return "", errors.Wrap(err, "could not get next ID")
+	}
+	return id, nil
+}
+
+// NextValidID returns a unique ID.
+func (c *httpClient) NextValidID(ctx context.Context) (string, error) {
+	var id, err = c.call(ctx, "flaki_client_nextvalidid", "/nextvalidid")
+	if err != nil {
+		return "", errors.Wrap(err, "could not get next valid ID")
+	}
+	return id, nil
+}
+
+// call posts the flaki request to the route, with the correlation ID and the span context in
+// the headers.
+func (c *httpClient) call(ctx context.Context, operationName, route string) (string, error) {
+	var span = startSpan(ctx, operationName, "http")
+	var body = flakiRequest().FinishedBytes()
+
+	var id string
+	var err = c.options.retry(ctx, span, func() error {
+		var req, err = http.NewRequest("POST", c.url+route, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Content-Type", "application/octet-stream")
+		if span != nil {
+			span.Tracer().Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+		}
+		if corrID := correlationID(ctx); corrID != "" {
+			req.Header.Set("X-Correlation-ID", corrID)
+		}
+
+		var resp *http.Response
+		resp, err = c.options.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		// The body is read entirely, so the connection is reused.
+		defer resp.Body.Close()
+
+		var data []byte
+		data, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "could not read reply")
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return &HTTPError{StatusCode: resp.StatusCode, Message: string(data)}
+		}
+		id, err = decodeReply(data)
+		return err
+	}, func(err error) bool {
+		return isHTTPRetryable(ctx, err)
+	})
+
+	finishSpan(span, err)
+	return id, err
+}
+
+// decodeReply returns the ID of the flatbuffer flaki reply. The flatbuffers are read without
+// bounds checks, so a reply that is not a flaki reply, e.g. the HTML page of a proxy, makes the
+// decoding panic. The panic is turned into ErrInvalidReply.
+func decodeReply(data []byte) (id string, err error) {
+	if len(data) < flatbuffers.SizeUOffsetT {
+		return "", ErrInvalidReply
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			id, err = "", ErrInvalidReply
+		}
+	}()
+	return string(fb.GetRootAsFlakiReply(data, 0).Id()), nil
+}
+
+// isHTTPRetryable returns true if the service is unavailable, rate limited or could not be
+// reached. The calls are not retried once the context is done, nor if the reply is invalid.
+func isHTTPRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || err == ErrInvalidReply {
+		return false
+	}
+
+	switch e := err.(type) {
+	case *HTTPError:
+		return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusServiceUnavailable
+	default:
+		// The request could not be sent.
+		return true
+	}
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/flaki-service/api/fb"
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPClient(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	var flakiID = strconv.FormatUint(rand.Uint64(), 10)
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+
+	var routes = []string{}
+	var s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		routes = append(routes, r.URL.Path)
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "application/octet-stream", r.Header.Get("Content-Type"))
+		assert.Equal(t, corrID, r.Header.Get("X-Correlation-ID"))
+
+		// Decode request.
+		var body, err = ioutil.ReadAll(r.Body)
+		assert.Nil(t, err)
+		assert.NotNil(t, fb.GetRootAsFlakiRequest(body, 0))
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(flakiReply(flakiID))
+	}))
+	defer s.Close()
+
+	var c = NewHTTPClient(s.URL + "/")
+	var ctx = WithCorrelationID(context.Background(), corrID)
+
+	// NextID.
+	{
+		var id, err = c.NextID(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, flakiID, id)
+	}
+
+	// NextValidID.
+	{
+		var id, err = c.NextValidID(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, flakiID, id)
+	}
+	assert.Equal(t, []string{"/nextid", "/nextvalidid"}, routes)
+}
+
+func TestHTTPClientRetry(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	var flakiID = strconv.FormatUint(rand.Uint64(), 10)
+
+	var calls int32
+	var statuses []int
+	var s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var i = atomic.AddInt32(&calls, 1) - 1
+		if int(i) < len(statuses) && statuses[i] != http.StatusOK {
+			http.Error(w, http.StatusText(statuses[i]), statuses[i])
+			return
+		}
+		w.Write(flakiReply(flakiID))
+	}))
+	defer s.Close()
+
+	var c = NewHTTPClient(s.URL, MaxRetries(2), RetryBackoff(time.Millisecond))
+
+	// Retry on 429 and 503.
+	{
+		calls, statuses = 0, []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusOK}
+		var id, err = c.NextID(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, flakiID, id)
+		assert.Equal(t, int32(3), calls)
+	}
+
+	// Retries exhausted.
+	{
+		calls, statuses = 0, []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests}
+		var id, err = c.NextValidID(context.Background())
+		assert.NotNil(t, err)
+		assert.Zero(t, id)
+		assert.Equal(t, int32(3), calls)
+		var httpErr, ok = errors.Cause(err).(*HTTPError)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusTooManyRequests, httpErr.StatusCode)
+	}
+
+	// No retry on other errors.
+	{
+		calls, statuses = 0, []int{http.StatusInternalServerError}
+		var id, err = c.NextID(context.Background())
+		assert.NotNil(t, err)
+		assert.Zero(t, id)
+		assert.Equal(t, int32(1), calls)
+	}
+}
+
+func flakiReply(id string) []byte {
+	var b = flatbuffers.NewBuilder(0)
+	var str = b.CreateString(id)
+
+	fb.FlakiReplyStart(b)
+	fb.FlakiReplyAddId(b, str)
+	b.Finish(fb.FlakiReplyEnd(b))
+
+	return b.FinishedBytes()
+}
+
+func TestHTTPClientInvalidReply(t *testing.T) {
+	var calls int32
+	var reply string
+	var s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		fmt.Fprint(w, reply)
+	}))
+	defer s.Close()
+
+	var c = NewHTTPClient(s.URL, MaxRetries(2), RetryBackoff(time.Millisecond))
+
+	// The invalid replies, empty or not a flatbuffer, are not retried.
+	for _, reply = range []string{"", "<html><body>Bad gateway</body></html>"} {
+		atomic.StoreInt32(&calls, 0)
+		var _, err = c.NextID(context.Background())
+		assert.Equal(t, ErrInvalidReply, errors.Cause(err), reply)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls), reply)
+	}
+}
+
+func TestDecodeReply(t *testing.T) {
+	var id, err = decodeReply(flakiReply("1234"))
+	assert.Nil(t, err)
+	assert.Equal(t, "1234", id)
+
+	for _, data := range [][]byte{nil, {1, 2}, []byte("<html>"), {0xff, 0xff, 0xff, 0x7f, 0, 0, 0, 0}} {
+		id, err = decodeReply(data)
+		assert.Equal(t, ErrInvalidReply, err, string(data))
+		assert.Zero(t, id)
+	}
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/cloudtrust/flaki-service/api/fb"
 	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/ratelimit"
+	"github.com/pkg/errors"
 )
 
 // Endpoints wraps a service behind a set of endpoints.
@@ -45,3 +47,41 @@ func MakeNextValidIDEndpoint(c IDGeneratorComponent) endpoint.Endpoint {
 		}
 	}
 }
+
+// The outcomes of the endpoint calls, and the classes of their errors.
+const (
+	outcomeSuccess = "success"
+	outcomeFailure = "failure"
+
+	errorClassRateLimited = "rate_limited"
+	errorClassCanceled    = "canceled"
+	errorClassTimeout     = "timeout"
+	errorClassGenerator   = "generator"
+)
+
+// errorClass returns the class of the error returned by an endpoint. The errors that are not
+// raised by the rate limiter or the context come from the flaki generator.
+func errorClass(err error) string {
+	switch errors.Cause(err) {
+	case ratelimit.ErrLimited:
+		return errorClassRateLimited
+	case context.Canceled:
+		return errorClassCanceled
+	case context.DeadlineExceeded:
+		return errorClassTimeout
+	default:
+		return errorClassGenerator
+	}
+}
+
+// endpointCorrelationID returns the correlation ID of the context. If there is none, the ID
+// of the reply is used, if any.
+func endpointCorrelationID(ctx context.Context, reply interface{}) string {
+	if corrID, ok := ctx.Value("correlation_id").(string); ok {
+		return corrID
+	}
+	if rep, ok := reply.(*fb.FlakiReply); ok && rep != nil {
+		return string(rep.Id())
+	}
+	return ""
+}
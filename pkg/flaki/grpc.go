@@ -7,10 +7,13 @@ import (
 
 	"github.com/cloudtrust/flaki-service/api/fb"
 	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/ratelimit"
 	grpc_transport "github.com/go-kit/kit/transport/grpc"
 	"github.com/google/flatbuffers/go"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type grpcServer struct {
@@ -65,7 +68,7 @@ func fetchGRPCCorrelationID(ctx context.Context, md metadata.MD) context.Context
 func (s *grpcServer) NextID(ctx context.Context, req *fb.FlakiRequest) (*flatbuffers.Builder, error) {
 	var _, rep, err = s.nextID.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, errors.Wrap(err, "grpc server could not return next ID")
+		return nil, grpcError(err, "grpc server could not return next ID")
 	}
 
 	var reply = rep.(*fb.FlakiReply)
@@ -84,7 +87,7 @@ func (s *grpcServer) NextID(ctx context.Context, req *fb.FlakiRequest) (*flatbuf
 func (s *grpcServer) NextValidID(ctx context.Context, req *fb.FlakiRequest) (*flatbuffers.Builder, error) {
 	var _, rep, err = s.nextValidID.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, errors.Wrap(err, "grpc server could not return next valid ID")
+		return nil, grpcError(err, "grpc server could not return next valid ID")
 	}
 
 	var reply = rep.(*fb.FlakiReply)
@@ -99,6 +102,15 @@ func (s *grpcServer) NextValidID(ctx context.Context, req *fb.FlakiRequest) (*fl
 	return b, nil
 }
 
+// grpcError wraps the error of the endpoint. The requests rejected by the rate limiter fail with
+// the code ResourceExhausted, so the clients can retry them.
+func grpcError(err error, msg string) error {
+	if errors.Cause(err) == ratelimit.ErrLimited {
+		return status.Error(codes.ResourceExhausted, errors.Wrap(err, msg).Error())
+	}
+	return errors.Wrap(err, msg)
+}
+
 // decodeGRPCRequest decodes the flatbuffer flaki request.
 func decodeGRPCRequest(_ context.Context, req interface{}) (interface{}, error) {
 	return req, nil
@@ -10,9 +10,13 @@ import (
 
 	"github.com/cloudtrust/flaki-service/api/fb"
 	"github.com/cloudtrust/flaki-service/pkg/flaki/mock"
+	"github.com/go-kit/kit/ratelimit"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func TestNewGRPCServer(t *testing.T) {
@@ -61,6 +65,28 @@ func TestGRPCErrorHandler(t *testing.T) {
 	var reply, err = s.NextID(context.Background(), req)
 	assert.NotNil(t, err)
 	assert.Nil(t, reply)
+	assert.Equal(t, codes.Unknown, status.Code(err))
+}
+
+func TestGRPCRateLimitError(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockComponent = mock.NewIDGeneratorComponent(mockCtrl)
+
+	// Rate limiters that reject all the requests.
+	var limit = ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Hour), 0))
+	var s = NewGRPCServer(MakeGRPCNextIDHandler(limit(MakeNextIDEndpoint(mockComponent))), MakeGRPCNextValidIDHandler(limit(MakeNextValidIDEndpoint(mockComponent))))
+
+	var req = createFlakiRequest()
+
+	// The rejected requests fail with the code ResourceExhausted.
+	var reply, err = s.NextID(context.Background(), req)
+	assert.Nil(t, reply)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	reply, err = s.NextValidID(context.Background(), req)
+	assert.Nil(t, reply)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
 }
 
 func TestFetchGRPCCorrelationID(t *testing.T) {
@@ -0,0 +1,54 @@
+package flaki
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Layout of the IDs generated by the Flaki generator, from the most significant bits: the unused
+// sign bit, the milliseconds since the epoch, the node ID, the component ID and the sequence number.
+const (
+	timestampBits   = 41
+	nodeIDBits      = 5
+	componentIDBits = 2
+	sequenceBits    = 15
+
+	componentIDShift = sequenceBits
+	nodeIDShift      = sequenceBits + componentIDBits
+	timestampShift   = sequenceBits + componentIDBits + nodeIDBits
+)
+
+// Largest node and component IDs accepted by the Flaki generator.
+const (
+	MaxNodeID      = 1<<nodeIDBits - 1
+	MaxComponentID = 1<<componentIDBits - 1
+)
+
+// Epoch is the default epoch of the Flaki generator.
+var Epoch = time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ID is a decoded Flaki ID.
+type ID struct {
+	Time        time.Time `json:"time"`
+	Sequence    uint64    `json:"sequence"`
+	NodeID      uint64    `json:"node_id"`
+	ComponentID uint64    `json:"component_id"`
+}
+
+// DecodeID decodes an ID returned by the NextID and NextValidID endpoints, i.e. a decimal string.
+func DecodeID(s string) (ID, error) {
+	var id, err = strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return ID{}, errors.Wrapf(err, "invalid flaki ID '%s'", s)
+	}
+
+	var ms = (id >> timestampShift) & (1<<timestampBits - 1)
+	return ID{
+		Time:        Epoch.Add(time.Duration(ms) * time.Millisecond),
+		Sequence:    id & (1<<sequenceBits - 1),
+		NodeID:      (id >> nodeIDShift) & (1<<nodeIDBits - 1),
+		ComponentID: (id >> componentIDShift) & (1<<componentIDBits - 1),
+	}, nil
+}
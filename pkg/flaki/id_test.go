@@ -0,0 +1,71 @@
+package flaki
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	flaki_gen "github.com/cloudtrust/flaki"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeID(t *testing.T) {
+	var id = uint64(1500)<<timestampShift | uint64(17)<<nodeIDShift | uint64(2)<<componentIDShift | uint64(7)
+
+	var decoded, err = DecodeID(strconv.FormatUint(id, 10))
+	assert.Nil(t, err)
+	assert.Equal(t, ID{
+		Time:        Epoch.Add(1500 * time.Millisecond),
+		Sequence:    7,
+		NodeID:      17,
+		ComponentID: 2,
+	}, decoded)
+
+	// Largest values of each field.
+	id = uint64(1<<timestampBits-1)<<timestampShift | uint64(MaxNodeID)<<nodeIDShift | uint64(MaxComponentID)<<componentIDShift | uint64(1<<sequenceBits-1)
+	decoded, err = DecodeID(strconv.FormatUint(id, 10))
+	assert.Nil(t, err)
+	assert.Equal(t, ID{
+		Time:        Epoch.Add(time.Duration(1<<timestampBits-1) * time.Millisecond),
+		Sequence:    1<<sequenceBits - 1,
+		NodeID:      MaxNodeID,
+		ComponentID: MaxComponentID,
+	}, decoded)
+
+	// Invalid IDs.
+	_, err = DecodeID("flaki")
+	assert.NotNil(t, err)
+	_, err = DecodeID("-1")
+	assert.NotNil(t, err)
+}
+
+func TestDecodeGeneratedID(t *testing.T) {
+	// The IDs generated by the Flaki generator are decoded with their node and component IDs, and
+	// their generation time.
+	var gen, err = flaki_gen.New(flaki_gen.ComponentID(1), flaki_gen.NodeID(19))
+	assert.Nil(t, err)
+
+	var first, second ID
+	first, err = DecodeID(gen.NextValidIDString())
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), first.ComponentID)
+	assert.Equal(t, uint64(19), first.NodeID)
+	assert.WithinDuration(t, time.Now(), first.Time, time.Minute)
+
+	// The IDs generated in the same millisecond differ by their sequence number.
+	second, err = DecodeID(gen.NextValidIDString())
+	assert.Nil(t, err)
+	if second.Time.Equal(first.Time) {
+		assert.Equal(t, first.Sequence+1, second.Sequence)
+	}
+}
+
+func TestMaxIDs(t *testing.T) {
+	// The generator accepts the largest node and component IDs, and rejects the next ones.
+	var _, err = flaki_gen.New(flaki_gen.ComponentID(MaxComponentID), flaki_gen.NodeID(MaxNodeID))
+	assert.Nil(t, err)
+	_, err = flaki_gen.New(flaki_gen.ComponentID(MaxComponentID + 1))
+	assert.NotNil(t, err)
+	_, err = flaki_gen.New(flaki_gen.NodeID(MaxNodeID + 1))
+	assert.NotNil(t, err)
+}
@@ -11,8 +11,9 @@ import (
 	"github.com/go-kit/kit/metrics"
 )
 
-// MakeEndpointInstrumentingMW makes an Instrumenting middleware at endpoint level.
-func MakeEndpointInstrumentingMW(h metrics.Histogram) endpoint.Middleware {
+// MakeEndpointInstrumentingMW makes an Instrumenting middleware at endpoint level. The durations
+// are tagged with the outcome of the calls, and the failed calls are counted by error class.
+func MakeEndpointInstrumentingMW(h metrics.Histogram, errCounter metrics.Counter) endpoint.Middleware {
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
 			var begin = time.Now()
@@ -20,16 +21,15 @@ func MakeEndpointInstrumentingMW(h metrics.Histogram) endpoint.Middleware {
 			var duration = time.Since(begin)
 
 			// If there is no correlation ID, use the newly generated ID.
-			var corrID = ctx.Value("correlation_id")
-			if corrID == nil {
-				if rep := reply.(*fb.FlakiReply); rep != nil {
-					corrID = string(rep.Id())
-				} else {
-					corrID = ""
-				}
+			var corrID = endpointCorrelationID(ctx, reply)
+
+			var outcome = outcomeSuccess
+			if err != nil {
+				outcome = outcomeFailure
+				errCounter.With("correlation_id", corrID, "error_class", errorClass(err)).Add(1)
 			}
 
-			h.With("correlation_id", corrID.(string)).Observe(duration.Seconds())
+			h.With("correlation_id", corrID, "outcome", outcome).Observe(duration.Seconds())
 			return reply, err
 		}
 	}
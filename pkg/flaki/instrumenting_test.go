@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/cloudtrust/flaki-service/pkg/flaki/mock"
+	"github.com/go-kit/kit/ratelimit"
 	"github.com/golang/mock/gomock"
 )
 
@@ -17,8 +18,9 @@ func TestEndpointInstrumentingMW(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockComponent = mock.NewIDGeneratorComponent(mockCtrl)
 	var mockHistogram = mock.NewHistogram(mockCtrl)
+	var mockCounter = mock.NewCounter(mockCtrl)
 
-	var m = MakeEndpointInstrumentingMW(mockHistogram)(MakeNextIDEndpoint(mockComponent))
+	var m = MakeEndpointInstrumentingMW(mockHistogram, mockCounter)(MakeNextIDEndpoint(mockComponent))
 
 	rand.Seed(time.Now().UnixNano())
 	var flakiID = strconv.FormatUint(rand.Uint64(), 10)
@@ -29,27 +31,41 @@ func TestEndpointInstrumentingMW(t *testing.T) {
 
 	// NextID.
 	mockComponent.EXPECT().NextID(ctx, req).Return(reply, nil).Times(1)
-	mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+	mockHistogram.EXPECT().With("correlation_id", corrID, "outcome", "success").Return(mockHistogram).Times(1)
 	mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
 	m(ctx, req)
 
 	// NextID error.
 	mockComponent.EXPECT().NextID(ctx, req).Return(nil, fmt.Errorf("fail")).Times(1)
-	mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+	mockCounter.EXPECT().With("correlation_id", corrID, "error_class", "generator").Return(mockCounter).Times(1)
+	mockCounter.EXPECT().Add(1.0).Return().Times(1)
+	mockHistogram.EXPECT().With("correlation_id", corrID, "outcome", "failure").Return(mockHistogram).Times(1)
 	mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
 	m(ctx, req)
 
 	// NextID without correlation ID.
 	mockComponent.EXPECT().NextID(context.Background(), req).Return(reply, nil).Times(1)
-	mockHistogram.EXPECT().With("correlation_id", flakiID).Return(mockHistogram).Times(1)
+	mockHistogram.EXPECT().With("correlation_id", flakiID, "outcome", "success").Return(mockHistogram).Times(1)
 	mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
 	m(context.Background(), req)
 
 	// NextID error without correlation ID.
 	mockComponent.EXPECT().NextID(context.Background(), req).Return(nil, fmt.Errorf("fail")).Times(1)
-	mockHistogram.EXPECT().With("correlation_id", "").Return(mockHistogram).Times(1)
+	mockCounter.EXPECT().With("correlation_id", "", "error_class", "generator").Return(mockCounter).Times(1)
+	mockCounter.EXPECT().Add(1.0).Return().Times(1)
+	mockHistogram.EXPECT().With("correlation_id", "", "outcome", "failure").Return(mockHistogram).Times(1)
 	mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
 	m(context.Background(), req)
+
+	// Rate limited, without reply.
+	var limited = MakeEndpointInstrumentingMW(mockHistogram, mockCounter)(func(context.Context, interface{}) (interface{}, error) {
+		return nil, ratelimit.ErrLimited
+	})
+	mockCounter.EXPECT().With("correlation_id", "", "error_class", "rate_limited").Return(mockCounter).Times(1)
+	mockCounter.EXPECT().Add(1.0).Return().Times(1)
+	mockHistogram.EXPECT().With("correlation_id", "", "outcome", "failure").Return(mockHistogram).Times(1)
+	mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+	limited(context.Background(), req)
 }
 
 func TestComponentInstrumentingMW(t *testing.T) {
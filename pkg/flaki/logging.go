@@ -4,14 +4,24 @@ package flaki
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"github.com/cloudtrust/flaki-service/api/fb"
+	"github.com/cloudtrust/flaki-service/internal/tracing"
 	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	opentracing "github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
-// MakeEndpointLoggingMW makes a logging middleware.
+// MakeEndpointLoggingMW makes a logging middleware. The requests are logged at info level with
+// their outcome, the failed ones at error level with the class of the error. The logs have the
+// IDs of the active span, if any.
 func MakeEndpointLoggingMW(logger log.Logger) endpoint.Middleware {
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
@@ -19,17 +29,17 @@ func MakeEndpointLoggingMW(logger log.Logger) endpoint.Middleware {
 			var reply, err = next(ctx, req)
 			var duration = time.Since(begin)
 
-			// If there is no correlation ID, use the newly generated ID.
-			var corrID = ctx.Value("correlation_id")
-			if corrID == nil {
-				if rep := reply.(*fb.FlakiReply); rep != nil {
-					corrID = string(rep.Id())
-				} else {
-					corrID = ""
-				}
-			}
+			// If there is no correlation ID, use the newly generated ID. It is also recorded
+			// in the access log.
+			var corrID = endpointCorrelationID(ctx, reply)
+			recordAccessLogCorrelationID(ctx, corrID)
 
-			logger.Log("correlation_id", corrID.(string), "took", duration)
+			var traceID, spanID = tracing.TraceIDs(opentracing.SpanFromContext(ctx))
+			if err != nil {
+				level.Error(logger).Log("correlation_id", corrID, "trace_id", traceID, "span_id", spanID, "took", duration, "outcome", outcomeFailure, "error_class", errorClass(err), "error", err.Error())
+			} else {
+				level.Info(logger).Log("correlation_id", corrID, "trace_id", traceID, "span_id", spanID, "took", duration, "outcome", outcomeSuccess)
+			}
 			return reply, err
 		}
 	}
@@ -41,7 +51,8 @@ type componentLoggingMW struct {
 	next   IDGeneratorComponent
 }
 
-// MakeComponentLoggingMW makes a logging middleware at component level.
+// MakeComponentLoggingMW makes a logging middleware at component level. The calls are logged
+// at debug level, the failed ones at error level.
 func MakeComponentLoggingMW(logger log.Logger) func(IDGeneratorComponent) IDGeneratorComponent {
 	return func(next IDGeneratorComponent) IDGeneratorComponent {
 		return &componentLoggingMW{
@@ -67,7 +78,12 @@ func (m *componentLoggingMW) NextID(ctx context.Context, req *fb.FlakiRequest) (
 		}
 	}
 
-	m.logger.Log("unit", "NextID", "correlation_id", corrID.(string), "took", duration)
+	var traceID, spanID = tracing.TraceIDs(opentracing.SpanFromContext(ctx))
+	if err != nil {
+		level.Error(m.logger).Log("unit", "NextID", "correlation_id", corrID.(string), "trace_id", traceID, "span_id", spanID, "took", duration, "error", err.Error())
+	} else {
+		level.Debug(m.logger).Log("unit", "NextID", "correlation_id", corrID.(string), "trace_id", traceID, "span_id", spanID, "took", duration)
+	}
 
 	return reply, err
 }
@@ -84,7 +100,8 @@ func (m *componentLoggingMW) NextValidID(ctx context.Context, req *fb.FlakiReque
 		corrID = string(reply.Id())
 	}
 
-	m.logger.Log("unit", "NextValidID", "correlation_id", corrID.(string), "took", duration)
+	var traceID, spanID = tracing.TraceIDs(opentracing.SpanFromContext(ctx))
+	level.Debug(m.logger).Log("unit", "NextValidID", "correlation_id", corrID.(string), "trace_id", traceID, "span_id", spanID, "took", duration)
 
 	return reply
 }
@@ -95,7 +112,8 @@ type moduleLoggingMW struct {
 	next   IDGeneratorModule
 }
 
-// MakeModuleLoggingMW makes a logging middleware at module level.
+// MakeModuleLoggingMW makes a logging middleware at module level. The calls are logged at
+// debug level, the failed ones at error level.
 func MakeModuleLoggingMW(logger log.Logger) func(IDGeneratorModule) IDGeneratorModule {
 	return func(next IDGeneratorModule) IDGeneratorModule {
 		return &moduleLoggingMW{
@@ -117,7 +135,12 @@ func (m *moduleLoggingMW) NextID(ctx context.Context) (string, error) {
 		corrID = id
 	}
 
-	m.logger.Log("unit", "NextID", "correlation_id", corrID.(string), "took", duration)
+	var traceID, spanID = tracing.TraceIDs(opentracing.SpanFromContext(ctx))
+	if err != nil {
+		level.Error(m.logger).Log("unit", "NextID", "correlation_id", corrID.(string), "trace_id", traceID, "span_id", spanID, "took", duration, "error", err.Error())
+	} else {
+		level.Debug(m.logger).Log("unit", "NextID", "correlation_id", corrID.(string), "trace_id", traceID, "span_id", spanID, "took", duration)
+	}
 
 	return id, err
 }
@@ -134,7 +157,116 @@ func (m *moduleLoggingMW) NextValidID(ctx context.Context) string {
 		corrID = id
 	}
 
-	m.logger.Log("unit", "NextValidID", "correlation_id", corrID.(string), "took", duration)
+	var traceID, spanID = tracing.TraceIDs(opentracing.SpanFromContext(ctx))
+	level.Debug(m.logger).Log("unit", "NextValidID", "correlation_id", corrID.(string), "trace_id", traceID, "span_id", spanID, "took", duration)
 
 	return id
 }
+
+// accessLog is put in the context by the access log middlewares. The transport tracing
+// middlewares, that run after them, record the IDs of the span they start in it, and the
+// endpoint logging middlewares the correlation ID of the request.
+type accessLog struct {
+	traceID       string
+	spanID        string
+	correlationID string
+}
+
+type accessLogKey struct{}
+
+// recordAccessLogTrace records the trace and span IDs in the access log of the context, if any.
+func recordAccessLogTrace(ctx context.Context, traceID, spanID string) {
+	if l, ok := ctx.Value(accessLogKey{}).(*accessLog); ok {
+		l.traceID, l.spanID = traceID, spanID
+	}
+}
+
+// recordAccessLogCorrelationID records the correlation ID in the access log of the context, if any.
+func recordAccessLogCorrelationID(ctx context.Context, corrID string) {
+	if l, ok := ctx.Value(accessLogKey{}).(*accessLog); ok {
+		l.correlationID = corrID
+	}
+}
+
+// responseWriter keeps the status code and the size of the response.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	var n, err = w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// MakeHTTPAccessLogMW makes a middleware that logs each HTTP request, with its method, path, status
+// code, response size, user agent and remote address. The requests are logged at info level, those
+// that fail with a server error at error level.
+func MakeHTTPAccessLogMW(logger log.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var l = &accessLog{}
+			var rw = &responseWriter{ResponseWriter: w, status: http.StatusOK}
+
+			var begin = time.Now()
+			next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), accessLogKey{}, l)))
+			var duration = time.Since(begin)
+
+			// The correlation ID generated for the request, if it had none.
+			var corrID = r.Header.Get("X-Correlation-ID")
+			if l.correlationID != "" {
+				corrID = l.correlationID
+			}
+
+			var lvl = level.Info
+			if rw.status >= http.StatusInternalServerError {
+				lvl = level.Error
+			}
+			lvl(logger).Log("method", r.Method, "path", r.URL.Path, "status", rw.status, "size", rw.size,
+				"user_agent", r.UserAgent(), "remote_addr", r.RemoteAddr, "took", duration,
+				"correlation_id", corrID, "trace_id", l.traceID, "span_id", l.spanID)
+		})
+	}
+}
+
+// MakeGRPCAccessLogInterceptor makes a gRPC unary interceptor that logs each request, with its
+// method, status code and peer address. The requests are logged at info level, the failed ones
+// at error level.
+func MakeGRPCAccessLogInterceptor(logger log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var l = &accessLog{}
+
+		var begin = time.Now()
+		var reply, err = handler(context.WithValue(ctx, accessLogKey{}, l), req)
+		var duration = time.Since(begin)
+
+		var addr string
+		if p, ok := peer.FromContext(ctx); ok {
+			addr = p.Addr.String()
+		}
+
+		// The correlation ID generated for the request, if it had none.
+		var corrID string
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md["correlation_id"]) > 0 {
+			corrID = md["correlation_id"][0]
+		}
+		if l.correlationID != "" {
+			corrID = l.correlationID
+		}
+
+		var lvl = level.Info
+		if err != nil {
+			lvl = level.Error
+		}
+		lvl(logger).Log("method", info.FullMethod, "code", status.Code(err).String(), "peer", addr, "took", duration,
+			"correlation_id", corrID, "trace_id", l.traceID, "span_id", l.spanID)
+		return reply, err
+	}
+}
@@ -4,12 +4,25 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/cloudtrust/flaki-service/pkg/flaki/mock"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/ratelimit"
 	"github.com/golang/mock/gomock"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	jaeger "github.com/uber/jaeger-client-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 func TestEndpointLoggingMW(t *testing.T) {
@@ -29,23 +42,40 @@ func TestEndpointLoggingMW(t *testing.T) {
 
 	// NextID.
 	mockComponent.EXPECT().NextID(ctx, req).Return(reply, nil).Times(1)
-	mockLogger.EXPECT().Log("correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.InfoValue(), "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any(), "outcome", "success").Return(nil).Times(1)
 	m(ctx, req)
 
 	// NextID error.
 	mockComponent.EXPECT().NextID(ctx, req).Return(nil, fmt.Errorf("fail")).Times(1)
-	mockLogger.EXPECT().Log("correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any(), "outcome", "failure", "error_class", "generator", "error", "fail").Return(nil).Times(1)
 	m(ctx, req)
 
 	// NextID without correlation ID.
 	mockComponent.EXPECT().NextID(context.Background(), req).Return(reply, nil).Times(1)
-	mockLogger.EXPECT().Log("correlation_id", flakiID, "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.InfoValue(), "correlation_id", flakiID, "trace_id", "", "span_id", "", "took", gomock.Any(), "outcome", "success").Return(nil).Times(1)
 	m(context.Background(), req)
 
 	// NextID error without correlation ID.
 	mockComponent.EXPECT().NextID(context.Background(), req).Return(nil, fmt.Errorf("fail")).Times(1)
-	mockLogger.EXPECT().Log("correlation_id", "", "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "correlation_id", "", "trace_id", "", "span_id", "", "took", gomock.Any(), "outcome", "failure", "error_class", "generator", "error", "fail").Return(nil).Times(1)
 	m(context.Background(), req)
+
+	// NextID with span.
+	var mockSpan = mock.NewSpan(mockCtrl)
+	var sc = jaeger.NewSpanContext(jaeger.TraceID{Low: rand.Uint64()}, jaeger.SpanID(rand.Uint64()), 0, true, nil)
+	mockSpan.EXPECT().Tracer().Return(opentracing.NoopTracer{}).Times(1)
+	var spanCtx = opentracing.ContextWithSpan(ctx, mockSpan)
+	mockComponent.EXPECT().NextID(spanCtx, req).Return(reply, nil).Times(1)
+	mockSpan.EXPECT().Context().Return(sc).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.InfoValue(), "correlation_id", corrID, "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String(), "took", gomock.Any(), "outcome", "success").Return(nil).Times(1)
+	m(spanCtx, req)
+
+	// Rate limited, without reply.
+	var limited = MakeEndpointLoggingMW(mockLogger)(func(context.Context, interface{}) (interface{}, error) {
+		return nil, ratelimit.ErrLimited
+	})
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "correlation_id", "", "trace_id", "", "span_id", "", "took", gomock.Any(), "outcome", "failure", "error_class", "rate_limited", "error", ratelimit.ErrLimited.Error()).Return(nil).Times(1)
+	limited(context.Background(), req)
 }
 
 func TestComponentLoggingMW(t *testing.T) {
@@ -65,32 +95,32 @@ func TestComponentLoggingMW(t *testing.T) {
 
 	// NextID.
 	mockComponent.EXPECT().NextID(ctx, req).Return(reply, nil).Times(1)
-	mockLogger.EXPECT().Log("unit", "NextID", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "NextID", "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 	m.NextID(ctx, req)
 
 	// NextID error.
 	mockComponent.EXPECT().NextID(ctx, req).Return(nil, fmt.Errorf("fail")).Times(1)
-	mockLogger.EXPECT().Log("unit", "NextID", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "unit", "NextID", "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any(), "error", "fail").Return(nil).Times(1)
 	m.NextID(ctx, req)
 
 	// NextID without correlation ID.
 	mockComponent.EXPECT().NextID(context.Background(), req).Return(reply, nil).Times(1)
-	mockLogger.EXPECT().Log("unit", "NextID", "correlation_id", flakiID, "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "NextID", "correlation_id", flakiID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 	m.NextID(context.Background(), req)
 
 	// NextID error without correlation ID.
 	mockComponent.EXPECT().NextID(context.Background(), req).Return(nil, fmt.Errorf("fail")).Times(1)
-	mockLogger.EXPECT().Log("unit", "NextID", "correlation_id", "", "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "unit", "NextID", "correlation_id", "", "trace_id", "", "span_id", "", "took", gomock.Any(), "error", "fail").Return(nil).Times(1)
 	m.NextID(context.Background(), req)
 
 	// NextValidID.
 	mockComponent.EXPECT().NextValidID(ctx, req).Return(reply).Times(1)
-	mockLogger.EXPECT().Log("unit", "NextValidID", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "NextValidID", "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 	m.NextValidID(ctx, req)
 
 	// NextValidID without correlation ID.
 	mockComponent.EXPECT().NextValidID(context.Background(), req).Return(reply).Times(1)
-	mockLogger.EXPECT().Log("unit", "NextValidID", "correlation_id", flakiID, "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "NextValidID", "correlation_id", flakiID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 	m.NextValidID(context.Background(), req)
 }
 
@@ -109,31 +139,153 @@ func TestModuleLoggingMW(t *testing.T) {
 
 	// NextID.
 	mockModule.EXPECT().NextID(ctx).Return(flakiID, nil).Times(1)
-	mockLogger.EXPECT().Log("unit", "NextID", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "NextID", "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 	m.NextID(ctx)
 
 	// NextID error.
 	mockModule.EXPECT().NextID(ctx).Return("", fmt.Errorf("fail")).Times(1)
-	mockLogger.EXPECT().Log("unit", "NextID", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "unit", "NextID", "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any(), "error", "fail").Return(nil).Times(1)
 	m.NextID(ctx)
 
 	// NextID without correlation ID.
 	mockModule.EXPECT().NextID(context.Background()).Return(flakiID, nil).Times(1)
-	mockLogger.EXPECT().Log("unit", "NextID", "correlation_id", flakiID, "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "NextID", "correlation_id", flakiID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 	m.NextID(context.Background())
 
 	// NextID error without correlation ID.
 	mockModule.EXPECT().NextID(context.Background()).Return("", fmt.Errorf("fail")).Times(1)
-	mockLogger.EXPECT().Log("unit", "NextID", "correlation_id", "", "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "unit", "NextID", "correlation_id", "", "trace_id", "", "span_id", "", "took", gomock.Any(), "error", "fail").Return(nil).Times(1)
 	m.NextID(context.Background())
 
 	// NextValidID.
 	mockModule.EXPECT().NextValidID(ctx).Return(flakiID).Times(1)
-	mockLogger.EXPECT().Log("unit", "NextValidID", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "NextValidID", "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 	m.NextValidID(ctx)
 
 	// NextValidID without correlation ID.
 	mockModule.EXPECT().NextValidID(context.Background()).Return(flakiID).Times(1)
-	mockLogger.EXPECT().Log("unit", "NextValidID", "correlation_id", flakiID, "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "NextValidID", "correlation_id", flakiID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 	m.NextValidID(context.Background())
 }
+
+func TestHTTPAccessLogMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockLogger = mock.NewLogger(mockCtrl)
+
+	var sc = jaeger.NewSpanContext(jaeger.TraceID{Low: rand.Uint64()}, jaeger.SpanID(rand.Uint64()), 0, true, nil)
+	var statusCode = http.StatusOK
+	var m = MakeHTTPAccessLogMW(mockLogger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The transport tracing MW records the trace.
+		recordAccessLogTrace(r.Context(), sc.TraceID().String(), sc.SpanID().String())
+		w.WriteHeader(statusCode)
+		w.Write([]byte("reply"))
+	}))
+
+	rand.Seed(time.Now().UnixNano())
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+	var req = httptest.NewRequest("POST", "http://cloudtrust.io/nextid", nil)
+	req.Header.Set("X-Correlation-ID", corrID)
+	req.Header.Set("User-Agent", "flaki-client")
+
+	// Success.
+	mockLogger.EXPECT().Log(level.Key(), level.InfoValue(), "method", "POST", "path", "/nextid", "status", http.StatusOK, "size", 5,
+		"user_agent", "flaki-client", "remote_addr", req.RemoteAddr, "took", gomock.Any(),
+		"correlation_id", corrID, "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String()).Return(nil).Times(1)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Server error.
+	statusCode = http.StatusInternalServerError
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "method", "POST", "path", "/nextid", "status", http.StatusInternalServerError, "size", 5,
+		"user_agent", "flaki-client", "remote_addr", req.RemoteAddr, "took", gomock.Any(),
+		"correlation_id", corrID, "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String()).Return(nil).Times(1)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Without tracing.
+	m = MakeHTTPAccessLogMW(mockLogger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	mockLogger.EXPECT().Log(level.Key(), level.InfoValue(), "method", "POST", "path", "/nextid", "status", http.StatusOK, "size", 0,
+		"user_agent", "flaki-client", "remote_addr", req.RemoteAddr, "took", gomock.Any(),
+		"correlation_id", corrID, "trace_id", "", "span_id", "").Return(nil).Times(1)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Without correlation ID, the generated one is logged.
+	var flakiID = strconv.FormatUint(rand.Uint64(), 10)
+	m = MakeHTTPAccessLogMW(mockLogger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The endpoint logging MW records the correlation ID.
+		recordAccessLogCorrelationID(r.Context(), flakiID)
+	}))
+	req.Header.Del("X-Correlation-ID")
+	mockLogger.EXPECT().Log(level.Key(), level.InfoValue(), "method", "POST", "path", "/nextid", "status", http.StatusOK, "size", 0,
+		"user_agent", "flaki-client", "remote_addr", req.RemoteAddr, "took", gomock.Any(),
+		"correlation_id", flakiID, "trace_id", "", "span_id", "").Return(nil).Times(1)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestGRPCAccessLogInterceptor(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockLogger = mock.NewLogger(mockCtrl)
+
+	var sc = jaeger.NewSpanContext(jaeger.TraceID{Low: rand.Uint64()}, jaeger.SpanID(rand.Uint64()), 0, true, nil)
+	var err error
+	var handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+		// The transport tracing MW records the trace.
+		recordAccessLogTrace(ctx, sc.TraceID().String(), sc.SpanID().String())
+		return "reply", err
+	}
+	var info = &grpc.UnaryServerInfo{FullMethod: "/fb.Flaki/NextID"}
+	var m = MakeGRPCAccessLogInterceptor(mockLogger)
+
+	rand.Seed(time.Now().UnixNano())
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+	var addr = &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 1234}
+	var ctx = peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+	ctx = metadata.NewIncomingContext(ctx, metadata.New(map[string]string{"correlation_id": corrID}))
+
+	// Success.
+	mockLogger.EXPECT().Log(level.Key(), level.InfoValue(), "method", "/fb.Flaki/NextID", "code", "OK", "peer", "192.0.2.1:1234", "took", gomock.Any(),
+		"correlation_id", corrID, "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String()).Return(nil).Times(1)
+	m(ctx, "req", info, handler)
+
+	// Error.
+	err = status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "method", "/fb.Flaki/NextID", "code", "ResourceExhausted", "peer", "192.0.2.1:1234", "took", gomock.Any(),
+		"correlation_id", corrID, "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String()).Return(nil).Times(1)
+	m(ctx, "req", info, handler)
+
+	// Without peer, metadata and tracing.
+	mockLogger.EXPECT().Log(level.Key(), level.InfoValue(), "method", "/fb.Flaki/NextID", "code", "OK", "peer", "", "took", gomock.Any(),
+		"correlation_id", "", "trace_id", "", "span_id", "").Return(nil).Times(1)
+	m(context.Background(), "req", info, func(ctx context.Context, req interface{}) (interface{}, error) { return "reply", nil })
+
+	// Without correlation ID, the generated one is logged.
+	var flakiID = strconv.FormatUint(rand.Uint64(), 10)
+	mockLogger.EXPECT().Log(level.Key(), level.InfoValue(), "method", "/fb.Flaki/NextID", "code", "OK", "peer", "", "took", gomock.Any(),
+		"correlation_id", flakiID, "trace_id", "", "span_id", "").Return(nil).Times(1)
+	m(context.Background(), "req", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		// The endpoint logging MW records the correlation ID.
+		recordAccessLogCorrelationID(ctx, flakiID)
+		return "reply", nil
+	})
+}
+
+func TestEndpointLoggingMWRecordsCorrelationID(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockComponent = mock.NewIDGeneratorComponent(mockCtrl)
+	var mockLogger = mock.NewLogger(mockCtrl)
+
+	var m = MakeEndpointLoggingMW(mockLogger)(MakeNextIDEndpoint(mockComponent))
+
+	rand.Seed(time.Now().UnixNano())
+	var flakiID = strconv.FormatUint(rand.Uint64(), 10)
+	var l = &accessLog{}
+	var ctx = context.WithValue(context.Background(), accessLogKey{}, l)
+	var req = createFlakiRequest()
+
+	// Without correlation ID, the generated ID is recorded in the access log.
+	mockComponent.EXPECT().NextID(ctx, req).Return(createFlakiReply(flakiID), nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.InfoValue(), "correlation_id", flakiID, "trace_id", "", "span_id", "", "took", gomock.Any(), "outcome", "success").Return(nil).Times(1)
+	m(ctx, req)
+	assert.Equal(t, flakiID, l.correlationID)
+}
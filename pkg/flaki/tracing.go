@@ -7,15 +7,19 @@ import (
 	"net/http"
 
 	"github.com/cloudtrust/flaki-service/api/fb"
+	"github.com/cloudtrust/flaki-service/internal/tracing"
 	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/ratelimit"
 	grpc_transport "github.com/go-kit/kit/transport/grpc"
 	opentracing "github.com/opentracing/opentracing-go"
 	otag "github.com/opentracing/opentracing-go/ext"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 )
 
 // MakeHTTPTracingMW try to extract an existing span from the HTTP headers. It it exists, we
-// continue the span, if not we create a new one.
+// continue the span, if not we create a new one. The trace ID is returned in the header "X-Trace-ID".
 func MakeHTTPTracingMW(tracer opentracing.Tracer, componentName, operationName string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -34,6 +38,13 @@ func MakeHTTPTracingMW(tracer opentracing.Tracer, componentName, operationName s
 			span.SetTag("transport", "http")
 			otag.SpanKindRPCServer.Set(span)
 
+			// Return the trace ID to the caller.
+			var traceID, spanID = tracing.TraceIDs(span)
+			if traceID != "" {
+				w.Header().Set("X-Trace-ID", traceID)
+			}
+			recordAccessLogTrace(r.Context(), traceID, spanID)
+
 			next.ServeHTTP(w, r.WithContext(opentracing.ContextWithSpan(r.Context(), span)))
 		})
 	}
@@ -59,7 +70,8 @@ func MakeGRPCTracingMW(tracer opentracing.Tracer, componentName, operationName s
 }
 
 // ServeGRPC try to extract an existing span from the GRPC metadata. It it exists, we
-// continue the span, if not we create a new one.
+// continue the span, if not we create a new one. The trace ID is returned in the header
+// metadata "trace_id".
 func (m *grpcTracingMW) ServeGRPC(ctx context.Context, req interface{}) (context.Context, interface{}, error) {
 	var md, _ = metadata.FromIncomingContext(ctx)
 
@@ -83,6 +95,13 @@ func (m *grpcTracingMW) ServeGRPC(ctx context.Context, req interface{}) (context
 	span.SetTag("transport", "grpc")
 	otag.SpanKindRPCServer.Set(span)
 
+	// Return the trace ID to the caller.
+	var traceID, spanID = tracing.TraceIDs(span)
+	if traceID != "" {
+		grpc.SetHeader(ctx, metadata.Pairs("trace_id", traceID))
+	}
+	recordAccessLogTrace(ctx, traceID, spanID)
+
 	return m.next.ServeGRPC(opentracing.ContextWithSpan(ctx, span), req)
 }
 
@@ -97,16 +116,11 @@ func MakeEndpointTracingMW(tracer opentracing.Tracer, operationName string) endp
 				var reply, err = next(opentracing.ContextWithSpan(ctx, span), req)
 
 				// If there is no correlation ID, use the newly generated ID.
-				var corrID = ctx.Value("correlation_id")
-				if corrID == nil {
-					if rep := reply.(*fb.FlakiReply); rep != nil {
-						corrID = string(rep.Id())
-					} else {
-						corrID = ""
-					}
+				span.SetTag("correlation_id", endpointCorrelationID(ctx, reply))
+				if err != nil {
+					otag.Error.Set(span, true)
+					span.LogKV("error", err.Error())
 				}
-
-				span.SetTag("correlation_id", corrID.(string))
 				return reply, err
 			}
 			return next(ctx, req)
@@ -114,6 +128,22 @@ func MakeEndpointTracingMW(tracer opentracing.Tracer, operationName string) endp
 	}
 }
 
+// MakeRateLimitTracingMW makes a middleware that records the requests rejected by the rate
+// limiter as events of the span of the context. It must wrap the rate limiter.
+func MakeRateLimitTracingMW(operationName string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var reply, err = next(ctx, req)
+			if errors.Cause(err) == ratelimit.ErrLimited {
+				if span := opentracing.SpanFromContext(ctx); span != nil {
+					span.LogKV("event", "rate_limited", "endpoint", operationName)
+				}
+			}
+			return reply, err
+		}
+	}
+}
+
 // Tracing middleware at component level.
 type componentTracingMW struct {
 	tracer opentracing.Tracer
@@ -149,6 +179,13 @@ func (m *componentTracingMW) NextID(ctx context.Context, req *fb.FlakiRequest) (
 		}
 		span.SetTag("correlation_id", corrID.(string))
 
+		if err != nil {
+			otag.Error.Set(span, true)
+			span.LogKV("error", err.Error())
+		} else {
+			span.SetTag("flaki_id", string(reply.Id()))
+		}
+
 		return reply, err
 	}
 
@@ -169,6 +206,7 @@ func (m *componentTracingMW) NextValidID(ctx context.Context, req *fb.FlakiReque
 			corrID = string(reply.Id())
 		}
 		span.SetTag("correlation_id", corrID.(string))
+		span.SetTag("flaki_id", string(reply.Id()))
 
 		return reply
 	}
@@ -178,16 +216,21 @@ func (m *componentTracingMW) NextValidID(ctx context.Context, req *fb.FlakiReque
 
 // Tracing middleware at module level.
 type moduleTracingMW struct {
-	tracer opentracing.Tracer
-	next   IDGeneratorModule
+	tracer      opentracing.Tracer
+	nodeID      uint64
+	componentID uint64
+	next        IDGeneratorModule
 }
 
-// MakeModuleTracingMW makes a tracing middleware at module level.
-func MakeModuleTracingMW(tracer opentracing.Tracer) func(IDGeneratorModule) IDGeneratorModule {
+// MakeModuleTracingMW makes a tracing middleware at module level. The spans are tagged with
+// the node and component IDs of the flaki generator.
+func MakeModuleTracingMW(tracer opentracing.Tracer, nodeID, componentID uint64) func(IDGeneratorModule) IDGeneratorModule {
 	return func(next IDGeneratorModule) IDGeneratorModule {
 		return &moduleTracingMW{
-			tracer: tracer,
-			next:   next,
+			tracer:      tracer,
+			nodeID:      nodeID,
+			componentID: componentID,
+			next:        next,
 		}
 	}
 }
@@ -206,6 +249,15 @@ func (m *moduleTracingMW) NextID(ctx context.Context) (string, error) {
 			corrID = id
 		}
 		span.SetTag("correlation_id", corrID.(string))
+		span.SetTag("flaki_node_id", m.nodeID)
+		span.SetTag("flaki_component_id", m.componentID)
+
+		if err != nil {
+			otag.Error.Set(span, true)
+			span.LogKV("error", err.Error())
+		} else {
+			span.SetTag("flaki_id", id)
+		}
 
 		return id, err
 	}
@@ -227,6 +279,9 @@ func (m *moduleTracingMW) NextValidID(ctx context.Context) string {
 			corrID = id
 		}
 		span.SetTag("correlation_id", corrID.(string))
+		span.SetTag("flaki_node_id", m.nodeID)
+		span.SetTag("flaki_component_id", m.componentID)
+		span.SetTag("flaki_id", id)
 
 		return id
 	}
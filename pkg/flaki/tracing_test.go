@@ -13,9 +13,16 @@ import (
 
 	"github.com/cloudtrust/flaki-service/api/fb"
 	"github.com/cloudtrust/flaki-service/pkg/flaki/mock"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/ratelimit"
 	"github.com/golang/mock/gomock"
 	flatbuffers "github.com/google/flatbuffers/go"
 	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	jaeger "github.com/uber/jaeger-client-go"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 )
 
 func TestHTTPTracingMW(t *testing.T) {
@@ -24,6 +31,7 @@ func TestHTTPTracingMW(t *testing.T) {
 	var mockTracer = mock.NewTracer(mockCtrl)
 	var mockSpan = mock.NewSpan(mockCtrl)
 	var mockSpanContext = mock.NewSpanContext(mockCtrl)
+	mockSpan.EXPECT().Tracer().Return(mockTracer).AnyTimes()
 
 	var m = MakeHTTPTracingMW(mockTracer, "componentName", "operationName")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
 
@@ -41,6 +49,7 @@ func TestHTTPTracingMW(t *testing.T) {
 	mockTracer.EXPECT().StartSpan("operationName", gomock.Any()).Return(mockSpan).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag(gomock.Any(), gomock.Any()).Return(mockSpan).Times(3)
+	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	m.ServeHTTP(w, req)
 
 	// Without existing tracer.
@@ -48,9 +57,40 @@ func TestHTTPTracingMW(t *testing.T) {
 	mockTracer.EXPECT().StartSpan("operationName").Return(mockSpan).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag(gomock.Any(), gomock.Any()).Return(mockSpan).Times(3)
+	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	m.ServeHTTP(w, req)
+	assert.Equal(t, "", w.Header().Get("X-Trace-ID"))
+
+	// Jaeger span, the trace ID is returned.
+	var sc = jaeger.NewSpanContext(jaeger.TraceID{Low: rand.Uint64()}, jaeger.SpanID(rand.Uint64()), 0, true, nil)
+	w = httptest.NewRecorder()
+	mockTracer.EXPECT().Extract(opentracing.HTTPHeaders, gomock.Any()).Return(nil, fmt.Errorf("fail")).Times(1)
+	mockTracer.EXPECT().StartSpan("operationName").Return(mockSpan).Times(1)
+	mockSpan.EXPECT().Finish().Return().Times(1)
+	mockSpan.EXPECT().SetTag(gomock.Any(), gomock.Any()).Return(mockSpan).Times(3)
+	mockSpan.EXPECT().Context().Return(sc).Times(1)
+	m.ServeHTTP(w, req)
+	assert.Equal(t, sc.TraceID().String(), w.Header().Get("X-Trace-ID"))
+
+	// OpenTelemetry span, the trace ID is returned.
+	var otelSC = otelSpanContextStub{trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}})}
+	w = httptest.NewRecorder()
+	mockTracer.EXPECT().Extract(opentracing.HTTPHeaders, gomock.Any()).Return(nil, fmt.Errorf("fail")).Times(1)
+	mockTracer.EXPECT().StartSpan("operationName").Return(mockSpan).Times(1)
+	mockSpan.EXPECT().Finish().Return().Times(1)
+	mockSpan.EXPECT().SetTag(gomock.Any(), gomock.Any()).Return(mockSpan).Times(3)
+	mockSpan.EXPECT().Context().Return(otelSC).Times(1)
+	m.ServeHTTP(w, req)
+	assert.Equal(t, otelSC.TraceID().String(), w.Header().Get("X-Trace-ID"))
+}
+
+// otelSpanContextStub is an OpenTelemetry span context, as returned by the bridge.
+type otelSpanContextStub struct {
+	trace.SpanContext
 }
 
+func (otelSpanContextStub) ForeachBaggageItem(handler func(k, v string) bool) {}
+
 func TestGRPCTracingMW(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -58,6 +98,7 @@ func TestGRPCTracingMW(t *testing.T) {
 	var mockTracer = mock.NewTracer(mockCtrl)
 	var mockSpan = mock.NewSpan(mockCtrl)
 	var mockSpanContext = mock.NewSpanContext(mockCtrl)
+	mockSpan.EXPECT().Tracer().Return(mockTracer).AnyTimes()
 
 	var m = MakeGRPCTracingMW(mockTracer, "componentName", "operationName")(mockGRPCHandler)
 
@@ -72,6 +113,7 @@ func TestGRPCTracingMW(t *testing.T) {
 	mockTracer.EXPECT().StartSpan("operationName", gomock.Any()).Return(mockSpan).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag(gomock.Any(), gomock.Any()).Return(mockSpan).Times(3)
+	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	m.ServeGRPC(context.Background(), b.FinishedBytes())
 
 	// Without existing tracer.
@@ -80,6 +122,7 @@ func TestGRPCTracingMW(t *testing.T) {
 	mockTracer.EXPECT().StartSpan("operationName").Return(mockSpan).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag(gomock.Any(), gomock.Any()).Return(mockSpan).Times(3)
+	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	m.ServeGRPC(context.Background(), b.FinishedBytes())
 }
 func TestEndpointTracingMW(t *testing.T) {
@@ -89,6 +132,7 @@ func TestEndpointTracingMW(t *testing.T) {
 	var mockTracer = mock.NewTracer(mockCtrl)
 	var mockSpan = mock.NewSpan(mockCtrl)
 	var mockSpanContext = mock.NewSpanContext(mockCtrl)
+	mockSpan.EXPECT().Tracer().Return(mockTracer).AnyTimes()
 
 	var m = MakeEndpointTracingMW(mockTracer, "operationName")(MakeNextIDEndpoint(mockComponent))
 
@@ -114,6 +158,8 @@ func TestEndpointTracingMW(t *testing.T) {
 	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag("correlation_id", corrID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("error", true).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().LogKV("error", "fail").Return().Times(1)
 	m(ctx, req)
 
 	// NextID without correlation ID.
@@ -130,12 +176,47 @@ func TestEndpointTracingMW(t *testing.T) {
 	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag("correlation_id", "").Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("error", true).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().LogKV("error", "fail").Return().Times(1)
 	m(opentracing.ContextWithSpan(context.Background(), mockSpan), req)
 
 	// Without tracer.
 	mockComponent.EXPECT().NextID(gomock.Any(), req).Return(reply, nil).Times(1)
 	m(context.Background(), req)
 }
+
+func TestEndpointTracingMWRateLimited(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockComponent = mock.NewIDGeneratorComponent(mockCtrl)
+	var mockLogger = mock.NewLogger(mockCtrl)
+	var mockTracer = mock.NewTracer(mockCtrl)
+	var mockSpan = mock.NewSpan(mockCtrl)
+	var mockSpanContext = mock.NewSpanContext(mockCtrl)
+	mockSpan.EXPECT().Tracer().Return(mockTracer).AnyTimes()
+
+	// The endpoint chain of flakid, with a rate limiter that rejects all the requests.
+	var e = MakeNextIDEndpoint(mockComponent)
+	e = ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Hour), 0))(e)
+	e = MakeRateLimitTracingMW("operationName")(e)
+	e = MakeEndpointLoggingMW(mockLogger)(e)
+	e = MakeEndpointTracingMW(mockTracer, "operationName")(e)
+
+	// The rejected request has no reply and no correlation ID.
+	mockTracer.EXPECT().StartSpan("operationName", gomock.Any()).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(2)
+	mockSpan.EXPECT().Finish().Return().Times(1)
+	mockSpan.EXPECT().LogKV("event", "rate_limited", "endpoint", "operationName").Return().Times(1)
+	mockSpan.EXPECT().SetTag("correlation_id", "").Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("error", true).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().LogKV("error", ratelimit.ErrLimited.Error()).Return().Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "correlation_id", "", "trace_id", "", "span_id", "", "took", gomock.Any(), "outcome", "failure", "error_class", "rate_limited", "error", ratelimit.ErrLimited.Error()).Return(nil).Times(1)
+
+	var reply, err = e(opentracing.ContextWithSpan(context.Background(), mockSpan), createFlakiRequest())
+	assert.Nil(t, reply)
+	assert.Equal(t, ratelimit.ErrLimited, err)
+}
+
 func TestComponentTracingMW(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -143,6 +224,7 @@ func TestComponentTracingMW(t *testing.T) {
 	var mockTracer = mock.NewTracer(mockCtrl)
 	var mockSpan = mock.NewSpan(mockCtrl)
 	var mockSpanContext = mock.NewSpanContext(mockCtrl)
+	mockSpan.EXPECT().Tracer().Return(mockTracer).AnyTimes()
 
 	var m = MakeComponentTracingMW(mockTracer)(mockComponent)
 
@@ -160,6 +242,7 @@ func TestComponentTracingMW(t *testing.T) {
 	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag("correlation_id", corrID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_id", flakiID).Return(mockSpan).Times(1)
 	m.NextID(ctx, req)
 
 	// NextID error.
@@ -168,6 +251,8 @@ func TestComponentTracingMW(t *testing.T) {
 	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag("correlation_id", corrID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("error", true).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().LogKV("error", "fail").Return().Times(1)
 	m.NextID(ctx, req)
 
 	// NextID without correlation ID.
@@ -176,6 +261,7 @@ func TestComponentTracingMW(t *testing.T) {
 	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag("correlation_id", flakiID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_id", flakiID).Return(mockSpan).Times(1)
 	m.NextID(opentracing.ContextWithSpan(context.Background(), mockSpan), req)
 
 	// NextID error without correlation ID.
@@ -184,6 +270,8 @@ func TestComponentTracingMW(t *testing.T) {
 	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag("correlation_id", "").Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("error", true).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().LogKV("error", "fail").Return().Times(1)
 	m.NextID(opentracing.ContextWithSpan(context.Background(), mockSpan), req)
 
 	// NextID without tracer.
@@ -196,6 +284,7 @@ func TestComponentTracingMW(t *testing.T) {
 	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag("correlation_id", corrID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_id", flakiID).Return(mockSpan).Times(1)
 	m.NextValidID(ctx, req)
 
 	// NextValidID without correlation ID.
@@ -204,6 +293,7 @@ func TestComponentTracingMW(t *testing.T) {
 	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag("correlation_id", flakiID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_id", flakiID).Return(mockSpan).Times(1)
 	m.NextValidID(opentracing.ContextWithSpan(context.Background(), mockSpan), req)
 
 	// NextValidID without tracer.
@@ -218,10 +308,13 @@ func TestModuleTracingMW(t *testing.T) {
 	var mockTracer = mock.NewTracer(mockCtrl)
 	var mockSpan = mock.NewSpan(mockCtrl)
 	var mockSpanContext = mock.NewSpanContext(mockCtrl)
-
-	var m = MakeModuleTracingMW(mockTracer)(mockModule)
+	mockSpan.EXPECT().Tracer().Return(mockTracer).AnyTimes()
 
 	rand.Seed(time.Now().UnixNano())
+	var nodeID = uint64(rand.Int63n(1024))
+	var componentID = uint64(rand.Int63n(32))
+	var m = MakeModuleTracingMW(mockTracer, nodeID, componentID)(mockModule)
+
 	var flakiID = strconv.FormatUint(rand.Uint64(), 10)
 	var corrID = strconv.FormatUint(rand.Uint64(), 10)
 	var ctx = context.WithValue(context.Background(), "correlation_id", corrID)
@@ -233,6 +326,9 @@ func TestModuleTracingMW(t *testing.T) {
 	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag("correlation_id", corrID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_node_id", nodeID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_component_id", componentID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_id", flakiID).Return(mockSpan).Times(1)
 	m.NextID(ctx)
 
 	// NextID error.
@@ -241,6 +337,10 @@ func TestModuleTracingMW(t *testing.T) {
 	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag("correlation_id", corrID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_node_id", nodeID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_component_id", componentID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("error", true).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().LogKV("error", "fail").Return().Times(1)
 	m.NextID(ctx)
 
 	// NextID without correlation ID.
@@ -249,6 +349,9 @@ func TestModuleTracingMW(t *testing.T) {
 	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag("correlation_id", flakiID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_node_id", nodeID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_component_id", componentID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_id", flakiID).Return(mockSpan).Times(1)
 	m.NextID(opentracing.ContextWithSpan(context.Background(), mockSpan))
 
 	// NextID error without correlation ID.
@@ -257,6 +360,10 @@ func TestModuleTracingMW(t *testing.T) {
 	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag("correlation_id", "").Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_node_id", nodeID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_component_id", componentID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("error", true).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().LogKV("error", "fail").Return().Times(1)
 	m.NextID(opentracing.ContextWithSpan(context.Background(), mockSpan))
 
 	// NextID without tracer.
@@ -269,6 +376,9 @@ func TestModuleTracingMW(t *testing.T) {
 	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag("correlation_id", corrID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_node_id", nodeID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_component_id", componentID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_id", flakiID).Return(mockSpan).Times(1)
 	m.NextValidID(ctx)
 
 	// NextValidID without correlation ID.
@@ -277,9 +387,47 @@ func TestModuleTracingMW(t *testing.T) {
 	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
 	mockSpan.EXPECT().Finish().Return().Times(1)
 	mockSpan.EXPECT().SetTag("correlation_id", flakiID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_node_id", nodeID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_component_id", componentID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("flaki_id", flakiID).Return(mockSpan).Times(1)
 	m.NextValidID(opentracing.ContextWithSpan(context.Background(), mockSpan))
 
 	// NextValidID without tracer.
 	mockModule.EXPECT().NextValidID(gomock.Any()).Return(flakiID).Times(1)
 	m.NextValidID(context.Background())
 }
+
+func TestRateLimitTracingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockTracer = mock.NewTracer(mockCtrl)
+	var mockSpan = mock.NewSpan(mockCtrl)
+	mockSpan.EXPECT().Tracer().Return(mockTracer).AnyTimes()
+
+	var limitErr = ratelimit.ErrLimited
+	var e = func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, limitErr
+	}
+	var m = MakeRateLimitTracingMW("nextid")(e)
+	var ctx = opentracing.ContextWithSpan(context.Background(), mockSpan)
+
+	// Rejected request.
+	mockSpan.EXPECT().LogKV("event", "rate_limited", "endpoint", "nextid").Return().Times(1)
+	var _, err = m(ctx, nil)
+	assert.Equal(t, ratelimit.ErrLimited, err)
+
+	// Rejected request without tracer.
+	_, err = m(context.Background(), nil)
+	assert.Equal(t, ratelimit.ErrLimited, err)
+
+	// Rejected request, with a wrapped error.
+	limitErr = errors.Wrap(ratelimit.ErrLimited, "could not get ID")
+	mockSpan.EXPECT().LogKV("event", "rate_limited", "endpoint", "nextid").Return().Times(1)
+	_, err = m(ctx, nil)
+	assert.Equal(t, ratelimit.ErrLimited, errors.Cause(err))
+
+	// Accepted request.
+	limitErr = nil
+	_, err = m(ctx, nil)
+	assert.Nil(t, err)
+}
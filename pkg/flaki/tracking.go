@@ -1,34 +1,41 @@
 package flaki
 
-//go:generate mockgen -destination=./mock/tracking.go -package=mock -mock_names=Sentry=Sentry  github.com/cloudtrust/flaki-service/pkg/flaki Sentry
+//go:generate mockgen -destination=./mock/tracking.go -package=mock -mock_names=ErrorTracker=ErrorTracker github.com/cloudtrust/flaki-service/pkg/flaki ErrorTracker
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
 
 	"github.com/cloudtrust/flaki-service/api/fb"
-	sentry "github.com/getsentry/raven-go"
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
-// Sentry interface.
-type Sentry interface {
-	CaptureError(err error, tags map[string]string, interfaces ...sentry.Interface) string
+// ErrorTracker is the interface of the error tracking provider.
+type ErrorTracker interface {
+	CaptureError(err error, tags map[string]string) string
 }
 
 // Tracking middleware at component level.
 type trackingComponentMW struct {
-	sentry Sentry
-	logger log.Logger
-	next   IDGeneratorComponent
+	tracker ErrorTracker
+	logger  log.Logger
+	next    IDGeneratorComponent
 }
 
-// MakeComponentTrackingMW makes an error tracking middleware, where the errors are logged and sent to Sentry.
-func MakeComponentTrackingMW(sentry Sentry, logger log.Logger) func(IDGeneratorComponent) IDGeneratorComponent {
+// MakeComponentTrackingMW makes an error tracking middleware, where the errors are logged and sent to the error tracker.
+func MakeComponentTrackingMW(tracker ErrorTracker, logger log.Logger) func(IDGeneratorComponent) IDGeneratorComponent {
 	return func(next IDGeneratorComponent) IDGeneratorComponent {
 		return &trackingComponentMW{
-			sentry: sentry,
-			logger: logger,
-			next:   next,
+			tracker: tracker,
+			logger:  logger,
+			next:    next,
 		}
 	}
 }
@@ -41,8 +48,8 @@ func (m *trackingComponentMW) NextID(ctx context.Context, req *fb.FlakiRequest)
 		if id := ctx.Value("correlation_id"); id != nil {
 			corrID = id.(string)
 		}
-		m.sentry.CaptureError(err, map[string]string{"correlation_id": corrID})
-		m.logger.Log("unit", "NextID", "correlation_id", corrID, "error", err.Error())
+		m.tracker.CaptureError(err, map[string]string{"correlation_id": corrID})
+		level.Error(m.logger).Log("unit", "NextID", "correlation_id", corrID, "error", err.Error())
 	}
 	return reply, err
 }
@@ -51,3 +58,54 @@ func (m *trackingComponentMW) NextID(ctx context.Context, req *fb.FlakiRequest)
 func (m *trackingComponentMW) NextValidID(ctx context.Context, req *fb.FlakiRequest) *fb.FlakiReply {
 	return m.next.NextValidID(ctx, req)
 }
+
+// MakeHTTPRecoveryMW makes a middleware that recovers from the panics of the HTTP handlers. The
+// panic is logged with its stack, sent to the error tracker with the request, and the reply is a 500.
+func MakeHTTPRecoveryMW(tracker ErrorTracker, logger log.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				var p = recover()
+				if p == nil {
+					return
+				}
+				// http.ErrAbortHandler aborts the response on purpose, the HTTP server handles it.
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+
+				var err = fmt.Errorf("panic: %v", p)
+				var corrID = r.Header.Get("X-Correlation-ID")
+				tracker.CaptureError(err, map[string]string{"correlation_id": corrID, "transport": "http", "method": r.Method, "path": r.URL.Path, "user_agent": r.UserAgent()})
+				level.Error(logger).Log("method", r.Method, "path", r.URL.Path, "correlation_id", corrID, "error", err.Error(), "stack", string(debug.Stack()))
+				w.WriteHeader(http.StatusInternalServerError)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// MakeGRPCRecoveryInterceptor makes a gRPC unary interceptor that recovers from the panics of the
+// handlers. The panic is logged with its stack, sent to the error tracker with the method, and the
+// reply is an Internal error.
+func MakeGRPCRecoveryInterceptor(tracker ErrorTracker, logger log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (reply interface{}, err error) {
+		defer func() {
+			var p = recover()
+			if p == nil {
+				return
+			}
+
+			var corrID string
+			if md, ok := metadata.FromIncomingContext(ctx); ok && len(md["correlation_id"]) > 0 {
+				corrID = md["correlation_id"][0]
+			}
+
+			var panicErr = fmt.Errorf("panic: %v", p)
+			tracker.CaptureError(panicErr, map[string]string{"correlation_id": corrID, "transport": "grpc", "method": info.FullMethod})
+			level.Error(logger).Log("method", info.FullMethod, "correlation_id", corrID, "error", panicErr.Error(), "stack", string(debug.Stack()))
+			reply, err = nil, status.Error(codes.Internal, "internal error")
+		}()
+		return handler(ctx, req)
+	}
+}
@@ -4,22 +4,30 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/cloudtrust/flaki-service/pkg/flaki/mock"
+	"github.com/go-kit/kit/log/level"
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func TestComponentTrackingMW(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 	var mockComponent = mock.NewIDGeneratorComponent(mockCtrl)
-	var mockSentry = mock.NewSentry(mockCtrl)
+	var mockTracker = mock.NewErrorTracker(mockCtrl)
 	var mockLogger = mock.NewLogger(mockCtrl)
 
-	var m = MakeComponentTrackingMW(mockSentry, mockLogger)(mockComponent)
+	var m = MakeComponentTrackingMW(mockTracker, mockLogger)(mockComponent)
 
 	rand.Seed(time.Now().UnixNano())
 	var corrID = strconv.FormatUint(rand.Uint64(), 10)
@@ -29,17 +37,83 @@ func TestComponentTrackingMW(t *testing.T) {
 
 	// NextID.
 	mockComponent.EXPECT().NextID(ctx, req).Return(nil, fmt.Errorf("fail")).Times(1)
-	mockSentry.EXPECT().CaptureError(fmt.Errorf("fail"), map[string]string{"correlation_id": corrID}).Return("").Times(1)
-	mockLogger.EXPECT().Log("unit", "NextID", "correlation_id", corrID, "error", "fail").Return(nil).Times(1)
+	mockTracker.EXPECT().CaptureError(fmt.Errorf("fail"), map[string]string{"correlation_id": corrID}).Return("").Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "unit", "NextID", "correlation_id", corrID, "error", "fail").Return(nil).Times(1)
 	m.NextID(ctx, req)
 
 	// NextID without correlation ID.
 	mockComponent.EXPECT().NextID(context.Background(), req).Return(nil, fmt.Errorf("fail")).Times(1)
-	mockSentry.EXPECT().CaptureError(fmt.Errorf("fail"), map[string]string{"correlation_id": ""}).Times(1)
-	mockLogger.EXPECT().Log("unit", "NextID", "correlation_id", "", "error", "fail").Return(nil).Times(1)
+	mockTracker.EXPECT().CaptureError(fmt.Errorf("fail"), map[string]string{"correlation_id": ""}).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "unit", "NextID", "correlation_id", "", "error", "fail").Return(nil).Times(1)
 	m.NextID(context.Background(), req)
 
 	// NextValidID never returns an error.
 	mockComponent.EXPECT().NextValidID(ctx, req).Return(reply).Times(1)
 	m.NextValidID(ctx, req)
 }
+
+func TestHTTPRecoveryMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockTracker = mock.NewErrorTracker(mockCtrl)
+	var mockLogger = mock.NewLogger(mockCtrl)
+
+	rand.Seed(time.Now().UnixNano())
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+
+	var m = MakeHTTPRecoveryMW(mockTracker, mockLogger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("fail")
+	}))
+
+	// The panic is reported and the reply is a 500.
+	var req = httptest.NewRequest("GET", "/nextid", nil)
+	req.Header.Set("X-Correlation-ID", corrID)
+	req.Header.Set("User-Agent", "flaki-client")
+	var w = httptest.NewRecorder()
+	mockTracker.EXPECT().CaptureError(fmt.Errorf("panic: fail"), map[string]string{"correlation_id": corrID, "transport": "http", "method": "GET", "path": "/nextid", "user_agent": "flaki-client"}).Return("").Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "method", "GET", "path", "/nextid", "correlation_id", corrID, "error", "panic: fail", "stack", gomock.Any()).Return(nil).Times(1)
+	m.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// Without panic, nothing is reported.
+	m = MakeHTTPRecoveryMW(mockTracker, mockLogger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/nextid", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// http.ErrAbortHandler is left to the HTTP server.
+	m = MakeHTTPRecoveryMW(mockTracker, mockLogger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	assert.Panics(t, func() { m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nextid", nil)) })
+}
+
+func TestGRPCRecoveryInterceptor(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockTracker = mock.NewErrorTracker(mockCtrl)
+	var mockLogger = mock.NewLogger(mockCtrl)
+
+	rand.Seed(time.Now().UnixNano())
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+	var ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("correlation_id", corrID))
+	var info = &grpc.UnaryServerInfo{FullMethod: "/fb.Flaki/NextID"}
+
+	var m = MakeGRPCRecoveryInterceptor(mockTracker, mockLogger)
+
+	// The panic is reported and the reply is an Internal error.
+	mockTracker.EXPECT().CaptureError(fmt.Errorf("panic: fail"), map[string]string{"correlation_id": corrID, "transport": "grpc", "method": "/fb.Flaki/NextID"}).Return("").Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "method", "/fb.Flaki/NextID", "correlation_id", corrID, "error", "panic: fail", "stack", gomock.Any()).Return(nil).Times(1)
+	var reply, err = m(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("fail")
+	})
+	assert.Nil(t, reply)
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	// Without panic, the reply of the handler is returned.
+	reply, err = m(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	})
+	assert.Equal(t, "reply", reply)
+	assert.Nil(t, err)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	common "github.com/cloudtrust/common-healthcheck"
@@ -40,8 +41,8 @@ type SentryHealthChecker interface {
 // StoreModule is the interface of the module that stores the health reports
 // in the DB.
 type StoreModule interface {
-	Read(name string) (StoredReport, error)
-	Update(unit string, validity time.Duration, reports json.RawMessage) error
+	Read(ctx context.Context, name string) (StoredReport, error)
+	Update(ctx context.Context, unit string, validity time.Duration, reports json.RawMessage) error
 }
 
 // Component is the Health component.
@@ -51,33 +52,54 @@ type Component struct {
 	redis               RedisHealthChecker
 	sentry              SentryHealthChecker
 	storage             StoreModule
+	mu                  sync.RWMutex
 	healthCheckValidity map[string]time.Duration
 }
 
 // NewComponent returns the health component.
 func NewComponent(influx InfluxHealthChecker, jaeger JaegerHealthChecker, redis RedisHealthChecker, sentry SentryHealthChecker, storage StoreModule, healthCheckValidity map[string]time.Duration) *Component {
+	var validity = make(map[string]time.Duration, len(healthCheckValidity))
+	for unit, d := range healthCheckValidity {
+		validity[unit] = d
+	}
+
 	return &Component{
 		influx:              influx,
 		jaeger:              jaeger,
 		redis:               redis,
 		sentry:              sentry,
 		storage:             storage,
-		healthCheckValidity: healthCheckValidity,
+		healthCheckValidity: validity,
 	}
 }
 
+// HealthCheckValidity returns the validity of the health checks results of the unit.
+func (c *Component) HealthCheckValidity(unit string) time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.healthCheckValidity[unit]
+}
+
+// SetHealthCheckValidity changes the validity of the health checks results of the unit. It
+// applies to the results stored from now on.
+func (c *Component) SetHealthCheckValidity(unit string, validity time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.healthCheckValidity[unit] = validity
+}
+
 // ExecInfluxHealthChecks executes the health checks for Influx.
 func (c *Component) ExecInfluxHealthChecks(ctx context.Context) json.RawMessage {
 	var reports = c.influx.HealthChecks(ctx)
 	var jsonReports, _ = json.Marshal(reports)
 
-	c.storage.Update(influxUnitName, c.healthCheckValidity[influxUnitName], jsonReports)
+	c.storage.Update(ctx, influxUnitName, c.HealthCheckValidity(influxUnitName), jsonReports)
 	return json.RawMessage(jsonReports)
 }
 
 // ReadInfluxHealthChecks read the health checks status in DB.
 func (c *Component) ReadInfluxHealthChecks(ctx context.Context) json.RawMessage {
-	return c.readFromDB(influxUnitName)
+	return c.readFromDB(ctx, influxUnitName)
 }
 
 // ExecJaegerHealthChecks executes the health checks for Jaeger.
@@ -85,13 +107,13 @@ func (c *Component) ExecJaegerHealthChecks(ctx context.Context) json.RawMessage
 	var reports = c.jaeger.HealthChecks(ctx)
 	var jsonReports, _ = json.Marshal(reports)
 
-	c.storage.Update(jaegerUnitName, c.healthCheckValidity[jaegerUnitName], jsonReports)
+	c.storage.Update(ctx, jaegerUnitName, c.HealthCheckValidity(jaegerUnitName), jsonReports)
 	return json.RawMessage(jsonReports)
 }
 
 // ReadJaegerHealthChecks read the health checks status in DB.
 func (c *Component) ReadJaegerHealthChecks(ctx context.Context) json.RawMessage {
-	return c.readFromDB(jaegerUnitName)
+	return c.readFromDB(ctx, jaegerUnitName)
 }
 
 // ExecRedisHealthChecks executes the health checks for Redis.
@@ -99,14 +121,14 @@ func (c *Component) ExecRedisHealthChecks(ctx context.Context) json.RawMessage {
 	var reports = c.redis.HealthChecks(ctx)
 	var jsonReports, _ = json.Marshal(reports)
 
-	c.storage.Update(redisUnitName, c.healthCheckValidity[redisUnitName], jsonReports)
+	c.storage.Update(ctx, redisUnitName, c.HealthCheckValidity(redisUnitName), jsonReports)
 	return json.RawMessage(jsonReports)
 
 }
 
 // ReadRedisHealthChecks read the health checks status in DB.
 func (c *Component) ReadRedisHealthChecks(ctx context.Context) json.RawMessage {
-	return c.readFromDB(redisUnitName)
+	return c.readFromDB(ctx, redisUnitName)
 }
 
 // ExecSentryHealthChecks executes the health checks for Sentry.
@@ -114,13 +136,13 @@ func (c *Component) ExecSentryHealthChecks(ctx context.Context) json.RawMessage
 	var reports = c.sentry.HealthChecks(ctx)
 	var jsonReports, _ = json.Marshal(reports)
 
-	c.storage.Update(sentryUnitName, c.healthCheckValidity[sentryUnitName], jsonReports)
+	c.storage.Update(ctx, sentryUnitName, c.HealthCheckValidity(sentryUnitName), jsonReports)
 	return json.RawMessage(jsonReports)
 }
 
 // ReadSentryHealthChecks read the health checks status in DB.
 func (c *Component) ReadSentryHealthChecks(ctx context.Context) json.RawMessage {
-	return c.readFromDB(sentryUnitName)
+	return c.readFromDB(ctx, sentryUnitName)
 }
 
 // AllHealthChecks call all component checks and build a general health report.
@@ -136,8 +158,8 @@ func (c *Component) AllHealthChecks(ctx context.Context) json.RawMessage {
 	return json.RawMessage(jsonReports)
 }
 
-func (c *Component) readFromDB(unit string) json.RawMessage {
-	var storedReport, err = c.storage.Read(unit)
+func (c *Component) readFromDB(ctx context.Context, unit string) json.RawMessage {
+	var storedReport, err = c.storage.Read(ctx, unit)
 
 	type report struct {
 		Name   string `json:"name"`
@@ -171,7 +193,7 @@ func (c *Component) readFromDB(unit string) json.RawMessage {
 		var jsonReport, _ = json.Marshal(report{
 			Name:   unit,
 			Status: common.KO.String(),
-			Error:  fmt.Sprintf("the health check results are stale because the test was not executed in the last %s", c.healthCheckValidity[storedReport.HealthcheckUnit]),
+			Error:  fmt.Sprintf("the health check results are stale because the test was not executed in the last %s", c.HealthCheckValidity(storedReport.HealthcheckUnit)),
 		})
 
 		return json.RawMessage(jsonReport)
@@ -52,7 +52,7 @@ func TestHealthChecks(t *testing.T) {
 
 	// Influx.
 	mockInfluxModule.EXPECT().HealthChecks(context.Background()).Return(influxReports).Times(1)
-	mockStorage.EXPECT().Update("influx", m["influx"], gomock.Any()).Times(1)
+	mockStorage.EXPECT().Update(gomock.Any(), "influx", m["influx"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecInfluxHealthChecks(context.Background())
 		//	var json, _ = json.Marshal(&report)
@@ -61,7 +61,7 @@ func TestHealthChecks(t *testing.T) {
 
 	// Jaeger.
 	mockJaegerModule.EXPECT().HealthChecks(context.Background()).Return(jaegerReports).Times(1)
-	mockStorage.EXPECT().Update("jaeger", m["jaeger"], gomock.Any()).Times(1)
+	mockStorage.EXPECT().Update(gomock.Any(), "jaeger", m["jaeger"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecJaegerHealthChecks(context.Background())
 		var json, _ = json.Marshal(&report)
@@ -70,7 +70,7 @@ func TestHealthChecks(t *testing.T) {
 
 	// Redis.
 	mockRedisModule.EXPECT().HealthChecks(context.Background()).Return(redisReports).Times(1)
-	mockStorage.EXPECT().Update("redis", m["redis"], gomock.Any()).Times(1)
+	mockStorage.EXPECT().Update(gomock.Any(), "redis", m["redis"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecRedisHealthChecks(context.Background())
 		var json, _ = json.Marshal(&report)
@@ -80,7 +80,7 @@ func TestHealthChecks(t *testing.T) {
 
 	// Sentry.
 	mockSentryModule.EXPECT().HealthChecks(context.Background()).Return(sentryReports).Times(1)
-	mockStorage.EXPECT().Update("sentry", m["sentry"], gomock.Any()).Times(1)
+	mockStorage.EXPECT().Update(gomock.Any(), "sentry", m["sentry"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecSentryHealthChecks(context.Background())
 		var json, _ = json.Marshal(&report)
@@ -88,10 +88,10 @@ func TestHealthChecks(t *testing.T) {
 	}
 
 	// All.
-	mockStorage.EXPECT().Read("influx").Return(makeStoredReport("influx"), nil).Times(1)
-	mockStorage.EXPECT().Read("jaeger").Return(makeStoredReport("jaeger"), nil).Times(1)
-	mockStorage.EXPECT().Read("redis").Return(makeStoredReport("redis"), nil).Times(1)
-	mockStorage.EXPECT().Read("sentry").Return(makeStoredReport("sentry"), nil).Times(1)
+	mockStorage.EXPECT().Read(gomock.Any(), "influx").Return(makeStoredReport("influx"), nil).Times(1)
+	mockStorage.EXPECT().Read(gomock.Any(), "jaeger").Return(makeStoredReport("jaeger"), nil).Times(1)
+	mockStorage.EXPECT().Read(gomock.Any(), "redis").Return(makeStoredReport("redis"), nil).Times(1)
+	mockStorage.EXPECT().Read(gomock.Any(), "sentry").Return(makeStoredReport("sentry"), nil).Times(1)
 	{
 		var report = c.AllHealthChecks(context.Background())
 		var json, _ = json.Marshal(&report)
@@ -136,7 +136,7 @@ func TestHealthChecksFail(t *testing.T) {
 
 	// Influx.
 	mockInfluxModule.EXPECT().HealthChecks(context.Background()).Return(influxReports).Times(1)
-	mockStorage.EXPECT().Update("influx", m["influx"], gomock.Any()).Times(1)
+	mockStorage.EXPECT().Update(gomock.Any(), "influx", m["influx"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecInfluxHealthChecks(context.Background())
 		var json, _ = json.Marshal(&report)
@@ -145,7 +145,7 @@ func TestHealthChecksFail(t *testing.T) {
 
 	// Jaeger.
 	mockJaegerModule.EXPECT().HealthChecks(context.Background()).Return(jaegerReports).Times(1)
-	mockStorage.EXPECT().Update("jaeger", m["jaeger"], gomock.Any()).Times(1)
+	mockStorage.EXPECT().Update(gomock.Any(), "jaeger", m["jaeger"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecJaegerHealthChecks(context.Background())
 		var json, _ = json.Marshal(&report)
@@ -154,7 +154,7 @@ func TestHealthChecksFail(t *testing.T) {
 
 	// Redis.
 	mockRedisModule.EXPECT().HealthChecks(context.Background()).Return(redisReports).Times(1)
-	mockStorage.EXPECT().Update("redis", m["redis"], gomock.Any()).Times(1)
+	mockStorage.EXPECT().Update(gomock.Any(), "redis", m["redis"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecRedisHealthChecks(context.Background())
 		var json, _ = json.Marshal(&report)
@@ -163,7 +163,7 @@ func TestHealthChecksFail(t *testing.T) {
 
 	// Sentry.
 	mockSentryModule.EXPECT().HealthChecks(context.Background()).Return(sentryReports).Times(1)
-	mockStorage.EXPECT().Update("sentry", m["sentry"], gomock.Any()).Times(1)
+	mockStorage.EXPECT().Update(gomock.Any(), "sentry", m["sentry"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecSentryHealthChecks(context.Background())
 		var json, _ = json.Marshal(&report)
@@ -171,10 +171,10 @@ func TestHealthChecksFail(t *testing.T) {
 	}
 
 	// All.
-	mockStorage.EXPECT().Read("influx").Return(makeStoredReport("influx"), nil).Times(1)
-	mockStorage.EXPECT().Read("jaeger").Return(makeStoredReport("jaeger"), nil).Times(1)
-	mockStorage.EXPECT().Read("redis").Return(makeStoredReport("redis"), nil).Times(1)
-	mockStorage.EXPECT().Read("sentry").Return(makeStoredReport("sentry"), nil).Times(1)
+	mockStorage.EXPECT().Read(gomock.Any(), "influx").Return(makeStoredReport("influx"), nil).Times(1)
+	mockStorage.EXPECT().Read(gomock.Any(), "jaeger").Return(makeStoredReport("jaeger"), nil).Times(1)
+	mockStorage.EXPECT().Read(gomock.Any(), "redis").Return(makeStoredReport("redis"), nil).Times(1)
+	mockStorage.EXPECT().Read(gomock.Any(), "sentry").Return(makeStoredReport("sentry"), nil).Times(1)
 	{
 		var reply = c.AllHealthChecks(context.Background())
 		var m map[string]json.RawMessage
@@ -186,3 +186,25 @@ func TestHealthChecksFail(t *testing.T) {
 		assert.Equal(t, `[{"name":"XXX","status":"OK","duration":"1s"}]`, string(m["sentry"]))
 	}
 }
+
+func TestHealthCheckValidity(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockInfluxModule = mock.NewInfluxHealthChecker(mockCtrl)
+	var mockStorage = mock.NewStoreModule(mockCtrl)
+	var m = map[string]time.Duration{
+		"influx": 1 * time.Minute,
+	}
+
+	var c = NewComponent(mockInfluxModule, nil, nil, nil, mockStorage, m)
+	assert.Equal(t, 1*time.Minute, c.HealthCheckValidity("influx"))
+
+	// The new validity applies to the next results, the map given to NewComponent is not modified.
+	c.SetHealthCheckValidity("influx", 5*time.Minute)
+	assert.Equal(t, 5*time.Minute, c.HealthCheckValidity("influx"))
+	assert.Equal(t, 1*time.Minute, m["influx"])
+
+	mockInfluxModule.EXPECT().HealthChecks(context.Background()).Return(nil).Times(1)
+	mockStorage.EXPECT().Update(gomock.Any(), "influx", 5*time.Minute, gomock.Any()).Times(1)
+	c.ExecInfluxHealthChecks(context.Background())
+}
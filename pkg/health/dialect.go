@@ -0,0 +1,57 @@
+package health
+
+import "fmt"
+
+// Dialect contains the SQL statements of the storage module for a given DB.
+type Dialect struct {
+	Name             string
+	upsertHealthStmt string
+	selectHealthStmt string
+	cleanHealthStmt  string
+}
+
+// Cockroach is the dialect of the Cockroach DB.
+var Cockroach = Dialect{
+	Name: "cockroach",
+	upsertHealthStmt: `UPSERT INTO health (
+		component_name,
+		component_id,
+		unit,
+		json,
+		last_updated,
+		valid_until)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+	selectHealthStmt: `SELECT * FROM health WHERE (component_name = $1 AND component_id = $2 AND unit = $3)`,
+	cleanHealthStmt:  `DELETE from health WHERE (component_name = $1 AND valid_until < $2)`,
+}
+
+// PostgreSQL is the dialect of the PostgreSQL DB, which has no UPSERT statement.
+var PostgreSQL = Dialect{
+	Name: "postgres",
+	upsertHealthStmt: `INSERT INTO health (
+		component_name,
+		component_id,
+		unit,
+		json,
+		last_updated,
+		valid_until)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (component_name, component_id, unit) DO UPDATE SET
+		json = EXCLUDED.json,
+		last_updated = EXCLUDED.last_updated,
+		valid_until = EXCLUDED.valid_until`,
+	selectHealthStmt: `SELECT * FROM health WHERE (component_name = $1 AND component_id = $2 AND unit = $3)`,
+	cleanHealthStmt:  `DELETE from health WHERE (component_name = $1 AND valid_until < $2)`,
+}
+
+// GetDialect returns the dialect named 'name', i.e. "cockroach" or "postgres".
+func GetDialect(name string) (Dialect, error) {
+	switch name {
+	case Cockroach.Name:
+		return Cockroach, nil
+	case PostgreSQL.Name:
+		return PostgreSQL, nil
+	default:
+		return Dialect{}, fmt.Errorf("unknown SQL dialect '%s'", name)
+	}
+}
@@ -5,19 +5,28 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/cloudtrust/flaki-service/internal/tracing"
 	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	opentracing "github.com/opentracing/opentracing-go"
 )
 
-// MakeEndpointLoggingMW makes a logging middleware.
+// MakeEndpointLoggingMW makes a logging middleware. The requests are logged at info level,
+// the failed ones at error level. The logs have the IDs of the active span, if any.
 func MakeEndpointLoggingMW(logger log.Logger) endpoint.Middleware {
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
-			defer func(begin time.Time) {
-				logger.Log("correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
-			}(time.Now())
-
-			return next(ctx, req)
+			var begin = time.Now()
+			var reply, err = next(ctx, req)
+
+			var traceID, spanID = tracing.TraceIDs(opentracing.SpanFromContext(ctx))
+			if err != nil {
+				level.Error(logger).Log("correlation_id", ctx.Value("correlation_id").(string), "trace_id", traceID, "span_id", spanID, "took", time.Since(begin), "error", err.Error())
+			} else {
+				level.Info(logger).Log("correlation_id", ctx.Value("correlation_id").(string), "trace_id", traceID, "span_id", spanID, "took", time.Since(begin))
+			}
+			return reply, err
 		}
 	}
 }
@@ -28,7 +37,8 @@ type componentLoggingMW struct {
 	next   HealthChecker
 }
 
-// MakeComponentLoggingMW makes a logging middleware at component level.
+// MakeComponentLoggingMW makes a logging middleware at component level. The health checks
+// are logged at debug level.
 func MakeComponentLoggingMW(logger log.Logger) func(HealthChecker) HealthChecker {
 	return func(next HealthChecker) HealthChecker {
 		return &componentLoggingMW{
@@ -41,7 +51,8 @@ func MakeComponentLoggingMW(logger log.Logger) func(HealthChecker) HealthChecker
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ExecInfluxHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ExecInfluxHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		var traceID, spanID = tracing.TraceIDs(opentracing.SpanFromContext(ctx))
+		level.Debug(m.logger).Log("unit", "ExecInfluxHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "trace_id", traceID, "span_id", spanID, "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ExecInfluxHealthChecks(ctx)
@@ -50,7 +61,8 @@ func (m *componentLoggingMW) ExecInfluxHealthChecks(ctx context.Context) json.Ra
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ReadInfluxHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ReadInfluxHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		var traceID, spanID = tracing.TraceIDs(opentracing.SpanFromContext(ctx))
+		level.Debug(m.logger).Log("unit", "ReadInfluxHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "trace_id", traceID, "span_id", spanID, "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ReadInfluxHealthChecks(ctx)
@@ -59,7 +71,8 @@ func (m *componentLoggingMW) ReadInfluxHealthChecks(ctx context.Context) json.Ra
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ExecJaegerHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ExecJaegerHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		var traceID, spanID = tracing.TraceIDs(opentracing.SpanFromContext(ctx))
+		level.Debug(m.logger).Log("unit", "ExecJaegerHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "trace_id", traceID, "span_id", spanID, "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ExecJaegerHealthChecks(ctx)
@@ -68,7 +81,8 @@ func (m *componentLoggingMW) ExecJaegerHealthChecks(ctx context.Context) json.Ra
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ReadJaegerHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ReadJaegerHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		var traceID, spanID = tracing.TraceIDs(opentracing.SpanFromContext(ctx))
+		level.Debug(m.logger).Log("unit", "ReadJaegerHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "trace_id", traceID, "span_id", spanID, "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ReadJaegerHealthChecks(ctx)
@@ -77,7 +91,8 @@ func (m *componentLoggingMW) ReadJaegerHealthChecks(ctx context.Context) json.Ra
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ExecRedisHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ExecRedisHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		var traceID, spanID = tracing.TraceIDs(opentracing.SpanFromContext(ctx))
+		level.Debug(m.logger).Log("unit", "ExecRedisHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "trace_id", traceID, "span_id", spanID, "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ExecRedisHealthChecks(ctx)
@@ -86,7 +101,8 @@ func (m *componentLoggingMW) ExecRedisHealthChecks(ctx context.Context) json.Raw
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ReadRedisHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ReadRedisHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		var traceID, spanID = tracing.TraceIDs(opentracing.SpanFromContext(ctx))
+		level.Debug(m.logger).Log("unit", "ReadRedisHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "trace_id", traceID, "span_id", spanID, "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ReadRedisHealthChecks(ctx)
@@ -95,7 +111,8 @@ func (m *componentLoggingMW) ReadRedisHealthChecks(ctx context.Context) json.Raw
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ExecSentryHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ExecSentryHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		var traceID, spanID = tracing.TraceIDs(opentracing.SpanFromContext(ctx))
+		level.Debug(m.logger).Log("unit", "ExecSentryHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "trace_id", traceID, "span_id", spanID, "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ExecSentryHealthChecks(ctx)
@@ -104,7 +121,8 @@ func (m *componentLoggingMW) ExecSentryHealthChecks(ctx context.Context) json.Ra
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ReadSentryHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ReadSentryHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		var traceID, spanID = tracing.TraceIDs(opentracing.SpanFromContext(ctx))
+		level.Debug(m.logger).Log("unit", "ReadSentryHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "trace_id", traceID, "span_id", spanID, "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ReadSentryHealthChecks(ctx)
@@ -113,7 +131,8 @@ func (m *componentLoggingMW) ReadSentryHealthChecks(ctx context.Context) json.Ra
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) AllHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "AllHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		var traceID, spanID = tracing.TraceIDs(opentracing.SpanFromContext(ctx))
+		level.Debug(m.logger).Log("unit", "AllHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "trace_id", traceID, "span_id", spanID, "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.AllHealthChecks(ctx)
@@ -12,8 +12,11 @@ import (
 
 	. "github.com/cloudtrust/flaki-service/pkg/health"
 	"github.com/cloudtrust/flaki-service/pkg/health/mock"
+	"github.com/go-kit/kit/log/level"
 	"github.com/golang/mock/gomock"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
+	jaeger "github.com/uber/jaeger-client-go"
 )
 
 func TestEndpointLoggingMW(t *testing.T) {
@@ -31,10 +34,21 @@ func TestEndpointLoggingMW(t *testing.T) {
 	var rep = json.RawMessage(`{"JSON":"MOCK_CONTENT"}`)
 
 	// With correlation ID.
-	mockLogger.EXPECT().Log("correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.InfoValue(), "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 	mockComponent.EXPECT().ExecInfluxHealthChecks(ctx).Return(rep).Times(1)
 	m(ctx, nil)
 
+	// With span.
+	var tracer, closer = jaeger.NewTracer("flaki-service", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+	var span = tracer.StartSpan("health")
+	defer span.Finish()
+	var sc = span.Context().(jaeger.SpanContext)
+	var spanCtx = opentracing.ContextWithSpan(ctx, span)
+	mockLogger.EXPECT().Log(level.Key(), level.InfoValue(), "correlation_id", corrID, "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String(), "took", gomock.Any()).Return(nil).Times(1)
+	mockComponent.EXPECT().ExecInfluxHealthChecks(spanCtx).Return(rep).Times(1)
+	m(spanCtx, nil)
+
 	// Without correlation ID.
 	mockComponent.EXPECT().ExecInfluxHealthChecks(context.Background()).Return(rep).Times(1)
 	var f = func() {
@@ -59,11 +73,11 @@ func TestComponentLoggingMW(t *testing.T) {
 	// InfluxHealthChecks.
 	{
 		mockComponent.EXPECT().ExecInfluxHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ExecInfluxHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "ExecInfluxHealthChecks", "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 		m.ExecInfluxHealthChecks(ctx)
 
 		mockComponent.EXPECT().ReadInfluxHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ReadInfluxHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "ReadInfluxHealthChecks", "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 		m.ReadInfluxHealthChecks(ctx)
 
 		// Without correlation ID.
@@ -83,11 +97,11 @@ func TestComponentLoggingMW(t *testing.T) {
 	// JaegerHealthChecks.
 	{
 		mockComponent.EXPECT().ExecJaegerHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ExecJaegerHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "ExecJaegerHealthChecks", "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 		m.ExecJaegerHealthChecks(ctx)
 
 		mockComponent.EXPECT().ReadJaegerHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ReadJaegerHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "ReadJaegerHealthChecks", "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 		m.ReadJaegerHealthChecks(ctx)
 
 		// Without correlation ID.
@@ -107,11 +121,11 @@ func TestComponentLoggingMW(t *testing.T) {
 	// RedisHealthChecks.
 	{
 		mockComponent.EXPECT().ExecRedisHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ExecRedisHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "ExecRedisHealthChecks", "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 		m.ExecRedisHealthChecks(ctx)
 
 		mockComponent.EXPECT().ReadRedisHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ReadRedisHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "ReadRedisHealthChecks", "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 		m.ReadRedisHealthChecks(ctx)
 
 		// Without correlation ID.
@@ -131,11 +145,11 @@ func TestComponentLoggingMW(t *testing.T) {
 	// SentryHealthChecks.
 	{
 		mockComponent.EXPECT().ExecSentryHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ExecSentryHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "ExecSentryHealthChecks", "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 		m.ExecSentryHealthChecks(ctx)
 
 		mockComponent.EXPECT().ReadSentryHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ReadSentryHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "ReadSentryHealthChecks", "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 		m.ReadSentryHealthChecks(ctx)
 
 		// Without correlation ID.
@@ -156,7 +170,7 @@ func TestComponentLoggingMW(t *testing.T) {
 	{
 		var report = json.RawMessage(`{"influx":[{"Name":"sentry","Duration":"1s","Status":"OK","Error":""}], "redis":[{"Name":"redis","Duration":"1s","Status":"OK","Error":""}]}`)
 		mockComponent.EXPECT().AllHealthChecks(ctx).Return(report).Times(1)
-		mockLogger.EXPECT().Log("unit", "AllHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log(level.Key(), level.DebugValue(), "unit", "AllHealthChecks", "correlation_id", corrID, "trace_id", "", "span_id", "", "took", gomock.Any()).Return(nil).Times(1)
 		m.AllHealthChecks(ctx)
 
 		// Without correlation ID.
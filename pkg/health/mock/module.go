@@ -178,26 +178,26 @@ func (m *StoreModule) EXPECT() *StoreModuleMockRecorder {
 }
 
 // Read mocks base method
-func (m *StoreModule) Read(arg0 string) (health.StoredReport, error) {
-	ret := m.ctrl.Call(m, "Read", arg0)
+func (m *StoreModule) Read(arg0 context.Context, arg1 string) (health.StoredReport, error) {
+	ret := m.ctrl.Call(m, "Read", arg0, arg1)
 	ret0, _ := ret[0].(health.StoredReport)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Read indicates an expected call of Read
-func (mr *StoreModuleMockRecorder) Read(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*StoreModule)(nil).Read), arg0)
+func (mr *StoreModuleMockRecorder) Read(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*StoreModule)(nil).Read), arg0, arg1)
 }
 
 // Update mocks base method
-func (m *StoreModule) Update(arg0 string, arg1 time.Duration, arg2 json.RawMessage) error {
-	ret := m.ctrl.Call(m, "Update", arg0, arg1, arg2)
+func (m *StoreModule) Update(arg0 context.Context, arg1 string, arg2 time.Duration, arg3 json.RawMessage) error {
+	ret := m.ctrl.Call(m, "Update", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update
-func (mr *StoreModuleMockRecorder) Update(arg0, arg1, arg2 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*StoreModule)(nil).Update), arg0, arg1, arg2)
+func (mr *StoreModuleMockRecorder) Update(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*StoreModule)(nil).Update), arg0, arg1, arg2, arg3)
 }
@@ -1,34 +1,17 @@
 package health
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"time"
 
+	"github.com/lib/pq"
+	opentracing "github.com/opentracing/opentracing-go"
+	otag "github.com/opentracing/opentracing-go/ext"
 	"github.com/pkg/errors"
 )
 
-const (
-	createHealthTblStmt = `CREATE TABLE IF NOT EXISTS health (
-		component_name STRING,
-		component_id STRING,
-		unit STRING,
-		json JSONB,
-		last_updated TIMESTAMPTZ,
-		valid_until TIMESTAMPTZ,
-		PRIMARY KEY (component_name, component_id, unit))`
-	upsertHealthStmt = `UPSERT INTO health (
-		component_name,
-		component_id,
-		unit,
-		json,
-		last_updated,
-		valid_until)
-		VALUES ($1, $2, $3, $4, $5, $6)`
-	selectHealthStmt = `SELECT * FROM health WHERE (component_name = $1 AND component_id = $2 AND unit = $3)`
-	cleanHealthStmt  = `DELETE from health WHERE (component_name = $1 AND valid_until < $2)`
-)
-
 type StoredReport struct {
 	ComponentName   string
 	ComponentID     string
@@ -38,11 +21,52 @@ type StoredReport struct {
 	ValidUntil      time.Time
 }
 
+const (
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = 50 * time.Millisecond
+)
+
 // StorageModule is the module that save health checks results in Storage DB.
 type StorageModule struct {
 	componentName string
 	componentID   string
 	db            Storage
+	dialect       Dialect
+	maxRetries    int
+	retryBackoff  time.Duration
+	tracer        opentracing.Tracer
+}
+
+// StorageOption is an option of the storage module.
+type StorageOption func(*StorageModule)
+
+// SQLDialect sets the SQL dialect of the DB. The default is Cockroach.
+func SQLDialect(d Dialect) StorageOption {
+	return func(c *StorageModule) {
+		c.dialect = d
+	}
+}
+
+// MaxRetries sets the number of times a write is retried after a serialization failure.
+func MaxRetries(n int) StorageOption {
+	return func(c *StorageModule) {
+		c.maxRetries = n
+	}
+}
+
+// RetryBackoff sets the wait before the first retry. It doubles after each retry.
+func RetryBackoff(d time.Duration) StorageOption {
+	return func(c *StorageModule) {
+		c.retryBackoff = d
+	}
+}
+
+// Tracer sets the tracer of the module. Each statement executed during a traced request or job
+// has its own span, with the statement in the tag "db.statement".
+func Tracer(tracer opentracing.Tracer) StorageOption {
+	return func(c *StorageModule) {
+		c.tracer = tracer
+	}
 }
 
 type Storage interface {
@@ -50,22 +74,29 @@ type Storage interface {
 	Query(query string, args ...interface{}) (*sql.Rows, error)
 }
 
-// NewStorageModule returns the storage module.
-func NewStorageModule(componentName, componentID string, db Storage) *StorageModule {
-	// Init DB: create health table.
-	db.Exec(createHealthTblStmt)
-
-	return &StorageModule{
+// NewStorageModule returns the storage module. The health table is created by the
+// schema migrations (see 'flakid migrate').
+func NewStorageModule(componentName, componentID string, db Storage, options ...StorageOption) *StorageModule {
+	var m = &StorageModule{
 		componentName: componentName,
 		componentID:   componentID,
 		db:            db,
+		dialect:       Cockroach,
+		maxRetries:    defaultMaxRetries,
+		retryBackoff:  defaultRetryBackoff,
+		tracer:        opentracing.NoopTracer{},
 	}
+
+	for _, opt := range options {
+		opt(m)
+	}
+	return m
 }
 
 // Update updates the health checks reports stored in DB with the values 'jsonReports'.
-func (c *StorageModule) Update(unit string, validity time.Duration, jsonReports json.RawMessage) error {
+func (c *StorageModule) Update(ctx context.Context, unit string, validity time.Duration, jsonReports json.RawMessage) error {
 	var now = time.Now()
-	var _, err = c.db.Exec(upsertHealthStmt, c.componentName, c.componentID, unit, string(jsonReports), now.UTC(), now.Add(validity).UTC())
+	var err = c.exec(ctx, c.dialect.upsertHealthStmt, c.componentName, c.componentID, unit, string(jsonReports), now.UTC(), now.Add(validity).UTC())
 
 	if err != nil {
 		return errors.Wrapf(err, "component '%s' with id '%s' could not update health check for unit '%s'", c.componentName, c.componentID, unit)
@@ -75,8 +106,10 @@ func (c *StorageModule) Update(unit string, validity time.Duration, jsonReports
 }
 
 // Read reads the reports in DB.
-func (c *StorageModule) Read(unit string) (StoredReport, error) {
-	var rows, err = c.db.Query(selectHealthStmt, c.componentName, c.componentID, unit)
+func (c *StorageModule) Read(ctx context.Context, unit string) (StoredReport, error) {
+	var span = c.startSpan(ctx, "health_db_query", c.dialect.selectHealthStmt)
+	var rows, err = c.db.Query(c.dialect.selectHealthStmt, c.componentName, c.componentID, unit)
+	finishSpan(span, err)
 	if err != nil {
 		return StoredReport{}, errors.Wrapf(err, "component '%s' with id '%s' could not read health check '%s': %s", c.componentName, c.componentID, unit, err)
 	}
@@ -112,8 +145,8 @@ func (c *StorageModule) Read(unit string) (StoredReport, error) {
 }
 
 // Clean deletes the old test reports that are no longer valid from the health DB table.
-func (c *StorageModule) Clean() error {
-	var _, err = c.db.Exec(cleanHealthStmt, c.componentName, time.Now().UTC())
+func (c *StorageModule) Clean(ctx context.Context) error {
+	var err = c.exec(ctx, c.dialect.cleanHealthStmt, c.componentName, time.Now().UTC())
 
 	if err != nil {
 		return errors.Wrapf(err, "component '%s' with id '%s' could not clean health checks", c.componentName, c.componentID)
@@ -121,3 +154,55 @@ func (c *StorageModule) Clean() error {
 
 	return nil
 }
+
+// exec executes the write statement. The serialization failures are transparently retried,
+// with an exponential backoff.
+func (c *StorageModule) exec(ctx context.Context, query string, args ...interface{}) error {
+	var backoff = c.retryBackoff
+	var err error
+
+	for i := 0; ; i++ {
+		var span = c.startSpan(ctx, "health_db_exec", query)
+		span.SetTag("attempt", i+1)
+		_, err = c.db.Exec(query, args...)
+		finishSpan(span, err)
+
+		if err == nil || !isRetryable(err) || i >= c.maxRetries {
+			return err
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// startSpan starts the span of the statement, child of the span of the context. Out of a traced
+// request or job, the span is a no-op span.
+func (c *StorageModule) startSpan(ctx context.Context, operationName, query string) opentracing.Span {
+	var parent = opentracing.SpanFromContext(ctx)
+	if parent == nil {
+		return opentracing.NoopTracer{}.StartSpan(operationName)
+	}
+
+	var span = c.tracer.StartSpan(operationName, opentracing.ChildOf(parent.Context()))
+	otag.DBType.Set(span, "sql")
+	span.SetTag("db.dialect", c.dialect.Name)
+	otag.DBStatement.Set(span, query)
+	return span
+}
+
+// finishSpan records the error, if any, and finishes the span.
+func finishSpan(span opentracing.Span, err error) {
+	if err != nil {
+		otag.Error.Set(span, true)
+		span.LogKV("error", err.Error())
+	}
+	span.Finish()
+}
+
+// isRetryable returns true if the error is a serialization failure (SQLSTATE 40001), i.e. a
+// transaction conflict that the DB asks the client to retry.
+func isRetryable(err error) bool {
+	var pqErr, ok = errors.Cause(err).(*pq.Error)
+	return ok && pqErr.Code == "40001"
+}
@@ -3,6 +3,7 @@
 package health_test
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"flag"
@@ -12,76 +13,136 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cloudtrust/flaki-service/internal/migration"
 	. "github.com/cloudtrust/flaki-service/pkg/health"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
 var (
-	hostPort = flag.String("hostport", "127.0.0.1:26257", "cockroach host:port")
-	user     = flag.String("user", "cockroach", "user name")
-	db       = flag.String("db", "health", "database name")
+	hostPort   = flag.String("hostport", "127.0.0.1:26257", "cockroach host:port")
+	user       = flag.String("user", "cockroach", "user name")
+	db         = flag.String("db", "health", "database name")
+	pgHostPort = flag.String("pghostport", "127.0.0.1:5432", "postgres host:port")
+	pgUser     = flag.String("pguser", "postgres", "postgres user name")
+	pgDB       = flag.String("pgdb", "health", "postgres database name")
 )
 
-func TestIntNewStorageModule(t *testing.T) {
-	var db = setupCleanDB(t)
-	rand.Seed(time.Now().UnixNano())
-
-	var (
-		componentName = "flaki-service"
-		componentID   = strconv.FormatUint(rand.Uint64(), 10)
-	)
-
-	// The table health does not exists.
-	_, err := db.Exec("SELECT * from health")
-	assert.NotNil(t, err)
+// The integration tests are executed against each dialect.
+var dialects = []Dialect{Cockroach, PostgreSQL}
 
-	var _ = NewStorageModule(componentName, componentID, db)
-
-	// NewStorageModule create table health.
-	_, err = db.Exec("SELECT * from health")
-	assert.Nil(t, err)
+func TestIntNewStorageModule(t *testing.T) {
+	for _, d := range dialects {
+		t.Run(d.Name, func(t *testing.T) {
+			var db = setupCleanDB(t, d)
+			rand.Seed(time.Now().UnixNano())
+
+			var (
+				componentName = "flaki-service"
+				componentID   = strconv.FormatUint(rand.Uint64(), 10)
+			)
+
+			// The table health is created by the migrations.
+			_, err := db.Exec("SELECT * from health")
+			assert.Nil(t, err)
+
+			var _ = NewStorageModule(componentName, componentID, db, SQLDialect(d))
+		})
+	}
 }
 
 func TestIntRead(t *testing.T) {
-	var db = setupCleanDB(t)
-	rand.Seed(time.Now().UnixNano())
-
-	var (
-		componentName = "flaki-service"
-		componentID   = strconv.FormatUint(rand.Uint64(), 10)
-		unit          = "influx"
-		reports       = json.RawMessage(`[{"name":"ping", "duration":"1s", "status":"OK", "error":"Error"}]`)
-	)
-
-	var m = NewStorageModule(componentName, componentID, db)
-
-	// Read health checks report for 'influx', it should be empty now.
-	var r, err = m.Read(unit)
-	assert.Nil(t, err)
-	fmt.Println(string(r.Reports))
-	assert.Zero(t, len(r.Reports))
-
-	// Save a health check report in DB.
-	err = m.Update(unit, 10 * time.Second, reports)
-	assert.Nil(t, err)
-
-	// Read health checks report for 'influx', now there is one result.
-	r, err = m.Read(unit)
-	assert.Nil(t, err)
+	for _, d := range dialects {
+		t.Run(d.Name, func(t *testing.T) {
+			var db = setupCleanDB(t, d)
+			rand.Seed(time.Now().UnixNano())
+
+			var (
+				componentName = "flaki-service"
+				componentID   = strconv.FormatUint(rand.Uint64(), 10)
+				unit          = "influx"
+				reports       = json.RawMessage(`[{"name":"ping", "duration":"1s", "status":"OK", "error":"Error"}]`)
+			)
+
+			var m = NewStorageModule(componentName, componentID, db, SQLDialect(d))
+
+			// Read health checks report for 'influx', it should be empty now.
+			var r, err = m.Read(context.Background(), unit)
+			assert.Nil(t, err)
+			assert.Zero(t, len(r.Reports))
+
+			// Save a health check report in DB.
+			err = m.Update(context.Background(), unit, 10*time.Second, reports)
+			assert.Nil(t, err)
+
+			// Read health checks report for 'influx', now there is one result.
+			r, err = m.Read(context.Background(), unit)
+			assert.Nil(t, err)
+
+			var aaa []map[string]string
+			json.Unmarshal(r.Reports, &aaa)
+			assert.Equal(t, "ping", aaa[0]["name"])
+			assert.Equal(t, "1s", aaa[0]["duration"])
+			assert.Equal(t, "OK", aaa[0]["status"])
+			assert.Equal(t, "Error", aaa[0]["error"])
+
+			// A second update replaces the report.
+			err = m.Update(context.Background(), unit, 10*time.Second, json.RawMessage(`[{"name":"ping", "duration":"2s", "status":"KO"}]`))
+			assert.Nil(t, err)
+
+			r, err = m.Read(context.Background(), unit)
+			assert.Nil(t, err)
+			json.Unmarshal(r.Reports, &aaa)
+			assert.Equal(t, "2s", aaa[0]["duration"])
+			assert.Equal(t, "KO", aaa[0]["status"])
+		})
+	}
+}
 
-	var aaa []map[string]string
-	json.Unmarshal(r.Reports, &aaa)
-	assert.Equal(t, "ping", aaa[0]["name"])
-	assert.Equal(t, "1s", aaa[0]["duration"])
-	assert.Equal(t, "OK", aaa[0]["status"])
-	assert.Equal(t, "Error", aaa[0]["error"])
+func TestIntClean(t *testing.T) {
+	for _, d := range dialects {
+		t.Run(d.Name, func(t *testing.T) {
+			var db = setupCleanDB(t, d)
+			rand.Seed(time.Now().UnixNano())
+
+			var (
+				componentName = "flaki-service"
+				componentID   = strconv.FormatUint(rand.Uint64(), 10)
+				unit          = "influx"
+			)
+
+			var m = NewStorageModule(componentName, componentID, db, SQLDialect(d))
+
+			// Save a report that is already expired, it is removed by Clean.
+			var err = m.Update(context.Background(), unit, -1*time.Second, json.RawMessage(`[]`))
+			assert.Nil(t, err)
+			assert.Nil(t, m.Clean(context.Background()))
+
+			var r StoredReport
+			r, err = m.Read(context.Background(), unit)
+			assert.Nil(t, err)
+			assert.Zero(t, len(r.Reports))
+		})
+	}
 }
 
-func setupCleanDB(t *testing.T) *sql.DB {
-	var db, err = sql.Open("postgres", fmt.Sprintf("postgresql://%s@%s/%s?sslmode=disable", *user, *hostPort, *db))
+func setupCleanDB(t *testing.T, d Dialect) *sql.DB {
+	var dsn string
+	switch d.Name {
+	case PostgreSQL.Name:
+		dsn = fmt.Sprintf("postgresql://%s@%s/%s?sslmode=disable", *pgUser, *pgHostPort, *pgDB)
+	default:
+		dsn = fmt.Sprintf("postgresql://%s@%s/%s?sslmode=disable", *user, *hostPort, *db)
+	}
+
+	var db, err = sql.Open("postgres", dsn)
 	assert.Nil(t, err)
 	// Clean
 	db.Exec("DROP table health")
+	db.Exec("DROP table schema_migrations")
+
+	// Create tables.
+	_, err = migration.NewMigrator(db).Up()
+	assert.Nil(t, err)
 	return db
 }
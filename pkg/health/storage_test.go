@@ -3,6 +3,7 @@ package health_test
 //go:generate mockgen -destination=./mock/storage.go -package=mock -mock_names=Storage=Storage  github.com/cloudtrust/flaki-service/pkg/health Storage
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/rand"
@@ -13,19 +14,12 @@ import (
 	. "github.com/cloudtrust/flaki-service/pkg/health"
 	"github.com/cloudtrust/flaki-service/pkg/health/mock"
 	"github.com/golang/mock/gomock"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
 )
 
 const (
-	createHealthTblStmt = `CREATE TABLE IF NOT EXISTS health (
-		component_name STRING,
-		component_id STRING,
-		unit STRING,
-		json JSONB,
-		last_updated TIMESTAMPTZ,
-		valid_until TIMESTAMPTZ,
-		PRIMARY KEY (component_name, component_id, unit))`
 	upsertHealthStmt = `UPSERT INTO health (
 		component_name,
 		component_id,
@@ -34,6 +28,18 @@ const (
 		last_updated,
 		valid_until)
 		VALUES ($1, $2, $3, $4, $5, $6)`
+	upsertPostgresHealthStmt = `INSERT INTO health (
+		component_name,
+		component_id,
+		unit,
+		json,
+		last_updated,
+		valid_until)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (component_name, component_id, unit) DO UPDATE SET
+		json = EXCLUDED.json,
+		last_updated = EXCLUDED.last_updated,
+		valid_until = EXCLUDED.valid_until`
 	selectHealthStmt = `SELECT * FROM health WHERE (component_name = $1 AND component_id = $2 AND unit = $3)`
 	cleanHealthStmt  = `DELETE from health WHERE (component_name = $1 AND valid_until < $2)`
 )
@@ -49,7 +55,7 @@ func TestNewStorageModule(t *testing.T) {
 		componentID   = strconv.FormatUint(rand.Uint64(), 10)
 	)
 
-	mockStorage.EXPECT().Exec(createHealthTblStmt).Return(nil, nil).Times(1)
+	// The module does not touch the DB, the tables are created by the migrations.
 	_ = NewStorageModule(componentName, componentID, mockStorage)
 }
 
@@ -66,11 +72,10 @@ func TestUpdate(t *testing.T) {
 		reports       = json.RawMessage(`{}`)
 	)
 
-	mockStorage.EXPECT().Exec(createHealthTblStmt).Return(nil, nil).Times(1)
 	var m = NewStorageModule(componentName, componentID, mockStorage)
 
 	mockStorage.EXPECT().Exec(upsertHealthStmt, componentName, componentID, unit, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
-	var err = m.Update(unit, 0, reports)
+	var err = m.Update(context.Background(), unit, 0, reports)
 	assert.Nil(t, err)
 }
 
@@ -87,10 +92,132 @@ func TestUpdateFail(t *testing.T) {
 		reports       = json.RawMessage(`{}`)
 	)
 
-	mockStorage.EXPECT().Exec(createHealthTblStmt).Return(nil, nil).Times(1)
 	var m = NewStorageModule(componentName, componentID, mockStorage)
 
 	mockStorage.EXPECT().Exec(upsertHealthStmt, componentName, componentID, unit, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("fail")).Times(1)
-	var err = m.Update(unit, 0, reports)
+	var err = m.Update(context.Background(), unit, 0, reports)
+	assert.NotNil(t, err)
+}
+
+func TestUpdateRetry(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockStorage = mock.NewStorage(mockCtrl)
+	rand.Seed(time.Now().UnixNano())
+
+	var (
+		componentName = "flaki-service"
+		componentID   = strconv.FormatUint(rand.Uint64(), 10)
+		unit          = "influx"
+		reports       = json.RawMessage(`{}`)
+		retryErr      = &pq.Error{Code: "40001", Message: "restart transaction"}
+	)
+
+	var m = NewStorageModule(componentName, componentID, mockStorage, MaxRetries(2), RetryBackoff(time.Millisecond))
+
+	// Serialization failures are retried.
+	gomock.InOrder(
+		mockStorage.EXPECT().Exec(upsertHealthStmt, componentName, componentID, unit, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, retryErr).Times(2),
+		mockStorage.EXPECT().Exec(upsertHealthStmt, componentName, componentID, unit, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(1),
+	)
+	assert.Nil(t, m.Update(context.Background(), unit, 0, reports))
+
+	// After MaxRetries retries, the error is returned.
+	mockStorage.EXPECT().Exec(upsertHealthStmt, componentName, componentID, unit, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, retryErr).Times(3)
+	assert.NotNil(t, m.Update(context.Background(), unit, 0, reports))
+
+	// Other errors are not retried.
+	mockStorage.EXPECT().Exec(cleanHealthStmt, componentName, gomock.Any()).Return(nil, fmt.Errorf("fail")).Times(1)
+	assert.NotNil(t, m.Clean(context.Background()))
+}
+
+func TestStorageTracing(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockStorage = mock.NewStorage(mockCtrl)
+	var mockTracer = mock.NewTracer(mockCtrl)
+	var mockSpan = mock.NewSpan(mockCtrl)
+	var mockSpanContext = mock.NewSpanContext(mockCtrl)
+	mockSpan.EXPECT().Tracer().Return(mockTracer).AnyTimes()
+	rand.Seed(time.Now().UnixNano())
+
+	var (
+		componentName = "flaki-service"
+		componentID   = strconv.FormatUint(rand.Uint64(), 10)
+		unit          = "influx"
+		reports       = json.RawMessage(`{}`)
+		retryErr      = &pq.Error{Code: "40001", Message: "restart transaction"}
+		ctx           = opentracing.ContextWithSpan(context.Background(), mockSpan)
+	)
+
+	var m = NewStorageModule(componentName, componentID, mockStorage, Tracer(mockTracer), MaxRetries(1), RetryBackoff(time.Millisecond))
+
+	// One span per attempt, the failed ones are tagged with the error.
+	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(2)
+	mockTracer.EXPECT().StartSpan("health_db_exec", gomock.Any()).Return(mockSpan).Times(2)
+	mockSpan.EXPECT().SetTag("db.type", "sql").Return(mockSpan).Times(2)
+	mockSpan.EXPECT().SetTag("db.dialect", "cockroach").Return(mockSpan).Times(2)
+	mockSpan.EXPECT().SetTag("db.statement", upsertHealthStmt).Return(mockSpan).Times(2)
+	mockSpan.EXPECT().SetTag("attempt", 1).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("attempt", 2).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("error", true).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().LogKV("error", retryErr.Error()).Return().Times(1)
+	mockSpan.EXPECT().Finish().Return().Times(2)
+	gomock.InOrder(
+		mockStorage.EXPECT().Exec(upsertHealthStmt, componentName, componentID, unit, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, retryErr).Times(1),
+		mockStorage.EXPECT().Exec(upsertHealthStmt, componentName, componentID, unit, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(1),
+	)
+	assert.Nil(t, m.Update(ctx, unit, 0, reports))
+
+	// Query.
+	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
+	mockTracer.EXPECT().StartSpan("health_db_query", gomock.Any()).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("db.type", "sql").Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("db.dialect", "cockroach").Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("db.statement", selectHealthStmt).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("error", true).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().LogKV("error", "fail").Return().Times(1)
+	mockSpan.EXPECT().Finish().Return().Times(1)
+	mockStorage.EXPECT().Query(selectHealthStmt, componentName, componentID, unit).Return(nil, fmt.Errorf("fail")).Times(1)
+	var _, err = m.Read(ctx, unit)
+	assert.NotNil(t, err)
+
+	// Out of a traced request, there is no span.
+	mockStorage.EXPECT().Exec(cleanHealthStmt, componentName, gomock.Any()).Return(nil, nil).Times(1)
+	assert.Nil(t, m.Clean(context.Background()))
+}
+
+func TestPostgreSQLDialect(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockStorage = mock.NewStorage(mockCtrl)
+	rand.Seed(time.Now().UnixNano())
+
+	var (
+		componentName = "flaki-service"
+		componentID   = strconv.FormatUint(rand.Uint64(), 10)
+		unit          = "influx"
+		reports       = json.RawMessage(`{}`)
+	)
+
+	var m = NewStorageModule(componentName, componentID, mockStorage, SQLDialect(PostgreSQL))
+
+	mockStorage.EXPECT().Exec(upsertPostgresHealthStmt, componentName, componentID, unit, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+	assert.Nil(t, m.Update(context.Background(), unit, 0, reports))
+
+	mockStorage.EXPECT().Exec(cleanHealthStmt, componentName, gomock.Any()).Return(nil, nil).Times(1)
+	assert.Nil(t, m.Clean(context.Background()))
+}
+
+func TestGetDialect(t *testing.T) {
+	var d, err = GetDialect("cockroach")
+	assert.Nil(t, err)
+	assert.Equal(t, Cockroach, d)
+
+	d, err = GetDialect("postgres")
+	assert.Nil(t, err)
+	assert.Equal(t, PostgreSQL, d)
+
+	_, err = GetDialect("mysql")
 	assert.NotNil(t, err)
 }
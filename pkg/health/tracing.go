@@ -0,0 +1,112 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-kit/kit/endpoint"
+	opentracing "github.com/opentracing/opentracing-go"
+	otag "github.com/opentracing/opentracing-go/ext"
+)
+
+// MakeEndpointTracingMW makes a tracing middleware at endpoint level. The span is a child of
+// the span of the context, if any.
+func MakeEndpointTracingMW(tracer opentracing.Tracer, operationName string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if span := opentracing.SpanFromContext(ctx); span != nil {
+				span = tracer.StartSpan(operationName, opentracing.ChildOf(span.Context()))
+				defer span.Finish()
+				setCorrelationIDTag(ctx, span)
+
+				var reply, err = next(opentracing.ContextWithSpan(ctx, span), req)
+				if err != nil {
+					otag.Error.Set(span, true)
+					span.LogKV("error", err.Error())
+				}
+				return reply, err
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// Tracing middleware at component level.
+type componentTracingMW struct {
+	tracer opentracing.Tracer
+	next   HealthChecker
+}
+
+// MakeComponentTracingMW makes a tracing middleware at component level.
+func MakeComponentTracingMW(tracer opentracing.Tracer) func(HealthChecker) HealthChecker {
+	return func(next HealthChecker) HealthChecker {
+		return &componentTracingMW{
+			tracer: tracer,
+			next:   next,
+		}
+	}
+}
+
+// componentTracingMW implements Component.
+func (m *componentTracingMW) ExecInfluxHealthChecks(ctx context.Context) json.RawMessage {
+	return m.trace(ctx, "exec_influx_health_checks", m.next.ExecInfluxHealthChecks)
+}
+
+// componentTracingMW implements Component.
+func (m *componentTracingMW) ReadInfluxHealthChecks(ctx context.Context) json.RawMessage {
+	return m.trace(ctx, "read_influx_health_checks", m.next.ReadInfluxHealthChecks)
+}
+
+// componentTracingMW implements Component.
+func (m *componentTracingMW) ExecJaegerHealthChecks(ctx context.Context) json.RawMessage {
+	return m.trace(ctx, "exec_jaeger_health_checks", m.next.ExecJaegerHealthChecks)
+}
+
+// componentTracingMW implements Component.
+func (m *componentTracingMW) ReadJaegerHealthChecks(ctx context.Context) json.RawMessage {
+	return m.trace(ctx, "read_jaeger_health_checks", m.next.ReadJaegerHealthChecks)
+}
+
+// componentTracingMW implements Component.
+func (m *componentTracingMW) ExecRedisHealthChecks(ctx context.Context) json.RawMessage {
+	return m.trace(ctx, "exec_redis_health_checks", m.next.ExecRedisHealthChecks)
+}
+
+// componentTracingMW implements Component.
+func (m *componentTracingMW) ReadRedisHealthChecks(ctx context.Context) json.RawMessage {
+	return m.trace(ctx, "read_redis_health_checks", m.next.ReadRedisHealthChecks)
+}
+
+// componentTracingMW implements Component.
+func (m *componentTracingMW) ExecSentryHealthChecks(ctx context.Context) json.RawMessage {
+	return m.trace(ctx, "exec_sentry_health_checks", m.next.ExecSentryHealthChecks)
+}
+
+// componentTracingMW implements Component.
+func (m *componentTracingMW) ReadSentryHealthChecks(ctx context.Context) json.RawMessage {
+	return m.trace(ctx, "read_sentry_health_checks", m.next.ReadSentryHealthChecks)
+}
+
+// componentTracingMW implements Component.
+func (m *componentTracingMW) AllHealthChecks(ctx context.Context) json.RawMessage {
+	return m.trace(ctx, "all_health_checks", m.next.AllHealthChecks)
+}
+
+// trace calls the health check in a child span of the span of the context, if any.
+func (m *componentTracingMW) trace(ctx context.Context, operationName string, healthChecks func(context.Context) json.RawMessage) json.RawMessage {
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span = m.tracer.StartSpan(operationName, opentracing.ChildOf(span.Context()))
+		defer span.Finish()
+		setCorrelationIDTag(ctx, span)
+
+		return healthChecks(opentracing.ContextWithSpan(ctx, span))
+	}
+	return healthChecks(ctx)
+}
+
+// setCorrelationIDTag tags the span with the correlation ID of the context, if any.
+func setCorrelationIDTag(ctx context.Context, span opentracing.Span) {
+	if corrID, ok := ctx.Value("correlation_id").(string); ok {
+		span.SetTag("correlation_id", corrID)
+	}
+}
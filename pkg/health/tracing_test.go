@@ -0,0 +1,110 @@
+package health_test
+
+//go:generate mockgen -destination=./mock/tracing.go -package=mock -mock_names=Tracer=Tracer,Span=Span,SpanContext=SpanContext github.com/opentracing/opentracing-go Tracer,Span,SpanContext
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/cloudtrust/flaki-service/pkg/health"
+	"github.com/cloudtrust/flaki-service/pkg/health/mock"
+	"github.com/golang/mock/gomock"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointTracingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockTracer = mock.NewTracer(mockCtrl)
+	var mockSpan = mock.NewSpan(mockCtrl)
+	var mockSpanContext = mock.NewSpanContext(mockCtrl)
+	mockSpan.EXPECT().Tracer().Return(mockTracer).AnyTimes()
+
+	rand.Seed(time.Now().UnixNano())
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+	var ctx = opentracing.ContextWithSpan(context.WithValue(context.Background(), "correlation_id", corrID), mockSpan)
+	var rep = json.RawMessage(`{"JSON":"MOCK_CONTENT"}`)
+
+	var m = MakeEndpointTracingMW(mockTracer, "exec_influx_health_endpoint")(func(context.Context, interface{}) (interface{}, error) {
+		return rep, nil
+	})
+
+	// With existing span.
+	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
+	mockTracer.EXPECT().StartSpan("exec_influx_health_endpoint", gomock.Any()).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("correlation_id", corrID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().Finish().Return().Times(1)
+	var reply, err = m(ctx, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, rep, reply)
+
+	// Without existing span.
+	reply, err = m(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, rep, reply)
+
+	// The error is recorded.
+	m = MakeEndpointTracingMW(mockTracer, "exec_influx_health_endpoint")(func(context.Context, interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("fail")
+	})
+	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
+	mockTracer.EXPECT().StartSpan("exec_influx_health_endpoint", gomock.Any()).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("correlation_id", corrID).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().SetTag("error", true).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().LogKV("error", "fail").Return().Times(1)
+	mockSpan.EXPECT().Finish().Return().Times(1)
+	_, err = m(ctx, nil)
+	assert.NotNil(t, err)
+}
+
+func TestComponentTracingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockTracer = mock.NewTracer(mockCtrl)
+	var mockSpan = mock.NewSpan(mockCtrl)
+	var mockSpanContext = mock.NewSpanContext(mockCtrl)
+	var mockComponent = mock.NewHealthChecker(mockCtrl)
+	mockSpan.EXPECT().Tracer().Return(mockTracer).AnyTimes()
+
+	var m = MakeComponentTracingMW(mockTracer)(mockComponent)
+
+	rand.Seed(time.Now().UnixNano())
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+	var ctx = opentracing.ContextWithSpan(context.WithValue(context.Background(), "correlation_id", corrID), mockSpan)
+	var rep = json.RawMessage(`{"JSON":"MOCK_CONTENT"}`)
+
+	var healthChecks = map[string]struct {
+		call   func(context.Context) json.RawMessage
+		expect func() *gomock.Call
+	}{
+		"exec_influx_health_checks": {m.ExecInfluxHealthChecks, func() *gomock.Call { return mockComponent.EXPECT().ExecInfluxHealthChecks(gomock.Any()) }},
+		"read_influx_health_checks": {m.ReadInfluxHealthChecks, func() *gomock.Call { return mockComponent.EXPECT().ReadInfluxHealthChecks(gomock.Any()) }},
+		"exec_jaeger_health_checks": {m.ExecJaegerHealthChecks, func() *gomock.Call { return mockComponent.EXPECT().ExecJaegerHealthChecks(gomock.Any()) }},
+		"read_jaeger_health_checks": {m.ReadJaegerHealthChecks, func() *gomock.Call { return mockComponent.EXPECT().ReadJaegerHealthChecks(gomock.Any()) }},
+		"exec_redis_health_checks":  {m.ExecRedisHealthChecks, func() *gomock.Call { return mockComponent.EXPECT().ExecRedisHealthChecks(gomock.Any()) }},
+		"read_redis_health_checks":  {m.ReadRedisHealthChecks, func() *gomock.Call { return mockComponent.EXPECT().ReadRedisHealthChecks(gomock.Any()) }},
+		"exec_sentry_health_checks": {m.ExecSentryHealthChecks, func() *gomock.Call { return mockComponent.EXPECT().ExecSentryHealthChecks(gomock.Any()) }},
+		"read_sentry_health_checks": {m.ReadSentryHealthChecks, func() *gomock.Call { return mockComponent.EXPECT().ReadSentryHealthChecks(gomock.Any()) }},
+		"all_health_checks":         {m.AllHealthChecks, func() *gomock.Call { return mockComponent.EXPECT().AllHealthChecks(gomock.Any()) }},
+	}
+
+	for operationName, hc := range healthChecks {
+		// With existing span.
+		mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
+		mockTracer.EXPECT().StartSpan(operationName, gomock.Any()).Return(mockSpan).Times(1)
+		mockSpan.EXPECT().SetTag("correlation_id", corrID).Return(mockSpan).Times(1)
+		mockSpan.EXPECT().Finish().Return().Times(1)
+		hc.expect().Return(rep).Times(1)
+		assert.Equal(t, rep, hc.call(ctx))
+
+		// Without existing span.
+		hc.expect().Return(rep).Times(1)
+		assert.Equal(t, rep, hc.call(context.Background()))
+	}
+}
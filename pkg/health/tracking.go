@@ -0,0 +1,61 @@
+package health
+
+//go:generate mockgen -destination=./mock/tracking.go -package=mock -mock_names=ErrorTracker=ErrorTracker github.com/cloudtrust/flaki-service/pkg/health ErrorTracker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// ErrorTracker is the interface of the error tracking provider.
+type ErrorTracker interface {
+	CaptureError(err error, tags map[string]string) string
+}
+
+// Tracking middleware at store module level.
+type storeModuleTrackingMW struct {
+	tracker ErrorTracker
+	logger  log.Logger
+	next    StoreModule
+}
+
+// MakeStoreModuleTrackingMW makes an error tracking middleware at store module level. The health
+// component turns the DB errors into KO reports, this middleware logs them and sends them to the
+// error tracker.
+func MakeStoreModuleTrackingMW(tracker ErrorTracker, logger log.Logger) func(StoreModule) StoreModule {
+	return func(next StoreModule) StoreModule {
+		return &storeModuleTrackingMW{
+			tracker: tracker,
+			logger:  logger,
+			next:    next,
+		}
+	}
+}
+
+// storeModuleTrackingMW implements StoreModule.
+func (m *storeModuleTrackingMW) Read(ctx context.Context, name string) (StoredReport, error) {
+	var report, err = m.next.Read(ctx, name)
+	if err != nil {
+		m.track(ctx, "Read", name, err)
+	}
+	return report, err
+}
+
+// storeModuleTrackingMW implements StoreModule.
+func (m *storeModuleTrackingMW) Update(ctx context.Context, unit string, validity time.Duration, reports json.RawMessage) error {
+	var err = m.next.Update(ctx, unit, validity, reports)
+	if err != nil {
+		m.track(ctx, "Update", unit, err)
+	}
+	return err
+}
+
+func (m *storeModuleTrackingMW) track(ctx context.Context, operation, unit string, err error) {
+	var corrID, _ = ctx.Value("correlation_id").(string)
+	m.tracker.CaptureError(err, map[string]string{"unit": unit, "operation": operation, "correlation_id": corrID})
+	level.Error(m.logger).Log("unit", unit, "operation", operation, "correlation_id", corrID, "error", err.Error())
+}
@@ -0,0 +1,54 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/cloudtrust/flaki-service/pkg/health"
+	"github.com/cloudtrust/flaki-service/pkg/health/mock"
+	"github.com/go-kit/kit/log/level"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreModuleTrackingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockStoreModule = mock.NewStoreModule(mockCtrl)
+	var mockTracker = mock.NewErrorTracker(mockCtrl)
+	var mockLogger = mock.NewLogger(mockCtrl)
+
+	var m = MakeStoreModuleTrackingMW(mockTracker, mockLogger)(mockStoreModule)
+
+	rand.Seed(time.Now().UnixNano())
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+	var ctx = context.WithValue(context.Background(), "correlation_id", corrID)
+	var reports = json.RawMessage(`[]`)
+
+	// Read.
+	mockStoreModule.EXPECT().Read(ctx, "influx").Return(StoredReport{}, nil).Times(1)
+	var _, err = m.Read(ctx, "influx")
+	assert.Nil(t, err)
+
+	// Read error.
+	mockStoreModule.EXPECT().Read(ctx, "influx").Return(StoredReport{}, fmt.Errorf("fail")).Times(1)
+	mockTracker.EXPECT().CaptureError(fmt.Errorf("fail"), map[string]string{"unit": "influx", "operation": "Read", "correlation_id": corrID}).Return("").Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "unit", "influx", "operation", "Read", "correlation_id", corrID, "error", "fail").Return(nil).Times(1)
+	_, err = m.Read(ctx, "influx")
+	assert.NotNil(t, err)
+
+	// Update.
+	mockStoreModule.EXPECT().Update(ctx, "redis", time.Minute, reports).Return(nil).Times(1)
+	assert.Nil(t, m.Update(ctx, "redis", time.Minute, reports))
+
+	// Update error without correlation ID.
+	mockStoreModule.EXPECT().Update(context.Background(), "redis", time.Minute, reports).Return(fmt.Errorf("fail")).Times(1)
+	mockTracker.EXPECT().CaptureError(fmt.Errorf("fail"), map[string]string{"unit": "redis", "operation": "Update", "correlation_id": ""}).Return("").Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "unit", "redis", "operation", "Update", "correlation_id", "", "error", "fail").Return(nil).Times(1)
+	assert.NotNil(t, m.Update(context.Background(), "redis", time.Minute, reports))
+}
@@ -0,0 +1,83 @@
+package job
+
+//go:generate mockgen -destination=./mock/component.go -package=mock -mock_names=JobManager=JobManager github.com/cloudtrust/flaki-service/pkg/job JobManager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints wraps the job manager behind a set of endpoints.
+type Endpoints struct {
+	ListJobs   endpoint.Endpoint
+	JobStatus  endpoint.Endpoint
+	TriggerJob endpoint.Endpoint
+	PauseJob   endpoint.Endpoint
+	ResumeJob  endpoint.Endpoint
+}
+
+// JobManager is the interface of the jobs manager.
+type JobManager interface {
+	List(context.Context) []JobInfo
+	Status(ctx context.Context, name string) (JobInfo, error)
+	Trigger(ctx context.Context, name string) error
+	Pause(ctx context.Context, name string) error
+	Resume(ctx context.Context, name string) error
+}
+
+// MakeListJobsEndpoint makes the endpoint that lists the registered jobs.
+func MakeListJobsEndpoint(m JobManager) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		return m.List(ctx), nil
+	}
+}
+
+// MakeJobStatusEndpoint makes the endpoint that returns the last execution status of a job.
+func MakeJobStatusEndpoint(m JobManager) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		switch name := req.(type) {
+		case string:
+			return m.Status(ctx, name)
+		default:
+			return nil, fmt.Errorf("wrong request type: %T", req)
+		}
+	}
+}
+
+// MakeTriggerJobEndpoint makes the endpoint that executes a job immediately.
+func MakeTriggerJobEndpoint(m JobManager) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		switch name := req.(type) {
+		case string:
+			return nil, m.Trigger(ctx, name)
+		default:
+			return nil, fmt.Errorf("wrong request type: %T", req)
+		}
+	}
+}
+
+// MakePauseJobEndpoint makes the endpoint that pauses a job.
+func MakePauseJobEndpoint(m JobManager) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		switch name := req.(type) {
+		case string:
+			return nil, m.Pause(ctx, name)
+		default:
+			return nil, fmt.Errorf("wrong request type: %T", req)
+		}
+	}
+}
+
+// MakeResumeJobEndpoint makes the endpoint that resumes a paused job.
+func MakeResumeJobEndpoint(m JobManager) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		switch name := req.(type) {
+		case string:
+			return nil, m.Resume(ctx, name)
+		default:
+			return nil, fmt.Errorf("wrong request type: %T", req)
+		}
+	}
+}
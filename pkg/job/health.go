@@ -2,19 +2,25 @@ package job
 
 import (
 	"context"
-	"time"
 	"encoding/json"
+	"time"
 
 	common "github.com/cloudtrust/common-healthcheck"
-	"github.com/cloudtrust/go-jobs/job"
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 )
 
 // Cockroach is the interface of the module that stores the health reports
 // in the DB.
 type Cockroach interface {
-	Update(unit string, validity time.Duration, jsonReports json.RawMessage) error
-	Clean() error
+	Update(ctx context.Context, unit string, validity time.Duration, jsonReports json.RawMessage) error
+	Clean(ctx context.Context) error
+}
+
+// HealthCheckValidity is the interface of the module that gives the validity of the health
+// checks results. It can change at runtime, when the configuration is reloaded.
+type HealthCheckValidity interface {
+	HealthCheckValidity(unit string) time.Duration
 }
 
 // Flaki is the interface of the IDs generator.
@@ -43,69 +49,69 @@ type SentryHealthChecker interface {
 }
 
 // MakeInfluxJob creates the job that periodically exectutes the health checks and save the result in DB.
-func MakeInfluxJob(influx InfluxHealthChecker, healthCheckValidity time.Duration, cockroach Cockroach) (*job.Job, error) {
+func MakeInfluxJob(influx InfluxHealthChecker, validity HealthCheckValidity, cockroach Cockroach) *Job {
 	var step1 = func(ctx context.Context, r interface{}) (interface{}, error) {
 		return influx.HealthChecks(ctx), nil
 	}
 
-	var step2 = func(_ context.Context, r interface{}) (interface{}, error) {
+	var step2 = func(ctx context.Context, r interface{}) (interface{}, error) {
 		var jsonReports, _ = json.Marshal(r)
-	
-		var err = cockroach.Update("influx", healthCheckValidity, jsonReports)
+
+		var err = cockroach.Update(ctx, "influx", validity.HealthCheckValidity("influx"), jsonReports)
 		return nil, err
 	}
-	return job.NewJob("influx", job.Steps(step1, step2))
+	return NewJob("influx", step1, step2)
 }
 
 // MakeJaegerJob creates the job that periodically exectutes the health checks and save the result in DB.
-func MakeJaegerJob(jaeger JaegerHealthChecker, healthCheckValidity time.Duration, cockroach Cockroach) (*job.Job, error) {
+func MakeJaegerJob(jaeger JaegerHealthChecker, validity HealthCheckValidity, cockroach Cockroach) *Job {
 	var step1 = func(ctx context.Context, r interface{}) (interface{}, error) {
 		return jaeger.HealthChecks(ctx), nil
 	}
-	var step2 = func(_ context.Context, r interface{}) (interface{}, error) {
+	var step2 = func(ctx context.Context, r interface{}) (interface{}, error) {
 		var jsonReports, _ = json.Marshal(r)
-	
-		var err = cockroach.Update("jaeger", healthCheckValidity, jsonReports)
+
+		var err = cockroach.Update(ctx, "jaeger", validity.HealthCheckValidity("jaeger"), jsonReports)
 		return nil, err
 	}
-	return job.NewJob("jaeger", job.Steps(step1, step2))
+	return NewJob("jaeger", step1, step2)
 }
 
 // MakeRedisJob creates the job that periodically exectutes the health checks and save the result in DB.
-func MakeRedisJob(redis RedisHealthChecker, healthCheckValidity time.Duration, cockroach Cockroach) (*job.Job, error) {
+func MakeRedisJob(redis RedisHealthChecker, validity HealthCheckValidity, cockroach Cockroach) *Job {
 	var step1 = func(ctx context.Context, r interface{}) (interface{}, error) {
 		return redis.HealthChecks(ctx), nil
 	}
-	var step2 = func(_ context.Context, r interface{}) (interface{}, error) {
+	var step2 = func(ctx context.Context, r interface{}) (interface{}, error) {
 		var jsonReports, _ = json.Marshal(r)
-	
-		var err = cockroach.Update("redis", healthCheckValidity, jsonReports)
+
+		var err = cockroach.Update(ctx, "redis", validity.HealthCheckValidity("redis"), jsonReports)
 		return nil, err
 	}
-	return job.NewJob("redis", job.Steps(step1, step2))
+	return NewJob("redis", step1, step2)
 }
 
 // MakeSentryJob creates the job that periodically exectutes the health checks and save the result in DB.
-func MakeSentryJob(sentry SentryHealthChecker, healthCheckValidity time.Duration, cockroach Cockroach) (*job.Job, error) {
+func MakeSentryJob(sentry SentryHealthChecker, validity HealthCheckValidity, cockroach Cockroach) *Job {
 	var step1 = func(ctx context.Context, r interface{}) (interface{}, error) {
 		return sentry.HealthChecks(ctx), nil
 	}
-	var step2 = func(_ context.Context, r interface{}) (interface{}, error) {
+	var step2 = func(ctx context.Context, r interface{}) (interface{}, error) {
 		var jsonReports, _ = json.Marshal(r)
-	
-		var err = cockroach.Update("sentry", healthCheckValidity, jsonReports)
+
+		var err = cockroach.Update(ctx, "sentry", validity.HealthCheckValidity("sentry"), jsonReports)
 		return nil, err
 	}
-	return job.NewJob("sentry", job.Steps(step1, step2))
+	return NewJob("sentry", step1, step2)
 }
 
 // MakeCleanCockroachJob creates the job that periodically exectutes the health checks and save the result in DB.
-func MakeCleanCockroachJob(cockroach Cockroach, logger log.Logger) (*job.Job, error) {
-	var clean = func(context.Context, interface{}) (interface{}, error) {
-		logger.Log("step", "clean")
-		return nil, cockroach.Clean()
+func MakeCleanCockroachJob(cockroach Cockroach, logger log.Logger) *Job {
+	var clean = func(ctx context.Context, _ interface{}) (interface{}, error) {
+		level.Debug(logger).Log("step", "clean")
+		return nil, cockroach.Clean(ctx)
 	}
-	return job.NewJob("clean", job.Steps(clean))
+	return NewJob("clean", clean)
 }
 
 // err return the string error that will be in the health report
@@ -0,0 +1,68 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/ratelimit"
+	http_transport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// MakeJobHandler makes a HTTP handler for a job endpoint. The job name is read
+// from the route variable "name".
+func MakeJobHandler(e endpoint.Endpoint) *http_transport.Server {
+	return http_transport.NewServer(e,
+		decodeJobRequest,
+		encodeJobReply,
+		http_transport.ServerErrorEncoder(jobErrorHandler),
+	)
+}
+
+// decodeJobRequest decodes the job request, i.e. the job name.
+func decodeJobRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return mux.Vars(r)["name"], nil
+}
+
+// encodeJobReply encodes the job reply.
+func encodeJobReply(_ context.Context, w http.ResponseWriter, rep interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if rep == nil {
+		w.WriteHeader(http.StatusOK)
+		return nil
+	}
+
+	var data, err = json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+	}
+
+	return nil
+}
+
+// jobErrorHandler encodes the job reply when there is an error.
+func jobErrorHandler(ctx context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	switch {
+	case errors.Cause(err) == ErrUnknownJob:
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Cause(err) == ErrJobRunning:
+		w.WriteHeader(http.StatusConflict)
+	case errors.Cause(err) == ratelimit.ErrLimited:
+		w.WriteHeader(http.StatusTooManyRequests)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	// Write error.
+	var reply, _ = json.MarshalIndent(map[string]string{"error": err.Error()}, "", "  ")
+	w.Write(reply)
+}
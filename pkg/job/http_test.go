@@ -0,0 +1,94 @@
+package job_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/cloudtrust/flaki-service/pkg/job"
+	"github.com/cloudtrust/flaki-service/pkg/job/mock"
+	"github.com/go-kit/kit/ratelimit"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobHandlers(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockManager = mock.NewJobManager(mockCtrl)
+
+	var route = mux.NewRouter()
+	route.Handle("/jobs", MakeJobHandler(MakeListJobsEndpoint(mockManager))).Methods("GET")
+	route.Handle("/jobs/{name}", MakeJobHandler(MakeJobStatusEndpoint(mockManager))).Methods("GET")
+	route.Handle("/jobs/{name}/trigger", MakeJobHandler(MakeTriggerJobEndpoint(mockManager))).Methods("POST")
+	route.Handle("/jobs/{name}/pause", MakeJobHandler(MakePauseJobEndpoint(mockManager))).Methods("POST")
+	route.Handle("/jobs/{name}/resume", MakeJobHandler(MakeResumeJobEndpoint(mockManager))).Methods("POST")
+
+	var serve = func(method, url string) *http.Response {
+		var w = httptest.NewRecorder()
+		route.ServeHTTP(w, httptest.NewRequest(method, url, nil))
+		return w.Result()
+	}
+
+	// List.
+	mockManager.EXPECT().List(gomock.Any()).Return([]JobInfo{{Name: "influx", Schedule: "@minutely"}}).Times(1)
+	{
+		var resp = serve("GET", "http://cloudtrust.io/jobs")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+
+		var body, _ = ioutil.ReadAll(resp.Body)
+		var infos []JobInfo
+		assert.Nil(t, json.Unmarshal(body, &infos))
+		assert.Equal(t, "influx", infos[0].Name)
+		assert.Equal(t, "@minutely", infos[0].Schedule)
+	}
+
+	// Status.
+	mockManager.EXPECT().Status(gomock.Any(), "influx").Return(JobInfo{Name: "influx"}, nil).Times(1)
+	assert.Equal(t, http.StatusOK, serve("GET", "http://cloudtrust.io/jobs/influx").StatusCode)
+
+	// Status of unknown job.
+	mockManager.EXPECT().Status(gomock.Any(), "unknown").Return(JobInfo{}, ErrUnknownJob).Times(1)
+	assert.Equal(t, http.StatusNotFound, serve("GET", "http://cloudtrust.io/jobs/unknown").StatusCode)
+
+	// Trigger.
+	mockManager.EXPECT().Trigger(gomock.Any(), "influx").Return(nil).Times(1)
+	assert.Equal(t, http.StatusOK, serve("POST", "http://cloudtrust.io/jobs/influx/trigger").StatusCode)
+
+	// Trigger running job.
+	mockManager.EXPECT().Trigger(gomock.Any(), "influx").Return(ErrJobRunning).Times(1)
+	assert.Equal(t, http.StatusConflict, serve("POST", "http://cloudtrust.io/jobs/influx/trigger").StatusCode)
+
+	// Trigger rate limited.
+	mockManager.EXPECT().Trigger(gomock.Any(), "influx").Return(errors.Wrap(ratelimit.ErrLimited, "rate limited")).Times(1)
+	assert.Equal(t, http.StatusTooManyRequests, serve("POST", "http://cloudtrust.io/jobs/influx/trigger").StatusCode)
+
+	// Pause.
+	mockManager.EXPECT().Pause(gomock.Any(), "influx").Return(nil).Times(1)
+	assert.Equal(t, http.StatusOK, serve("POST", "http://cloudtrust.io/jobs/influx/pause").StatusCode)
+
+	// Resume.
+	mockManager.EXPECT().Resume(gomock.Any(), "influx").Return(nil).Times(1)
+	assert.Equal(t, http.StatusOK, serve("POST", "http://cloudtrust.io/jobs/influx/resume").StatusCode)
+}
+
+func TestJobEndpointsWrongRequestType(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockManager = mock.NewJobManager(mockCtrl)
+
+	var _, err = MakeJobStatusEndpoint(mockManager)(context.Background(), 1)
+	assert.NotNil(t, err)
+	_, err = MakeTriggerJobEndpoint(mockManager)(context.Background(), 1)
+	assert.NotNil(t, err)
+	_, err = MakePauseJobEndpoint(mockManager)(context.Background(), 1)
+	assert.NotNil(t, err)
+	_, err = MakeResumeJobEndpoint(mockManager)(context.Background(), 1)
+	assert.NotNil(t, err)
+}
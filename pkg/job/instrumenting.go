@@ -0,0 +1,28 @@
+package job
+
+//go:generate mockgen -destination=./mock/instrumenting.go -package=mock -mock_names=Counter=Counter,Histogram=Histogram github.com/go-kit/kit/metrics Counter,Histogram
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+// MakeJobInstrumentingMW makes an Instrumenting middleware at job level. It records the
+// duration of each execution, labeled with the job name and its outcome.
+func MakeJobInstrumentingMW(h metrics.Histogram) Middleware {
+	return func(j *Job) *Job {
+		var runMW = func(next Run) Run {
+			return func(ctx context.Context) error {
+				var begin = time.Now()
+				var err = next(ctx)
+				var duration = time.Since(begin)
+
+				h.With("job", j.Name(), "outcome", outcome(err), "correlation_id", correlationID(ctx)).Observe(duration.Seconds())
+				return err
+			}
+		}
+		return j.wrap(runMW, nil)
+	}
+}
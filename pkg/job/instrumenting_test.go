@@ -0,0 +1,47 @@
+package job_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/cloudtrust/flaki-service/pkg/job"
+	"github.com/cloudtrust/flaki-service/pkg/job/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobInstrumentingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockHistogram = mock.NewHistogram(mockCtrl)
+
+	rand.Seed(time.Now().UnixNano())
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+	var ctx = context.WithValue(context.Background(), "correlation_id", corrID)
+
+	var success = MakeJobInstrumentingMW(mockHistogram)(NewJob("success", func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	}))
+	var failure = MakeJobInstrumentingMW(mockHistogram)(NewJob("failure", func(context.Context, interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("fail")
+	}))
+
+	// Success.
+	mockHistogram.EXPECT().With("job", "success", "outcome", "success", "correlation_id", corrID).Return(mockHistogram).Times(1)
+	mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+	assert.Nil(t, success.Run(ctx))
+
+	// Failure.
+	mockHistogram.EXPECT().With("job", "failure", "outcome", "failure", "correlation_id", corrID).Return(mockHistogram).Times(1)
+	mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+	assert.NotNil(t, failure.Run(ctx))
+
+	// Without correlation ID.
+	mockHistogram.EXPECT().With("job", "success", "outcome", "success", "correlation_id", "").Return(mockHistogram).Times(1)
+	mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+	assert.Nil(t, success.Run(context.Background()))
+}
@@ -0,0 +1,80 @@
+package job
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Step is a step of a job. The reply of a step is the input of the next one.
+type Step func(context.Context, interface{}) (interface{}, error)
+
+// Run is the execution of all the steps of a job.
+type Run func(context.Context) error
+
+// Middleware is a job middleware.
+type Middleware func(*Job) *Job
+
+// Job is a named sequence of steps.
+type Job struct {
+	name   string
+	steps  []Step
+	runMWs []func(Run) Run
+}
+
+// NewJob returns a job that executes the steps in order.
+func NewJob(name string, steps ...Step) *Job {
+	return &Job{
+		name:  name,
+		steps: steps,
+	}
+}
+
+// Name returns the name of the job.
+func (j *Job) Name() string {
+	return j.name
+}
+
+// Run executes the steps of the job in order. It stops at the first failing step.
+func (j *Job) Run(ctx context.Context) error {
+	var run Run = j.runSteps
+	for _, mw := range j.runMWs {
+		run = mw(run)
+	}
+	return run(ctx)
+}
+
+func (j *Job) runSteps(ctx context.Context) error {
+	var r interface{}
+	for i, step := range j.steps {
+		var err error
+		r, err = step(ctx, r)
+		if err != nil {
+			return errors.Wrapf(err, "job '%s' failed at step %d", j.name, i+1)
+		}
+	}
+	return nil
+}
+
+// wrap returns a copy of the job, where the execution is wrapped with 'runMW' and
+// each step with 'stepMW'. The step middleware receives the step number, starting at 1.
+// Nil middlewares are ignored.
+func (j *Job) wrap(runMW func(Run) Run, stepMW func(int, Step) Step) *Job {
+	var wrapped = &Job{
+		name:   j.name,
+		steps:  make([]Step, len(j.steps)),
+		runMWs: append([]func(Run) Run{}, j.runMWs...),
+	}
+
+	for i, step := range j.steps {
+		if stepMW != nil {
+			step = stepMW(i+1, step)
+		}
+		wrapped.steps[i] = step
+	}
+
+	if runMW != nil {
+		wrapped.runMWs = append(wrapped.runMWs, runMW)
+	}
+	return wrapped
+}
@@ -0,0 +1,37 @@
+package job_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/cloudtrust/flaki-service/pkg/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobRun(t *testing.T) {
+	var calls []interface{}
+	var step1 = func(_ context.Context, r interface{}) (interface{}, error) {
+		calls = append(calls, r)
+		return "step1", nil
+	}
+	var step2 = func(_ context.Context, r interface{}) (interface{}, error) {
+		calls = append(calls, r)
+		return nil, nil
+	}
+	var fail = func(_ context.Context, r interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("fail")
+	}
+
+	// Steps are executed in order, the reply of a step is the input of the next one.
+	var j = NewJob("test", step1, step2)
+	assert.Equal(t, "test", j.Name())
+	assert.Nil(t, j.Run(context.Background()))
+	assert.Equal(t, []interface{}{nil, "step1"}, calls)
+
+	// The job stops at the first failing step.
+	calls = nil
+	j = NewJob("test", fail, step1)
+	assert.NotNil(t, j.Run(context.Background()))
+	assert.Zero(t, len(calls))
+}
@@ -0,0 +1,33 @@
+package job
+
+//go:generate mockgen -destination=./mock/logging.go -package=mock -mock_names=Logger=Logger github.com/go-kit/kit/log Logger
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// MakeJobLoggingMW makes a logging middleware at job level. The executions are logged at info
+// level, the failed ones at error level.
+func MakeJobLoggingMW(logger log.Logger) Middleware {
+	return func(j *Job) *Job {
+		var runMW = func(next Run) Run {
+			return func(ctx context.Context) error {
+				var begin = time.Now()
+				var err = next(ctx)
+				var duration = time.Since(begin)
+
+				if err != nil {
+					level.Error(logger).Log("job", j.Name(), "correlation_id", correlationID(ctx), "outcome", outcome(err), "took", duration, "error", err.Error())
+				} else {
+					level.Info(logger).Log("job", j.Name(), "correlation_id", correlationID(ctx), "outcome", outcome(err), "took", duration)
+				}
+				return err
+			}
+		}
+		return j.wrap(runMW, nil)
+	}
+}
@@ -0,0 +1,41 @@
+package job_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/cloudtrust/flaki-service/pkg/job"
+	"github.com/cloudtrust/flaki-service/pkg/job/mock"
+	"github.com/go-kit/kit/log/level"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobLoggingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockLogger = mock.NewLogger(mockCtrl)
+
+	rand.Seed(time.Now().UnixNano())
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+	var ctx = context.WithValue(context.Background(), "correlation_id", corrID)
+
+	var success = MakeJobLoggingMW(mockLogger)(NewJob("success", func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	}))
+	var failure = MakeJobLoggingMW(mockLogger)(NewJob("failure", func(context.Context, interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("fail")
+	}))
+
+	// Success.
+	mockLogger.EXPECT().Log(level.Key(), level.InfoValue(), "job", "success", "correlation_id", corrID, "outcome", "success", "took", gomock.Any()).Return(nil).Times(1)
+	assert.Nil(t, success.Run(ctx))
+
+	// Failure.
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "job", "failure", "correlation_id", corrID, "outcome", "failure", "took", gomock.Any(), "error", "job 'failure' failed at step 1: fail").Return(nil).Times(1)
+	assert.NotNil(t, failure.Run(ctx))
+}
@@ -0,0 +1,299 @@
+package job
+
+//go:generate mockgen -destination=./mock/manager.go -package=mock -mock_names=Controller=Controller,StatusStorage=StatusStorage github.com/cloudtrust/flaki-service/pkg/job Controller,StatusStorage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	gojobs "github.com/cloudtrust/go-jobs/job"
+	"github.com/pkg/errors"
+)
+
+// ErrUnknownJob is returned when the requested job is not registered.
+var ErrUnknownJob = fmt.Errorf("unknown job")
+
+// ErrJobRunning is returned when a job is triggered while it is already running.
+var ErrJobRunning = fmt.Errorf("job already running")
+
+// Controller is the interface of the go-jobs controller.
+type Controller interface {
+	Register(j *gojobs.Job)
+	Schedule(cronExpression string, jobName string) error
+}
+
+// StatusStorage is the interface of the go-jobs status storage.
+type StatusStorage interface {
+	GetStatus(componentName, jobName string) (map[string]string, error)
+}
+
+// JobInfo describes a registered job.
+type JobInfo struct {
+	Name          string            `json:"name"`
+	Schedule      string            `json:"schedule"`
+	Paused        bool              `json:"paused"`
+	Running       bool              `json:"running"`
+	LastExecution *Execution        `json:"last_execution,omitempty"`
+	Status        map[string]string `json:"status,omitempty"`
+}
+
+// Execution describes an execution of a job, either scheduled or triggered manually. The
+// manual executions do not go through the go-jobs controller, so they are only recorded
+// here and not in the status storage.
+type Execution struct {
+	Manual bool      `json:"manual"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// Manager registers the jobs in the go-jobs controller and keeps track of them, so they
+// can be listed, triggered, paused and resumed at runtime.
+type Manager struct {
+	componentName string
+	controller    Controller
+	status        StatusStorage
+
+	mu   sync.Mutex
+	jobs map[string]*managedJob
+}
+
+type managedJob struct {
+	job      *Job
+	schedule string
+	paused   bool
+	running  bool
+	last     *Execution
+
+	// The job is registered in the controller as 'controllerName'. Only the executions of
+	// the current generation run, the generation is incremented by each reschedule.
+	controllerName string
+	generation     int
+	lastGeneration int
+}
+
+// NewManager returns a job manager.
+func NewManager(componentName string, controller Controller, status StatusStorage) *Manager {
+	return &Manager{
+		componentName: componentName,
+		controller:    controller,
+		status:        status,
+		jobs:          make(map[string]*managedJob),
+	}
+}
+
+// Register registers the job in the controller and schedules it with the cron expression 'schedule'.
+func (m *Manager) Register(j *Job, schedule string) error {
+	m.mu.Lock()
+	m.jobs[j.Name()] = &managedJob{
+		job:            j,
+		schedule:       schedule,
+		controllerName: j.Name(),
+	}
+	m.mu.Unlock()
+
+	var err = m.schedule(j.Name(), j.Name(), 0, schedule)
+	if err != nil {
+		m.mu.Lock()
+		delete(m.jobs, j.Name())
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Reschedule changes the cron expression of a registered job. The go-jobs controller cannot
+// unschedule a job, so the job is registered again under a new name, e.g. "influx-1", and the
+// executions triggered by the previous schedule are skipped. On failure, the job keeps its
+// previous schedule.
+func (m *Manager) Reschedule(name, schedule string) error {
+	m.mu.Lock()
+	var j, ok = m.jobs[name]
+	if !ok {
+		m.mu.Unlock()
+		return ErrUnknownJob
+	}
+	if j.schedule == schedule {
+		m.mu.Unlock()
+		return nil
+	}
+	j.lastGeneration++
+	var generation = j.lastGeneration
+	m.mu.Unlock()
+
+	var controllerName = fmt.Sprintf("%s-%d", name, generation)
+	var err = m.schedule(name, controllerName, generation, schedule)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// A concurrent reschedule may have already replaced this one.
+	if generation > j.generation {
+		j.generation = generation
+		j.schedule = schedule
+		j.controllerName = controllerName
+	}
+	return nil
+}
+
+// schedule registers the job 'name' in the controller as 'controllerName' and schedules it.
+// The scheduled executions are skipped once the job generation is no longer 'generation'.
+func (m *Manager) schedule(name, controllerName string, generation int, schedule string) error {
+	// The controller executes the job through the manager, so paused jobs are skipped and
+	// scheduled executions do not overlap with manual ones.
+	var run = func(ctx context.Context, _ interface{}) (interface{}, error) {
+		m.mu.Lock()
+		var current = m.jobs[name].generation == generation
+		m.mu.Unlock()
+
+		if !current {
+			return nil, nil
+		}
+		return nil, m.run(ctx, name, false)
+	}
+
+	var controllerJob, err = gojobs.NewJob(controllerName, gojobs.Steps(run))
+	if err != nil {
+		return errors.Wrapf(err, "could not create job '%s'", name)
+	}
+
+	m.controller.Register(controllerJob)
+
+	err = m.controller.Schedule(schedule, controllerName)
+	if err != nil {
+		return errors.Wrapf(err, "could not schedule job '%s' with '%s'", name, schedule)
+	}
+	return nil
+}
+
+// List returns the registered jobs, sorted by name.
+func (m *Manager) List(_ context.Context) []JobInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var infos = []JobInfo{}
+	for _, j := range m.jobs {
+		infos = append(infos, j.info())
+	}
+
+	sort.Slice(infos, func(i, k int) bool { return infos[i].Name < infos[k].Name })
+	return infos
+}
+
+// Status returns the job and its last execution status read from the status storage.
+func (m *Manager) Status(_ context.Context, name string) (JobInfo, error) {
+	m.mu.Lock()
+	var j, ok = m.jobs[name]
+	var info JobInfo
+	var controllerName string
+	if ok {
+		info = j.info()
+		controllerName = j.controllerName
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return JobInfo{}, ErrUnknownJob
+	}
+
+	var status, err = m.status.GetStatus(m.componentName, controllerName)
+	if err != nil {
+		return JobInfo{}, errors.Wrapf(err, "could not read status of job '%s'", name)
+	}
+	info.Status = status
+
+	return info, nil
+}
+
+// Trigger executes the job immediately, even if it is paused.
+func (m *Manager) Trigger(ctx context.Context, name string) error {
+	return m.run(ctx, name, true)
+}
+
+// Pause stops the scheduled executions of the job until it is resumed.
+func (m *Manager) Pause(_ context.Context, name string) error {
+	return m.setPaused(name, true)
+}
+
+// Resume restarts the scheduled executions of a paused job.
+func (m *Manager) Resume(_ context.Context, name string) error {
+	return m.setPaused(name, false)
+}
+
+func (m *Manager) setPaused(name string, paused bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var j, ok = m.jobs[name]
+	if !ok {
+		return ErrUnknownJob
+	}
+	j.paused = paused
+	return nil
+}
+
+// run executes the job. A scheduled execution (manual=false) is silently skipped if the
+// job is paused or already running.
+func (m *Manager) run(ctx context.Context, name string, manual bool) error {
+	m.mu.Lock()
+	var j, ok = m.jobs[name]
+	switch {
+	case !ok:
+		m.mu.Unlock()
+		return ErrUnknownJob
+	case j.running && manual:
+		m.mu.Unlock()
+		return ErrJobRunning
+	case j.running, j.paused && !manual:
+		m.mu.Unlock()
+		return nil
+	}
+	j.running = true
+	var execution = &Execution{Manual: manual, Start: time.Now()}
+	j.last = execution
+	m.mu.Unlock()
+
+	// The execution is recorded even if the job panics, so the job is not left running. The
+	// panic goes on to the recovery middlewares.
+	var err error
+	defer func() {
+		var r = recover()
+
+		m.mu.Lock()
+		j.running = false
+		execution.End = time.Now()
+		switch {
+		case r != nil:
+			execution.Error = fmt.Sprintf("panic: %v", r)
+		case err != nil:
+			execution.Error = err.Error()
+		}
+		m.mu.Unlock()
+
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	err = j.job.Run(ctx)
+	return err
+}
+
+func (j *managedJob) info() JobInfo {
+	var info = JobInfo{
+		Name:     j.job.Name(),
+		Schedule: j.schedule,
+		Paused:   j.paused,
+		Running:  j.running,
+	}
+	if j.last != nil {
+		var last = *j.last
+		info.LastExecution = &last
+	}
+	return info
+}
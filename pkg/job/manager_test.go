@@ -0,0 +1,195 @@
+package job_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/cloudtrust/flaki-service/pkg/job"
+	"github.com/cloudtrust/flaki-service/pkg/job/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockController = mock.NewController(mockCtrl)
+	var mockStatus = mock.NewStatusStorage(mockCtrl)
+
+	var m = NewManager("flaki-service", mockController, mockStatus)
+
+	var runs = 0
+	var j = NewJob("influx", func(context.Context, interface{}) (interface{}, error) {
+		runs++
+		return nil, nil
+	})
+
+	// Register.
+	mockController.EXPECT().Register(gomock.Any()).Times(1)
+	mockController.EXPECT().Schedule("@minutely", "influx").Return(nil).Times(1)
+	assert.Nil(t, m.Register(j, "@minutely"))
+
+	// List.
+	assert.Equal(t, []JobInfo{{Name: "influx", Schedule: "@minutely"}}, m.List(context.Background()))
+
+	// Status.
+	var status = map[string]string{"message": "completed"}
+	mockStatus.EXPECT().GetStatus("flaki-service", "influx").Return(status, nil).Times(1)
+	{
+		var info, err = m.Status(context.Background(), "influx")
+		assert.Nil(t, err)
+		assert.Equal(t, status, info.Status)
+	}
+
+	// Status fail.
+	mockStatus.EXPECT().GetStatus("flaki-service", "influx").Return(nil, fmt.Errorf("fail")).Times(1)
+	{
+		var _, err = m.Status(context.Background(), "influx")
+		assert.NotNil(t, err)
+	}
+
+	// Trigger.
+	assert.Nil(t, m.Trigger(context.Background(), "influx"))
+	assert.Equal(t, 1, runs)
+
+	// The manual execution is in the job status.
+	mockStatus.EXPECT().GetStatus("flaki-service", "influx").Return(status, nil).Times(1)
+	{
+		var info, err = m.Status(context.Background(), "influx")
+		assert.Nil(t, err)
+		assert.NotNil(t, info.LastExecution)
+		assert.True(t, info.LastExecution.Manual)
+		assert.False(t, info.LastExecution.End.Before(info.LastExecution.Start))
+		assert.Zero(t, info.LastExecution.Error)
+	}
+
+	// Pause and resume.
+	assert.Nil(t, m.Pause(context.Background(), "influx"))
+	assert.True(t, m.List(context.Background())[0].Paused)
+	assert.Nil(t, m.Resume(context.Background(), "influx"))
+	assert.False(t, m.List(context.Background())[0].Paused)
+
+	// Unknown job.
+	{
+		var _, err = m.Status(context.Background(), "unknown")
+		assert.Equal(t, ErrUnknownJob, err)
+	}
+	assert.Equal(t, ErrUnknownJob, m.Trigger(context.Background(), "unknown"))
+	assert.Equal(t, ErrUnknownJob, m.Pause(context.Background(), "unknown"))
+	assert.Equal(t, ErrUnknownJob, m.Resume(context.Background(), "unknown"))
+}
+
+func TestManagerTriggerFail(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockController = mock.NewController(mockCtrl)
+	var mockStatus = mock.NewStatusStorage(mockCtrl)
+
+	var m = NewManager("flaki-service", mockController, mockStatus)
+
+	var j = NewJob("influx", func(context.Context, interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("fail")
+	})
+
+	mockController.EXPECT().Register(gomock.Any()).Times(1)
+	mockController.EXPECT().Schedule("@minutely", "influx").Return(nil).Times(1)
+	assert.Nil(t, m.Register(j, "@minutely"))
+
+	assert.NotNil(t, m.Trigger(context.Background(), "influx"))
+
+	var last = m.List(context.Background())[0].LastExecution
+	assert.NotNil(t, last)
+	assert.True(t, last.Manual)
+	assert.Contains(t, last.Error, "fail")
+}
+
+func TestManagerScheduleFail(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockController = mock.NewController(mockCtrl)
+	var mockStatus = mock.NewStatusStorage(mockCtrl)
+
+	var m = NewManager("flaki-service", mockController, mockStatus)
+
+	mockController.EXPECT().Register(gomock.Any()).Times(1)
+	mockController.EXPECT().Schedule("invalid", "influx").Return(fmt.Errorf("fail")).Times(1)
+	assert.NotNil(t, m.Register(NewJob("influx"), "invalid"))
+
+	// The job is not registered.
+	assert.Empty(t, m.List(context.Background()))
+	assert.Equal(t, ErrUnknownJob, m.Trigger(context.Background(), "influx"))
+}
+
+func TestManagerTriggerPanic(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockController = mock.NewController(mockCtrl)
+	var mockStatus = mock.NewStatusStorage(mockCtrl)
+
+	var m = NewManager("flaki-service", mockController, mockStatus)
+
+	var panics = true
+	var j = NewJob("influx", func(context.Context, interface{}) (interface{}, error) {
+		if panics {
+			panic("job panic")
+		}
+		return nil, nil
+	})
+
+	mockController.EXPECT().Register(gomock.Any()).Times(1)
+	mockController.EXPECT().Schedule("@minutely", "influx").Return(nil).Times(1)
+	assert.Nil(t, m.Register(j, "@minutely"))
+
+	// The panic is not recovered by the manager.
+	assert.Panics(t, func() { m.Trigger(context.Background(), "influx") })
+
+	// The job is not left running, and the panic is recorded.
+	var info = m.List(context.Background())[0]
+	assert.False(t, info.Running)
+	assert.NotNil(t, info.LastExecution)
+	assert.False(t, info.LastExecution.End.IsZero())
+	assert.Contains(t, info.LastExecution.Error, "job panic")
+
+	// The job can run again.
+	panics = false
+	assert.Nil(t, m.Trigger(context.Background(), "influx"))
+	assert.Zero(t, m.List(context.Background())[0].LastExecution.Error)
+}
+
+func TestManagerReschedule(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockController = mock.NewController(mockCtrl)
+	var mockStatus = mock.NewStatusStorage(mockCtrl)
+
+	var m = NewManager("flaki-service", mockController, mockStatus)
+
+	mockController.EXPECT().Register(gomock.Any()).Times(1)
+	mockController.EXPECT().Schedule("@minutely", "influx").Return(nil).Times(1)
+	assert.Nil(t, m.Register(NewJob("influx"), "@minutely"))
+
+	// Same schedule.
+	assert.Nil(t, m.Reschedule("influx", "@minutely"))
+
+	// The job is registered again in the controller under a new name.
+	mockController.EXPECT().Register(gomock.Any()).Times(1)
+	mockController.EXPECT().Schedule("@hourly", "influx-1").Return(nil).Times(1)
+	assert.Nil(t, m.Reschedule("influx", "@hourly"))
+	assert.Equal(t, []JobInfo{{Name: "influx", Schedule: "@hourly"}}, m.List(context.Background()))
+
+	mockStatus.EXPECT().GetStatus("flaki-service", "influx-1").Return(nil, nil).Times(1)
+	{
+		var _, err = m.Status(context.Background(), "influx")
+		assert.Nil(t, err)
+	}
+
+	// Invalid schedule, the previous one is kept.
+	mockController.EXPECT().Register(gomock.Any()).Times(1)
+	mockController.EXPECT().Schedule("invalid", "influx-2").Return(fmt.Errorf("fail")).Times(1)
+	assert.NotNil(t, m.Reschedule("influx", "invalid"))
+	assert.Equal(t, "@hourly", m.List(context.Background())[0].Schedule)
+
+	// Unknown job.
+	assert.Equal(t, ErrUnknownJob, m.Reschedule("unknown", "@hourly"))
+}
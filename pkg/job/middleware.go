@@ -0,0 +1,45 @@
+package job
+
+//go:generate mockgen -destination=./mock/idGenerator.go -package=mock -mock_names=IDGenerator=IDGenerator github.com/cloudtrust/flaki-service/pkg/job IDGenerator
+
+import (
+	"context"
+)
+
+// IDGenerator is the interface of the distributed unique IDs generator.
+type IDGenerator interface {
+	NextValidID(context.Context) string
+}
+
+// MakeJobCorrelationIDMW makes a middleware that adds a correlation ID in the
+// context of each job execution, if there is not already one.
+func MakeJobCorrelationIDMW(g IDGenerator) Middleware {
+	return func(j *Job) *Job {
+		var runMW = func(next Run) Run {
+			return func(ctx context.Context) error {
+				// If there is no correlation ID in the context, request one.
+				if ctx.Value("correlation_id") == nil {
+					ctx = context.WithValue(ctx, "correlation_id", g.NextValidID(ctx))
+				}
+				return next(ctx)
+			}
+		}
+		return j.wrap(runMW, nil)
+	}
+}
+
+// correlationID returns the correlation ID of the context, or the empty string if there is none.
+func correlationID(ctx context.Context) string {
+	if id, ok := ctx.Value("correlation_id").(string); ok {
+		return id
+	}
+	return ""
+}
+
+// outcome returns the outcome of a job execution, as reported in the logs and metrics.
+func outcome(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
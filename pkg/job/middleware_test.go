@@ -0,0 +1,45 @@
+package job_test
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/cloudtrust/flaki-service/pkg/job"
+	"github.com/cloudtrust/flaki-service/pkg/job/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobCorrelationIDMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockIDGenerator = mock.NewIDGenerator(mockCtrl)
+
+	rand.Seed(time.Now().UnixNano())
+	var flakiID = strconv.FormatUint(rand.Uint64(), 10)
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+	var ctx = context.WithValue(context.Background(), "correlation_id", corrID)
+
+	var stepCorrID interface{}
+	var j = NewJob("test", func(ctx context.Context, _ interface{}) (interface{}, error) {
+		stepCorrID = ctx.Value("correlation_id")
+		return nil, nil
+	})
+	var m = MakeJobCorrelationIDMW(mockIDGenerator)(j)
+
+	// Context with correlation ID.
+	assert.Nil(t, m.Run(ctx))
+	assert.Equal(t, corrID, stepCorrID)
+
+	// Without correlation ID.
+	mockIDGenerator.EXPECT().NextValidID(gomock.Any()).Return(flakiID).Times(1)
+	assert.Nil(t, m.Run(context.Background()))
+	assert.Equal(t, flakiID, stepCorrID)
+
+	// The middleware does not modify the original job.
+	assert.Nil(t, j.Run(context.Background()))
+	assert.Nil(t, stepCorrID)
+}
@@ -0,0 +1,60 @@
+package job
+
+//go:generate mockgen -destination=./mock/tracing.go -package=mock -mock_names=Tracer=Tracer,Span=Span,SpanContext=SpanContext github.com/opentracing/opentracing-go Tracer,Span,SpanContext
+
+import (
+	"context"
+	"fmt"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	otag "github.com/opentracing/opentracing-go/ext"
+)
+
+// MakeJobTracingMW makes a tracing middleware at job level. Each execution opens a span
+// for the job, and a child span for each of its steps.
+func MakeJobTracingMW(tracer opentracing.Tracer, componentName string) Middleware {
+	return func(j *Job) *Job {
+		var runMW = func(next Run) Run {
+			return func(ctx context.Context) error {
+				// Manual executions continue the trace of the request, if any.
+				var span opentracing.Span
+				if parent := opentracing.SpanFromContext(ctx); parent != nil {
+					span = tracer.StartSpan(fmt.Sprintf("%s_job", j.Name()), opentracing.ChildOf(parent.Context()))
+				} else {
+					span = tracer.StartSpan(fmt.Sprintf("%s_job", j.Name()))
+				}
+				defer span.Finish()
+
+				// Set tags.
+				otag.Component.Set(span, componentName)
+				span.SetTag("job", j.Name())
+				span.SetTag("correlation_id", correlationID(ctx))
+
+				var err = next(opentracing.ContextWithSpan(ctx, span))
+				if err != nil {
+					otag.Error.Set(span, true)
+					span.LogKV("error", err.Error())
+				}
+				return err
+			}
+		}
+
+		var stepMW = func(i int, next Step) Step {
+			return func(ctx context.Context, r interface{}) (interface{}, error) {
+				if span := opentracing.SpanFromContext(ctx); span != nil {
+					span = tracer.StartSpan(fmt.Sprintf("%s_job_step_%d", j.Name(), i), opentracing.ChildOf(span.Context()))
+					defer span.Finish()
+
+					var rep, err = next(opentracing.ContextWithSpan(ctx, span), r)
+					if err != nil {
+						otag.Error.Set(span, true)
+						span.LogKV("error", err.Error())
+					}
+					return rep, err
+				}
+				return next(ctx, r)
+			}
+		}
+		return j.wrap(runMW, stepMW)
+	}
+}
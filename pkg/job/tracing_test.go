@@ -0,0 +1,54 @@
+package job_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/cloudtrust/flaki-service/pkg/job"
+	"github.com/cloudtrust/flaki-service/pkg/job/mock"
+	"github.com/golang/mock/gomock"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobTracingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockTracer = mock.NewTracer(mockCtrl)
+	var mockSpan = mock.NewSpan(mockCtrl)
+	var mockSpanContext = mock.NewSpanContext(mockCtrl)
+	mockSpan.EXPECT().Tracer().Return(mockTracer).AnyTimes()
+
+	var step = func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+	var fail = func(context.Context, interface{}) (interface{}, error) { return nil, fmt.Errorf("fail") }
+
+	// Success: one span for the job and one per step.
+	var m = MakeJobTracingMW(mockTracer, "flaki-service")(NewJob("influx", step, step))
+	mockTracer.EXPECT().StartSpan("influx_job").Return(mockSpan).Times(1)
+	mockTracer.EXPECT().StartSpan("influx_job_step_1", gomock.Any()).Return(mockSpan).Times(1)
+	mockTracer.EXPECT().StartSpan("influx_job_step_2", gomock.Any()).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(2)
+	mockSpan.EXPECT().Finish().Return().Times(3)
+	mockSpan.EXPECT().SetTag(gomock.Any(), gomock.Any()).Return(mockSpan).Times(3)
+	assert.Nil(t, m.Run(context.Background()))
+
+	// Failure: the error is recorded on the step span and on the job span.
+	m = MakeJobTracingMW(mockTracer, "flaki-service")(NewJob("influx", fail))
+	mockTracer.EXPECT().StartSpan("influx_job").Return(mockSpan).Times(1)
+	mockTracer.EXPECT().StartSpan("influx_job_step_1", gomock.Any()).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
+	mockSpan.EXPECT().Finish().Return().Times(2)
+	mockSpan.EXPECT().SetTag(gomock.Any(), gomock.Any()).Return(mockSpan).Times(3)
+	mockSpan.EXPECT().SetTag("error", true).Return(mockSpan).Times(2)
+	mockSpan.EXPECT().LogKV("error", gomock.Any()).Return().Times(2)
+	assert.NotNil(t, m.Run(context.Background()))
+
+	// Manual execution with an existing span.
+	m = MakeJobTracingMW(mockTracer, "flaki-service")(NewJob("influx"))
+	mockTracer.EXPECT().StartSpan("influx_job", gomock.Any()).Return(mockSpan).Times(1)
+	mockSpan.EXPECT().Context().Return(mockSpanContext).Times(1)
+	mockSpan.EXPECT().Finish().Return().Times(1)
+	mockSpan.EXPECT().SetTag(gomock.Any(), gomock.Any()).Return(mockSpan).Times(3)
+	assert.Nil(t, m.Run(opentracing.ContextWithSpan(context.Background(), mockSpan)))
+}
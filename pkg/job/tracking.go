@@ -0,0 +1,50 @@
+package job
+
+//go:generate mockgen -destination=./mock/tracking.go -package=mock -mock_names=ErrorTracker=ErrorTracker github.com/cloudtrust/flaki-service/pkg/job ErrorTracker
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// ErrorTracker is the interface of the error tracking provider.
+type ErrorTracker interface {
+	CaptureError(err error, tags map[string]string) string
+}
+
+// MakeJobTrackingMW makes an error tracking middleware at job level. A job that fails
+// 'threshold' times in a row is logged and reported to the error tracker. If it keeps failing, it
+// is reported again every 'threshold' failures. A successful execution resets the count.
+func MakeJobTrackingMW(tracker ErrorTracker, threshold int, logger log.Logger) Middleware {
+	return func(j *Job) *Job {
+		var mu sync.Mutex
+		var failures = 0
+
+		var runMW = func(next Run) Run {
+			return func(ctx context.Context) error {
+				var err = next(ctx)
+
+				mu.Lock()
+				if err == nil {
+					failures = 0
+				} else {
+					failures++
+				}
+				var consecutiveFailures = failures
+				mu.Unlock()
+
+				if err != nil && threshold > 0 && consecutiveFailures%threshold == 0 {
+					var corrID = correlationID(ctx)
+					tracker.CaptureError(err, map[string]string{"job": j.Name(), "correlation_id": corrID, "consecutive_failures": strconv.Itoa(consecutiveFailures)})
+					level.Error(logger).Log("job", j.Name(), "correlation_id", corrID, "consecutive_failures", consecutiveFailures, "error", err.Error())
+				}
+				return err
+			}
+		}
+		return j.wrap(runMW, nil)
+	}
+}
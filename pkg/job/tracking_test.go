@@ -0,0 +1,46 @@
+package job_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/cloudtrust/flaki-service/pkg/job"
+	"github.com/cloudtrust/flaki-service/pkg/job/mock"
+	"github.com/go-kit/kit/log/level"
+	"github.com/golang/mock/gomock"
+)
+
+func TestJobTrackingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockTracker = mock.NewErrorTracker(mockCtrl)
+	var mockLogger = mock.NewLogger(mockCtrl)
+
+	rand.Seed(time.Now().UnixNano())
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+	var ctx = context.WithValue(context.Background(), "correlation_id", corrID)
+
+	var err error
+	var m = MakeJobTrackingMW(mockTracker, 2, mockLogger)(NewJob("influx", func(context.Context, interface{}) (interface{}, error) {
+		return nil, err
+	}))
+
+	// A single failure is not reported.
+	err = fmt.Errorf("fail")
+	m.Run(ctx)
+
+	// The second consecutive failure is reported.
+	mockTracker.EXPECT().CaptureError(gomock.Any(), map[string]string{"job": "influx", "correlation_id": corrID, "consecutive_failures": "2"}).Return("").Times(1)
+	mockLogger.EXPECT().Log(level.Key(), level.ErrorValue(), "job", "influx", "correlation_id", corrID, "consecutive_failures", 2, "error", gomock.Any()).Return(nil).Times(1)
+	m.Run(ctx)
+
+	// A success resets the count.
+	err = nil
+	m.Run(ctx)
+	err = fmt.Errorf("fail")
+	m.Run(ctx)
+}